@@ -6,16 +6,15 @@
 package custom
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/xml"
+	"log"
 	"time"
 
-	"reflect"
-	"unsafe"
-
 	"github.com/gogo/protobuf/types"
 	errors "golang.org/x/xerrors"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var _ = xml.Unmarshaler((*DateTime)(nil))
@@ -25,32 +24,14 @@ type DateTime struct {
 	time.Time
 }
 
-func getWriter(enc *xml.Encoder) *bufio.Writer {
-	rEnc := reflect.ValueOf(enc)
-	rP := rEnc.Elem().FieldByName("p").Addr()
-	return *(**bufio.Writer)(unsafe.Pointer(rP.Elem().FieldByName("Writer").UnsafeAddr()))
-}
-
 func (dt DateTime) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
 	//fmt.Printf("Marshal %v: %v\n", start.Name.Local, dt.Time.Format(time.RFC3339))
 	if dt.Time.IsZero() {
+		// with the xsi prefix, as the encoder would name the namespace XMLSchema-instance
 		start.Attr = append(start.Attr,
-			xml.Attr{Name: xml.Name{Space: "http://www.w3.org/2001/XMLSchema-instance", Local: "nil"}, Value: "true"})
-
-		bw := getWriter(enc)
-		bw.Flush()
-		old := *bw
-		var buf bytes.Buffer
-		*bw = *bufio.NewWriter(&buf)
-		if err := enc.EncodeElement("", start); err != nil {
-			return err
-		}
-		b := bytes.ReplaceAll(bytes.ReplaceAll(buf.Bytes(),
-			[]byte("XMLSchema-instance:"), []byte("xsi:")),
-			[]byte("xmlns:XMLSchema-instance="), []byte("xmlns:xsi="))
-		*bw = old
-		bw.Write(b)
-		return bw.Flush()
+			xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: "http://www.w3.org/2001/XMLSchema-instance"},
+			xml.Attr{Name: xml.Name{Local: "xsi:nil"}, Value: "true"})
+		return enc.EncodeElement("", start)
 	}
 	return enc.EncodeElement(dt.Time.In(time.Local).Format(time.RFC3339), start)
 }
@@ -140,3 +121,60 @@ func (dt *DateTime) Unmarshal(dAtA []byte) error {
 	dt.Time, err = types.TimestampFromProto(&ts)
 	return err
 }
+
+// TimestampProto returns the google.golang.org/protobuf (APIv2) Timestamp of dt,
+// nil for the zero time.
+func (dt DateTime) TimestampProto() *timestamppb.Timestamp { return AsTimestamp(dt.Time) }
+
+// AsTimestamp returns the APIv2 Timestamp of v (anything AsTime accepts),
+// nil for the zero time - as the generated APIv2 messages need.
+func AsTimestamp(v interface{}) *timestamppb.Timestamp {
+	t := AsTime(v)
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// TimeFromTimestamp returns the local time of the APIv2 Timestamp, the zero time for nil.
+func TimeFromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime().In(time.Local)
+}
+
+// AsDuration returns v as a time.Duration: v can be a time.Duration, an APIv2 Duration,
+// a string time.ParseDuration accepts or a number of nanoseconds - 0 for anything else.
+func AsDuration(v interface{}) time.Duration {
+	switch x := v.(type) {
+	case time.Duration:
+		return x
+	case *time.Duration:
+		if x != nil {
+			return *x
+		}
+	case *durationpb.Duration:
+		return x.AsDuration()
+	case int64:
+		return time.Duration(x)
+	case string:
+		d, err := time.ParseDuration(x)
+		if err != nil {
+			log.Printf("ERROR parsing %q as Duration: %v", x, err)
+		}
+		return d
+	case nil:
+	default:
+		log.Printf("WARN: unknown Duration type %T", v)
+	}
+	return 0
+}
+
+// AsDurationProto returns the APIv2 Duration of v (anything AsDuration accepts), nil for nil.
+func AsDurationProto(v interface{}) *durationpb.Duration {
+	if v == nil {
+		return nil
+	}
+	return durationpb.New(AsDuration(v))
+}
@@ -8,6 +8,9 @@ import (
 )
 
 func TestDateTimeMarshalXML(t *testing.T) {
+	// the wanted output is in CEST
+	defer func(old *time.Location) { time.Local = old }(time.Local)
+	time.Local = time.FixedZone("CEST", 2*3600)
 	var buf strings.Builder
 	enc := xml.NewEncoder(&buf)
 	st := xml.StartElement{Name: xml.Name{Local: "element"}}
@@ -20,10 +23,66 @@ func TestDateTimeMarshalXML(t *testing.T) {
 	} {
 		buf.Reset()
 		if err := tC.In.MarshalXML(enc, st); err != nil {
-			t.Fatalf("%v: %w", tC.In, err)
+			t.Fatalf("%v: %+v", tC.In, err)
 		}
 		if got := buf.String(); tC.Want != got {
 			t.Errorf("%v: got %q wanted %q", tC.In, got, tC.Want)
 		}
 	}
 }
+
+func TestTimestamp(t *testing.T) {
+	if ts := AsTimestamp(time.Time{}); ts != nil {
+		t.Errorf("zero time: got %v, wanted nil", ts)
+	}
+	if tm := TimeFromTimestamp(nil); !tm.IsZero() {
+		t.Errorf("nil: got %v, wanted the zero time", tm)
+	}
+	want := time.Date(2019, 10, 22, 16, 56, 32, 0, time.Local)
+	for _, v := range []interface{}{want, &want, DateTime{Time: want}, want.Format(time.RFC3339)} {
+		ts := AsTimestamp(v)
+		if got := TimeFromTimestamp(ts); !got.Equal(want) {
+			t.Errorf("%T: got %v, wanted %v", v, got, want)
+		}
+		if got := AsTime(ts); !got.Equal(want) {
+			t.Errorf("AsTime(%v): got %v, wanted %v", ts, got, want)
+		}
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+	for _, tC := range []struct {
+		In   string
+		Want time.Time
+	}{
+		{In: "2019-10-22T16:56:32Z", Want: time.Date(2019, 10, 22, 16, 56, 32, 0, time.UTC)},
+		{In: "2019-10-22T16:56:32.5-05:00", Want: time.Date(2019, 10, 22, 21, 56, 32, 5e8, time.UTC)},
+		{In: "2019-10-22 16:56:32", Want: time.Date(2019, 10, 22, 16, 56, 32, 0, time.Local)},
+		{In: "2019-10-22", Want: time.Date(2019, 10, 22, 0, 0, 0, 0, time.Local)},
+	} {
+		var got time.Time
+		if err := ParseTime(&got, tC.In); err != nil {
+			t.Errorf("%q: %+v", tC.In, err)
+		} else if !got.Equal(tC.Want) {
+			t.Errorf("%q: got %v, wanted %v", tC.In, got, tC.Want)
+		}
+	}
+	var got time.Time
+	if err := ParseTime(&got, "2019-10-22T16:56:32-05:00"); err != nil {
+		t.Fatal(err)
+	} else if _, offset := got.Zone(); offset != -5*3600 {
+		t.Errorf("the zone is lost: %v", got)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	want := 36*time.Hour + 1500*time.Millisecond
+	for _, v := range []interface{}{want, &want, AsDurationProto(want), int64(want), want.String()} {
+		if got := AsDuration(v); got != want {
+			t.Errorf("%T: got %v, wanted %v", v, got, want)
+		}
+	}
+	if d := AsDurationProto(nil); d != nil {
+		t.Errorf("nil: got %v, wanted nil", d)
+	}
+}
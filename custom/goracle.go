@@ -13,8 +13,9 @@ import (
 	"time"
 	"unsafe"
 
-	errors "golang.org/x/xerrors"
 	"github.com/godror/godror"
+	errors "golang.org/x/xerrors"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var ZeroIsAlmostZero bool
@@ -60,6 +61,8 @@ func NumbersFromStrings(s *[]string) *[]godror.Number {
 
 const timeFormat = time.RFC3339
 
+// ParseTime parses s as an RFC3339 time, in its zone if it has one ("Z" or "+01:00"),
+// in time.Local otherwise. The time part, or its end can be missing.
 func ParseTime(t *time.Time, s string) error {
 	if s == "" {
 		*t = time.Time{}
@@ -73,6 +76,13 @@ func ParseTime(t *time.Time, s string) error {
 			s = s[:i] + "T" + s[i+1:]
 		}
 	}
+	if hasZone(s) {
+		var err error
+		if *t, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return errors.Errorf("%s: %w", s, err)
+		}
+		return nil
+	}
 
 	n := len(s)
 	if n > len(timeFormat) {
@@ -86,6 +96,16 @@ func ParseTime(t *time.Time, s string) error {
 	return nil
 }
 
+// hasZone reports whether the time s ends with a zone: "Z" or a +hh:mm offset.
+func hasZone(s string) bool {
+	if strings.HasSuffix(s, "Z") {
+		return true
+	}
+	n := len(s)
+	return n > len("T00:00:00+00:00") && (s[n-6] == '+' || s[n-6] == '-') && s[n-3] == ':' &&
+		strings.IndexByte(s, 'T') < n-6
+}
+
 type Lob struct {
 	*godror.Lob
 	data []byte
@@ -156,6 +176,8 @@ func AsString(v interface{}) string {
 	switch x := v.(type) {
 	case string:
 		return x
+	case []byte:
+		return string(x)
 	case Number:
 		return string(x)
 	case sql.NullString:
@@ -182,11 +204,13 @@ func AsFloat64(v interface{}) float64 {
 		result = float64(x)
 	case sql.NullFloat64:
 		result = x.Float64
-	case string, godror.Number:
+	case string, []byte, godror.Number:
 		var s string
 		switch x := x.(type) {
 		case string:
 			s = x
+		case []byte:
+			s = string(x)
 		case godror.Number:
 			s = string(x)
 		}
@@ -215,6 +239,11 @@ func AsInt32(v interface{}) int32 {
 	switch x := v.(type) {
 	case int32:
 		return x
+	case bool:
+		if x {
+			return 1
+		}
+		return 0
 	case int64:
 		return int32(x)
 	case float64:
@@ -223,11 +252,13 @@ func AsInt32(v interface{}) int32 {
 		return int32(x)
 	case sql.NullInt64:
 		return int32(x.Int64)
-	case string, godror.Number:
+	case string, []byte, godror.Number:
 		var s string
 		switch x := x.(type) {
 		case string:
 			s = x
+		case []byte:
+			s = string(x)
 		case godror.Number:
 			s = string(x)
 		}
@@ -256,11 +287,13 @@ func AsInt64(v interface{}) int64 {
 		return int64(x)
 	case sql.NullInt64:
 		return x.Int64
-	case string, godror.Number:
+	case string, []byte, godror.Number:
 		var s string
 		switch x := x.(type) {
 		case string:
 			s = x
+		case []byte:
+			s = string(x)
 		case godror.Number:
 			s = string(x)
 		}
@@ -277,6 +310,30 @@ func AsInt64(v interface{}) int64 {
 	}
 	return 0
 }
+
+// AsBool returns v if it is a bool, and whether it is not 0 if it is a number
+// (as the BOOLEANs are bound before 23c).
+func AsBool(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	}
+	return AsInt32(v) != 0
+}
+
+// AsBytes returns v as a []byte (a string is converted), nil for anything else.
+func AsBytes(v interface{}) []byte {
+	switch x := v.(type) {
+	case []byte:
+		return x
+	case string:
+		return []byte(x)
+	}
+	return nil
+}
+
 func AsDate(v interface{}) *DateTime {
 	//log.Printf("AsDate(%[1]v %[1]T)", v)
 	if v == nil {
@@ -300,6 +357,8 @@ func AsDate(v interface{}) *DateTime {
 		*d = x
 	case time.Time:
 		d.Time = x
+	case *timestamppb.Timestamp:
+		d.Time = TimeFromTimestamp(x)
 	case string:
 		_ = ParseTime(&d.Time, x)
 	default:
@@ -6,41 +6,46 @@ toolchain go1.21.0
 
 require (
 	github.com/LK4D4/joincontext v0.0.0-20171026170139-1724345da6d5
+	github.com/UNO-SOFT/zlog v0.7.7
 	github.com/antzucaro/matchr v0.0.0-20180616170659-cbc221335f3c
 	github.com/fatih/structs v1.1.0
 	github.com/go-kit/kit v0.12.0
 	github.com/go-stack/stack v1.8.0
 	github.com/godror/godror v0.37.0
 	github.com/gogo/protobuf v1.3.2
-	github.com/google/go-cmp v0.5.9
+	github.com/google/go-cmp v0.6.0
+	github.com/google/renameio/v2 v2.0.0
 	github.com/grpc-ecosystem/go-grpc-middleware v1.0.1-0.20190118093823-f849b5445de4
 	github.com/kortschak/utter v1.5.0
 	github.com/kylelemons/godebug v1.1.0
 	github.com/oklog/ulid v1.3.1
 	github.com/tgulacsi/go v0.24.4
-	golang.org/x/exp v0.0.0-20230713183714-613f0c0eb8a1
+	go.opentelemetry.io/otel v1.21.0
+	go.opentelemetry.io/otel/metric v1.21.0
+	go.opentelemetry.io/otel/sdk v1.21.0
+	go.opentelemetry.io/otel/sdk/metric v1.21.0
+	go.opentelemetry.io/otel/trace v1.21.0
 	golang.org/x/sync v0.1.0
+	golang.org/x/tools v0.7.0
 	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2
+	google.golang.org/genproto v0.0.0-20230124163310-31e0e69b6fc2
 	google.golang.org/grpc v1.51.0
+	google.golang.org/protobuf v1.30.0
 )
 
 require (
-	github.com/UNO-SOFT/zlog v0.7.7 // indirect
 	github.com/go-kit/log v0.2.1 // indirect
 	github.com/go-logfmt/logfmt v0.6.0 // indirect
-	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/logr v1.3.0 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/go-logr/zerologr v1.2.3 // indirect
 	github.com/godror/knownpb v0.1.1 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
-	github.com/google/renameio/v2 v2.0.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.17 // indirect
 	github.com/rs/zerolog v1.29.0 // indirect
 	golang.org/x/net v0.8.0 // indirect
-	golang.org/x/sys v0.10.0 // indirect
+	golang.org/x/sys v0.14.0 // indirect
 	golang.org/x/term v0.10.0 // indirect
 	golang.org/x/text v0.8.0 // indirect
-	golang.org/x/tools v0.7.0 // indirect
-	google.golang.org/genproto v0.0.0-20230124163310-31e0e69b6fc2 // indirect
-	google.golang.org/protobuf v1.30.0 // indirect
 )
@@ -0,0 +1,118 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// The REF CURSOR outputs are sent in batches of at most BatchSize rows
+// (or as the batch-size annotation of the function says, or the BatchSize of the generated server),
+// and at most the MaxMsgSize of the generated server bytes: a batch is cut before the row
+// which would not fit into the output (but the first row of an output is always sent, even if it does not fit).
+
+// BatchSize is the default maximum number of rows in a batch of a REF CURSOR output.
+var BatchSize = 128
+
+// DefaultMaxMsgSize is the default maximum size of an output message, as gRPC's default.
+const DefaultMaxMsgSize = 4 << 20
+
+// batchRows returns the maximum number of rows in the batches of the function.
+func (f Function) batchRows() int {
+	if f.batchSize > 0 {
+		return f.batchSize
+	}
+	return BatchSize
+}
+
+// MessageSize returns the size of the marshaled message - or 0 if it is not a message.
+func MessageSize(m interface{}) int {
+	switch m := m.(type) {
+	case interface{ Size() int }: // gogo
+		return m.Size()
+	case proto.Message:
+		return proto.Size(m)
+	}
+	return 0
+}
+
+// TaggedFixedSize returns the size of the output m having an empty batch of a tagged cursor,
+// with room for the length of the batch growing up to maxSize.
+func TaggedFixedSize(m interface{}, maxSize int) int {
+	return MessageSize(m) + protowire.SizeVarint(uint64(maxSize)) - 1
+}
+
+// RowSize returns the size of the message as an element of a repeated field - or 0 if it is not a message.
+func RowSize(m interface{}) int {
+	n := MessageSize(m)
+	if n == 0 {
+		return 0
+	}
+	// the tag (of a field number below 2048) and the length
+	return 2 + protowire.SizeVarint(uint64(n)) + n
+}
+
+// batchLimits returns the statement of the generated function getting the limits of its batches
+// (batchRows and batchBytes) from the server, and declaring the room left in the output (batchRoom),
+// and whether the output has no rows yet (batchEmpty).
+func (f Function) batchLimits() string {
+	return fmt.Sprintf(`batchRows, batchBytes := s.batchLimits(%q, %d)
+	var batchRoom int
+	var batchEmpty bool
+`, strings.ToLower(f.FullName()), f.batchRows())
+}
+
+// batchAppend returns the statements of an iterator appending the row (the n-th of the batch) to dest,
+// if it fits into the output (or the output has no rows yet, of any iterator):
+// otherwise the batch is ended, and the row is pending for the next one.
+func batchAppend(dest, row string) string {
+	return fmt.Sprintf(`row := %s
+	size := genocall.RowSize(row)
+	if !batchEmpty && size > batchRoom {
+		pending = func() bool {
+			if !batchEmpty && size > batchRoom {
+				return false
+			}
+			batchRoom, batchEmpty = batchRoom-size, false
+			%s = append(%s, row)
+			return true
+		}
+		return nil
+	}
+	batchRoom, batchEmpty = batchRoom-size, false
+	%s = append(%s, row)`, row, dest, dest, dest, dest)
+}
+
+// batchStart is the start of the Iterate of an iterator: the pending row of the previous batch
+// is the first of this one - if it fits into this output, otherwise the batch is empty.
+const batchStart = `var n int
+	if pending != nil {
+		if !pending() {
+			return nil
+		}
+		pending, n = nil, 1
+	}`
+
+// batchCommon is the part of the generated server limiting the batches.
+const batchCommon = `
+// batchLimits returns the maximum number of rows and bytes of a batch of the REF CURSOR outputs
+// of the function, by BatchSize (or size) and MaxMsgSize (or genocall.DefaultMaxMsgSize).
+func (s *genocallServer) batchLimits(funName string, size int) (rows, bytes int) {
+	rows, bytes = size, s.MaxMsgSize
+	if s.BatchSize != nil {
+		if n := s.BatchSize(funName); n > 0 {
+			rows = n
+		}
+	}
+	if bytes <= 0 {
+		bytes = genocall.DefaultMaxMsgSize
+	}
+	return rows, bytes
+}
+`
@@ -0,0 +1,70 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBatchSizeAnnotation(t *testing.T) {
+	b := rAnnotation.FindString("  --genocall:batch-size list=32\n")
+	a, err := parseAnnotation("TST_WEAK", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Type != "batch-size" || a.Name != "list" || a.Size != 32 {
+		t.Fatalf("got %+v from %q", a, b)
+	}
+	functions := ApplyAnnotations(weakCursorFunctions(t), []Annotation{a})
+	_, callFun := functions[0].PlsqlBlock("")
+	for _, want := range []string{
+		`batchRows, batchBytes := s.batchLimits("tst_weak.list", 32)`,
+		"for ; n < batchRows; n++ {",
+		"batchRoom, batchEmpty = batchBytes-batchFixed, true",
+		"if !batchEmpty && size > batchRoom {",
+	} {
+		if !strings.Contains(callFun, want) {
+			t.Errorf("%q is not in the call:\n%s", want, callFun)
+		}
+	}
+	_, callFun = weakCursorFunctions(t)[0].PlsqlBlock("")
+	if want := `s.batchLimits("tst_weak.list", 128)`; !strings.Contains(callFun, want) {
+		t.Errorf("%q is not in the call:\n%s", want, callFun)
+	}
+}
+
+type sizer int
+
+func (s sizer) Size() int { return int(s) }
+
+func TestRowSize(t *testing.T) {
+	for _, tc := range []struct {
+		m    interface{}
+		want int
+	}{
+		{sizer(3), 2 + 1 + 3},
+		{sizer(200), 2 + 2 + 200},
+		{wrapperspb.String("abc"), 2 + 1 + 5},
+		{struct{ A string }{"abc"}, 0},
+		{sizer(0), 0},
+	} {
+		if got := RowSize(tc.m); got != tc.want {
+			t.Errorf("%#v: got %d, wanted %d", tc.m, got, tc.want)
+		}
+	}
+}
+
+func TestTaggedFixedSize(t *testing.T) {
+	// the length of the batch grows from 1 byte to 4 bytes (below 256MiB), or 5
+	if got, want := TaggedFixedSize(sizer(10), DefaultMaxMsgSize), 10+3; got != want {
+		t.Errorf("got %d, wanted %d", got, want)
+	}
+	if got, want := TaggedFixedSize(sizer(10), 1<<30), 10+4; got != want {
+		t.Errorf("got %d, wanted %d", got, want)
+	}
+}
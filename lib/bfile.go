@@ -0,0 +1,123 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import "fmt"
+
+// A BFILE cannot be bound, so it is a message of its directory and file name (Bfile):
+// the PL/SQL block makes the locator of the input by BFILENAME,
+// and returns the names of the output by DBMS_LOB.FILEGETNAME.
+//
+// With BfileContent, the content of the output files is read, too.
+// BFILEs are supported as arguments only, not as fields of records or elements of tables.
+
+// BfileContent makes the generated functions read the content of the output BFILEs,
+// into the content field of their messages.
+var BfileContent bool
+
+// The fields of the Bfile message.
+const (
+	bfileDirectory = "directory"
+	bfileFilename  = "filename"
+	bfileContent   = "content"
+)
+
+// bfileFields returns the fields of the message of a BFILE.
+func bfileFields(dir direction) []NamedArgument {
+	fields := make([]NamedArgument, 0, 3)
+	for _, f := range []struct {
+		Name, Type string
+		Length     uint
+	}{
+		{bfileDirectory, "VARCHAR2", 128},
+		{bfileFilename, "VARCHAR2", 2000},
+		{bfileContent, "BLOB", 0},
+	} {
+		arg := NewArgument(f.Name, f.Type, f.Type, "", "", dir, "", 0, 0, f.Length, nil)
+		fields = append(fields, NamedArgument{Name: arg.Name, Argument: &arg})
+	}
+	return fields
+}
+
+// isBfile reports whether the argument is a BFILE.
+func (arg Argument) isBfile() bool { return arg.Type == "BFILE" }
+
+// getConvBfile returns the declarations and the statements of the PL/SQL block
+// converting the BFILE argument (in vn) from and to its directory and file name,
+// and appends the binding of those to convIn and convOut.
+func (arg Argument) getConvBfile(
+	convIn, convOut []string,
+	funName, vn string,
+	addParam func(string) string,
+) (decls, pre, post, _, _ []string, err error) {
+	decls = append(decls, vn+" BFILE; --F="+arg.Name)
+	aname := CamelCase(arg.Name)
+	readContent := BfileContent && arg.IsOutput()
+	if arg.IsOutput() {
+		var got string
+		if got, err = arg.goType(false); err != nil {
+			return
+		}
+		if arg.IsInput() {
+			// just the names are copied, the content is output only
+			convIn = append(convIn, fmt.Sprintf(`
+					output.%s = new(%s)  // bf1
+					if input.%s != nil {
+						output.%s.Directory, output.%s.Filename = input.%s.Directory, input.%s.Filename
+					}
+					`, aname, withPb(CamelCase(got[1:])),
+				aname, aname, aname, aname, aname))
+		} else {
+			convIn = append(convIn, fmt.Sprintf(`
+                    if output.%s == nil {
+                        output.%s = new(%s)  // bf2
+                    }`, aname,
+				aname, withPb(CamelCase(got[1:]))))
+		}
+	}
+	params := make(map[string]string, len(arg.RecordOf))
+	for _, a := range arg.RecordOf {
+		if a.Name == bfileContent && !readContent {
+			continue
+		}
+		tmp := getParamName(funName, vn+"."+a.Name)
+		params[a.Name] = tmp
+		decls = append(decls, tmp+" "+a.AbsType+"; --F="+arg.Name)
+		convIn, convOut = a.getConvRec(convIn, convOut,
+			aname+"."+CamelCase(a.Name), addParam(tmp),
+			0, arg, a.Name, 0)
+	}
+	dirName, fileName := params[bfileDirectory], params[bfileFilename]
+	if arg.IsInput() {
+		// every parameter is bound once in pre, and once in post - see demap
+		pre = append(pre,
+			dirName+" := :"+dirName+"; "+fileName+" := :"+fileName+";",
+			"IF "+dirName+" IS NOT NULL THEN "+vn+" := BFILENAME("+dirName+", "+fileName+"); END IF;",
+		)
+	}
+	if !arg.IsOutput() {
+		return decls, pre, post, convIn, convOut, nil
+	}
+	post = append(post,
+		dirName+" := NULL; "+fileName+" := NULL;",
+		"IF "+vn+" IS NOT NULL THEN DBMS_LOB.FILEGETNAME("+vn+", "+dirName+", "+fileName+"); END IF;",
+		":"+dirName+" := "+dirName+"; :"+fileName+" := "+fileName+";",
+	)
+	if readContent {
+		content := params[bfileContent]
+		post = append(post,
+			"IF "+vn+" IS NOT NULL AND DBMS_LOB.FILEEXISTS("+vn+") = 1 THEN",
+			"  DBMS_LOB.CREATETEMPORARY("+content+", TRUE);",
+			"  DBMS_LOB.FILEOPEN("+vn+", DBMS_LOB.FILE_READONLY);",
+			"  IF DBMS_LOB.GETLENGTH("+vn+") > 0 THEN",
+			"    DBMS_LOB.LOADFROMFILE("+content+", "+vn+", DBMS_LOB.GETLENGTH("+vn+"));",
+			"  END IF;",
+			"  DBMS_LOB.FILECLOSE("+vn+");",
+			"END IF;",
+			":"+content+" := "+content+";",
+		)
+	}
+	return decls, pre, post, convIn, convOut, nil
+}
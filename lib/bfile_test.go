@@ -0,0 +1,78 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"strings"
+	"testing"
+)
+
+// lobFunctions returns TST_LOB.COPY(P_SRC IN BFILE, P_DST IN/OUT BFILE, P_TXT IN/OUT NCLOB,
+// P_LONG IN LONG, P_RAW OUT LONG RAW, P_ROW IN UROWID) RETURN BFILE.
+func lobFunctions(t *testing.T) []Function {
+	return fixtureFunctions(t, fixtureSnapshot("TST_LOB", []fixtureArg{
+		{"COPY", "", "OUT", "BFILE", "BFILE"},
+		{"COPY", "P_SRC", "IN", "BFILE", "BFILE"},
+		{"COPY", "P_DST", "IN/OUT", "BFILE", "BFILE"},
+		{"COPY", "P_TXT", "IN/OUT", "NCLOB", "NCLOB"},
+		{"COPY", "P_LONG", "IN", "LONG", "LONG"},
+		{"COPY", "P_RAW", "OUT", "LONG RAW", "LONG RAW"},
+		{"COPY", "P_ROW", "IN", "UROWID", "UROWID"},
+	}))
+}
+
+func TestLobArguments(t *testing.T) {
+	functions := lobFunctions(t)
+	if len(functions) != 1 {
+		t.Fatalf("got %d functions, wanted 1", len(functions))
+	}
+	fun := functions[0]
+	want := map[string]string{
+		"p_src": "*Bfile", "p_dst": "*Bfile", "p_txt": "string",
+		"p_long": "string", "p_raw": "[]byte", "p_row": "string",
+	}
+	for _, arg := range fun.Args {
+		if got, err := arg.goType(false); err != nil {
+			t.Errorf("%s: %+v", arg.Name, err)
+		} else if got != want[arg.Name] {
+			t.Errorf("%s: got %q, wanted %q", arg.Name, got, want[arg.Name])
+		}
+	}
+
+	var buf strings.Builder
+	if err := SaveProtobuf(&buf, functions, "lob"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"message Bfile {",
+		"string directory = 1",
+		"bytes content = 3",
+		"Bfile p_src = 1",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q is not in the proto:\n%s", want, buf.String())
+		}
+	}
+
+	for _, content := range []bool{false, true} {
+		BfileContent = content
+		plsBlock, callFun := lobFunctions(t)[0].PlsqlBlock("")
+		BfileContent = false
+		for _, want := range []string{
+			" := BFILENAME(",
+			"DBMS_LOB.FILEGETNAME(",
+		} {
+			if !strings.Contains(plsBlock, want) {
+				t.Errorf("%t: %q is not in the block:\n%s", content, want, plsBlock)
+			}
+		}
+		if got := strings.Contains(plsBlock, "DBMS_LOB.LOADFROMFILE("); got != content {
+			t.Errorf("%t: reads the content: %t\n%s", content, got, plsBlock)
+		}
+		if got := strings.Contains(callFun, "output.PDst.Content"); got != content {
+			t.Errorf("%t: binds the content: %t\n%s", content, got, callFun)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NativeBoolean binds the BOOLEAN arguments directly, as the database supports it since 23c.
+// Otherwise they are bound as numbers (1 for TRUE, 0 for FALSE), converted by CASE expressions
+// in the PL/SQL block.
+var NativeBoolean bool
+
+// nativeBooleanVersion is the first major version of the database binding BOOLEANs.
+const nativeBooleanVersion = 23
+
+// SupportsNativeBoolean reports whether the database the snapshot is read from binds BOOLEANs.
+func (s *Snapshot) SupportsNativeBoolean() bool {
+	major, _, _ := strings.Cut(s.ServerVersion, ".")
+	n, err := strconv.Atoi(major)
+	return err == nil && n >= nativeBooleanVersion
+}
+
+// isBoolean reports whether the argument is a simple BOOLEAN.
+func (arg Argument) isBoolean() bool {
+	return arg.Flavor == FLAVOR_SIMPLE && isBooleanType(arg.Type)
+}
+
+func isBooleanType(typ string) bool { return typ == "BOOLEAN" || typ == "PL/SQL BOOLEAN" }
+
+// boolFromNumber returns the PL/SQL expression converting the number (bind) to BOOLEAN.
+func boolFromNumber(bind string) string {
+	return "CASE " + bind + " WHEN 1 THEN TRUE WHEN 0 THEN FALSE END"
+}
+
+// boolToNumber returns the PL/SQL expression converting the BOOLEAN to number.
+func boolToNumber(expr string) string {
+	return "CASE WHEN " + expr + " THEN 1 WHEN NOT " + expr + " THEN 0 END"
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// booleanFunctions returns TST_BOOL.FLIP(P_A IN BOOLEAN, P_B OUT BOOLEAN, P_REC IN/OUT REC_T),
+// where REC_T is a RECORD (FLAG BOOLEAN, NUM NUMBER(5)), TST_BOOL.IS_SET(P_A IN BOOLEAN) RETURN BOOLEAN,
+// and TST_BOOL.GET_FLAGS(P_FLAGS IN/OUT FLAGS_T, P_RECS OUT RECS_T), where FLAGS_T is a TABLE OF BOOLEAN
+// INDEX BY VARCHAR2(30), and RECS_T is a TABLE OF REC_T.
+func booleanFunctions(t *testing.T) []Function {
+	boolT := &PlsType{TypeName: TypeName{Name: "PL/SQL BOOLEAN"}, Attr: "FLAG"}
+	numT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "NUM", Prec: sql.NullInt64{Int64: 5, Valid: true}}
+	recT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_BOOL", Name: "REC_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{boolT, numT}}
+	flagsT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_BOOL", Name: "FLAGS_T"}, TypeCode: "PL/SQL INDEX TABLE", IndexBy: "VARCHAR2",
+		CollectionOf: &PlsType{TypeName: TypeName{Name: "PL/SQL BOOLEAN"}}}
+	recsT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_BOOL", Name: "RECS_T"}, TypeCode: "PL/SQL INDEX TABLE", IndexBy: "PLS_INTEGER", CollectionOf: recT}
+
+	return fixtureFunctions(t, fixtureSnapshot("TST_BOOL", []fixtureArg{
+		{"FLIP", "P_A", "IN", "PL/SQL BOOLEAN", "BOOLEAN"},
+		{"FLIP", "P_B", "OUT", "PL/SQL BOOLEAN", "BOOLEAN"},
+		{"FLIP", "P_REC", "IN/OUT", "PL/SQL RECORD", "OWNR.TST_BOOL.REC_T"},
+		{"IS_SET", "", "OUT", "PL/SQL BOOLEAN", "BOOLEAN"},
+		{"IS_SET", "P_A", "IN", "PL/SQL BOOLEAN", "BOOLEAN"},
+		{"GET_FLAGS", "P_FLAGS", "IN/OUT", "PL/SQL TABLE", "OWNR.TST_BOOL.FLAGS_T"},
+		{"GET_FLAGS", "P_RECS", "OUT", "PL/SQL TABLE", "OWNR.TST_BOOL.RECS_T"},
+	}, boolT, numT, recT, flagsT, recsT))
+}
+
+func TestBooleanArguments(t *testing.T) {
+	if (&Snapshot{ServerVersion: "19.0.0.0.0"}).SupportsNativeBoolean() || !(&Snapshot{ServerVersion: "23.0.0.0.0"}).SupportsNativeBoolean() {
+		t.Error("SupportsNativeBoolean")
+	}
+	defer func(old bool) { NativeBoolean = old }(NativeBoolean)
+	for _, native := range []bool{false, true} {
+		NativeBoolean = native
+		functions := booleanFunctions(t)
+		if len(functions) != 3 {
+			t.Fatalf("got %d functions, wanted 3", len(functions))
+		}
+		byName := make(map[string]Function, len(functions))
+		for _, f := range functions {
+			byName[f.Name] = f
+		}
+		flip, isSet, getFlags := byName["FLIP"], byName["IS_SET"], byName["GET_FLAGS"]
+		plsql, callFun := flip.PlsqlBlock("")
+		retPlsql, _ := isSet.PlsqlBlock("")
+		flagsPlsql, flagsCallFun := getFlags.PlsqlBlock("")
+		if native {
+			if strings.Contains(plsql, "CASE") || !strings.Contains(plsql, "p_a=>:3") || !strings.Contains(retPlsql, ":1 := TST_bool.is_set(p_a=>:2)") {
+				t.Errorf("the booleans are converted:\n%s\n%s", plsql, retPlsql)
+			}
+			if !strings.Contains(callFun, "params[2] = input.PA") {
+				t.Errorf("the bool is not bound:\n%s", callFun)
+			}
+			if strings.Contains(flagsPlsql, "CASE") || !strings.Contains(flagsPlsql, "BOOLEAN_tab_typ") {
+				t.Errorf("the booleans of the tables are converted:\n%s", flagsPlsql)
+			}
+			continue
+		}
+		for _, want := range []string{
+			"v001 BOOLEAN; --L=p_a",
+			"v001 := CASE :1 WHEN 1 THEN TRUE WHEN 0 THEN FALSE END;",
+			":4 := CASE WHEN v002 THEN 1 WHEN NOT v002 THEN 0 END;",
+			"v003.flag := CASE :2 WHEN 1 THEN TRUE WHEN 0 THEN FALSE END;",
+			":5 := CASE WHEN v003.flag THEN 1 WHEN NOT v003.flag THEN 0 END;",
+		} {
+			if !strings.Contains(plsql, want) {
+				t.Errorf("%q is not in the block:\n%s", want, plsql)
+			}
+		}
+		for _, want := range []string{
+			"v002 := TST_bool.is_set(p_a=>v001);",
+			":2 := CASE WHEN v002 THEN 1 WHEN NOT v002 THEN 0 END;",
+		} {
+			if !strings.Contains(retPlsql, want) {
+				t.Errorf("%q is not in the block:\n%s", want, retPlsql)
+			}
+		}
+		for _, want := range []string{
+			"output.PB = var_",
+			".Int32 == 1",
+			"output.PRec.Flag = var_",
+		} {
+			if !strings.Contains(callFun, want) {
+				t.Errorf("%q is not in the call:\n%s", want, callFun)
+			}
+		}
+		// the maps are copied through arrays of numbers, the tables are read as numbers
+		for _, want := range []string{
+			"NUMBER_tab_typ; --M=p_flags",
+			"v001(p002#keys$(i1)) := CASE p002#values$(i1) WHEN 1 THEN TRUE WHEN 0 THEN FALSE END;",
+			"p002#values$(i1) := CASE WHEN v001(k1) THEN 1 WHEN NOT v001(k1) THEN 0 END;",
+			`SELECT CASE WHEN "FLAG" THEN 1 WHEN NOT "FLAG" THEN 0 END AS "FLAG", "NUM" FROM (SELECT ROWNUM AS "I$", t.* FROM TABLE(`,
+		} {
+			if !strings.Contains(flagsPlsql, want) {
+				t.Errorf("%q is not in the block:\n%s", want, flagsPlsql)
+			}
+		}
+		for _, want := range []string{
+			"make([]int32, 0, ",
+			"custom.AsInt32(v)",
+			"custom.AsBool(x__PFlags__values[i])",
+			"x.Flag = custom.AsBool(I[0])",
+		} {
+			if !strings.Contains(flagsCallFun, want) {
+				t.Errorf("%q is not in the call:\n%s", want, flagsCallFun)
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// fixtureFunctionsAll returns the functions of all the test fixtures.
+func fixtureFunctionsAll(t *testing.T) []Function {
+	functions, _, err := testSnapshot().Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fixture := range []func(*testing.T) []Function{
+		nestedRecordFunctions, tableFunctions, objectFunctions, mapFunctions, outTableFunctions, outRecordFunctions,
+		booleanFunctions, datetimeFunctions, lobFunctions, lobStreamFunctions,
+		weakCursorFunctions, implicitFunctions, cursorFunctions,
+	} {
+		functions = append(functions, fixture(t)...)
+	}
+	return functions
+}
+
+// compileGenerated generates the server of the functions (returned by the functions func)
+// and its messages, as the command does, and type checks them together
+// - the server against the real generated message types -, unless testing.Short.
+func compileGenerated(t *testing.T, functions func(*testing.T) []Function) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("the type check of the generated code is slow")
+	}
+	const pbImport = "example.com/pb"
+	for _, v2 := range []bool{false, true} {
+		name := "gogo"
+		if v2 {
+			name = "v2"
+		}
+		t.Run(name, func(t *testing.T) {
+			defer func(old bool) { ProtoAPIv2 = old }(ProtoAPIv2)
+			ProtoAPIv2 = v2
+			funcs := functions(t)
+
+			dir := t.TempDir()
+			if _, _, err := SavePackages(dir, funcs, "srv", pbImport, "genocall", "fp", false); err != nil {
+				t.Fatal(err)
+			}
+			files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := make(map[string][]byte, len(files))
+			for _, fn := range files {
+				if strings.HasSuffix(fn, "_test.go") {
+					continue
+				}
+				if srv[filepath.Base(fn)], err = os.ReadFile(fn); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			const commonProto = "pb/srv_common.proto"
+			fd, common, err := ProtoDescriptorCommon(commonProto, funcs, "srv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			protos, names := []*descriptor.FileDescriptorProto{fd}, []string{commonProto}
+			groups := make(map[string][]Function)
+			for _, f := range funcs {
+				if _, ok := groups[f.Package]; !ok {
+					names = append(names, "pb/"+strings.ToLower(f.Package)+".proto")
+				}
+				groups[f.Package] = append(groups[f.Package], f)
+			}
+			for _, nm := range names[1:] {
+				pkg := strings.TrimSuffix(strings.TrimPrefix(nm, "pb/"), ".proto")
+				var gFuncs []Function
+				for k, v := range groups {
+					if strings.ToLower(k) == pkg {
+						gFuncs = v
+					}
+				}
+				if fd, err = ProtoDescriptorService(nm, gFuncs, "srv", CamelCase(pkg), commonProto, common); err != nil {
+					t.Fatal(err)
+				}
+				protos = append(protos, fd)
+			}
+			var pb map[string][]byte
+			if v2 {
+				pb, err = GenerateProtoGoV2(pbImport+";pb", protos, names...)
+			} else {
+				pb, err = GenerateProtoGo(ProtoGoParameter, protos, names...)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fset := token.NewFileSet()
+			imp := &stubImporter{fset: fset, std: importer.ForCompiler(fset, "source", nil), stubs: map[string]string{
+				"github.com/davecgh/go-spew/spew": "package spew\nfunc Sdump(...interface{}) string { return \"\" }",
+			}}
+			p, err := imp.check(pbImport, pb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			imp.pkgs = map[string]*types.Package{pbImport: p}
+			if _, err := imp.check("srv", srv); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCompileGenerated(t *testing.T) {
+	compileGenerated(t, fixtureFunctionsAll)
+}
+
+// TestCompileSameName compiles the functions of the same name in two packages.
+func TestCompileSameName(t *testing.T) {
+	compileGenerated(t, twoPackageFunctions)
+}
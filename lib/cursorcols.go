@@ -0,0 +1,494 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A weakly typed REF CURSOR (SYS_REFCURSOR) has no record type to read its columns from,
+// so they are given by a cursor-columns annotation ("ret" is the returned cursor):
+//
+//	--genocall:cursor-columns list_rows.p_cur => id NUMBER(9), name VARCHAR2(30), created DATE
+//
+// The annotations can be in the package spec, or in an annotations file (see ReadAnnotationsFile),
+// so the specs of packages we do not own need not be changed.
+//
+// DiscoverCursorColumns finds the columns by describing the sample query of a cursor-query annotation
+//
+//	--genocall:cursor-query list_rows.p_cur => SELECT id, name, created FROM tbl
+//
+// with DBMS_SQL.DESCRIBE_COLUMNS2 - or, if asked for, by calling the function once, with NULL inputs.
+
+// cursorColumn is a column of a weakly typed REF CURSOR.
+type cursorColumn struct {
+	Name, Type               string
+	Length, Precision, Scale int64
+}
+
+func (c cursorColumn) String() string {
+	switch {
+	case c.Length > 0:
+		return fmt.Sprintf("%s %s(%d)", c.Name, c.Type, c.Length)
+	case c.Precision > 0 && c.Scale > 0:
+		return fmt.Sprintf("%s %s(%d,%d)", c.Name, c.Type, c.Precision, c.Scale)
+	case c.Precision > 0:
+		return fmt.Sprintf("%s %s(%d)", c.Name, c.Type, c.Precision)
+	}
+	return c.Name + " " + c.Type
+}
+
+var (
+	rColumnName       = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_#$]*$`)
+	rAnnotationPrefix = regexp.MustCompile(`^--(oracall|gen-?o-?call):`)
+	rColumnSize       = regexp.MustCompile(`\(\s*([0-9]+)\s*(?:,\s*(-?[0-9]+)\s*)?(?:BYTE|CHAR)?\s*\)`)
+)
+
+// newCursorColumn returns the column of the name and the data type (as written by the database),
+// for the types the generated code supports.
+func newCursorColumn(name, dataType string, length, precision, scale int64) (cursorColumn, error) {
+	if !rColumnName.MatchString(name) {
+		return cursorColumn{}, fmt.Errorf("column %q has no usable name (give it an alias)", name)
+	}
+	c := cursorColumn{Name: strings.ToLower(name), Type: strings.ToUpper(dataType)}
+	switch c.Type {
+	case "VARCHAR2", "NVARCHAR2", "VARCHAR", "CHAR", "NCHAR", "RAW":
+		if c.Type == "VARCHAR" {
+			c.Type = "VARCHAR2"
+		}
+		c.Length = length
+	case "NUMBER":
+		// an unconstrained NUMBER, or a FLOAT has a negative scale
+		if precision > 0 && scale >= 0 {
+			c.Precision, c.Scale = precision, scale
+		}
+	case "FLOAT", "DOUBLE", "BINARY_FLOAT", "BINARY_DOUBLE":
+		c.Type = "NUMBER"
+	case "INTEGER", "BINARY_INTEGER", "PLS_INTEGER":
+		c.Type = "INTEGER"
+	case "DATE", "LONG", "LONG RAW", "ROWID", "UROWID", "CLOB", "NCLOB", "BLOB", "BOOLEAN",
+		"TIMESTAMP", typeTimestampTZ, typeTimestampLTZ, typeIntervalDS, typeIntervalYM:
+	default:
+		return c, fmt.Errorf("column %q: %w: %s", name, UnknownSimpleType, dataType)
+	}
+	return c, nil
+}
+
+// parseCursorColumns parses the columns of a cursor-columns annotation:
+// the comma separated names and data types, as "id NUMBER(9,2), name VARCHAR2(30)".
+func parseCursorColumns(text string) ([]cursorColumn, error) {
+	var columns []cursorColumn
+	var depth, start int
+	for i := 0; i <= len(text); i++ {
+		if i < len(text) {
+			switch text[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth != 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		def := strings.TrimSpace(text[start:i])
+		start = i + 1
+		name, dataType, ok := strings.Cut(def, " ")
+		if !ok {
+			return columns, fmt.Errorf("%q: wanted name and type", def)
+		}
+		dataType = strings.Join(strings.Fields(strings.ToUpper(dataType)), " ")
+		var size, scale int64
+		if m := rColumnSize.FindStringSubmatch(dataType); m != nil {
+			size, _ = strconv.ParseInt(m[1], 10, 64)
+			if m[2] != "" {
+				scale, _ = strconv.ParseInt(m[2], 10, 64)
+			}
+			dataType = strings.Join(strings.Fields(strings.Replace(dataType, m[0], " ", 1)), " ")
+		}
+		dataType = canonicalDatetime(dataType)
+		c, err := newCursorColumn(name, dataType, size, size, scale)
+		if err != nil {
+			return columns, err
+		}
+		columns = append(columns, c)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%q: no columns", text)
+	}
+	return columns, nil
+}
+
+// isWeakCursor reports whether the argument is a REF CURSOR without a known record type.
+func (arg Argument) isWeakCursor() bool {
+	return arg.Type == "REF CURSOR" && (arg.TableOf == nil || len(arg.TableOf.RecordOf) == 0)
+}
+
+// setCursorColumns sets the record of the weakly typed REF CURSOR argument (or the returned one, as "ret")
+// to the columns of a cursor-columns annotation.
+func (f *Function) setCursorColumns(argName, text string) error {
+	columns, err := parseCursorColumns(text)
+	if err != nil {
+		return err
+	}
+	var arg *Argument
+	if argName == "ret" && f.Returns != nil {
+		ret := *f.Returns
+		f.Returns, arg = &ret, &ret
+	} else {
+		f.Args = append([]Argument(nil), f.Args...)
+		for i := range f.Args {
+			if f.Args[i].Name == argName {
+				arg = &f.Args[i]
+				break
+			}
+		}
+	}
+	if arg == nil {
+		return fmt.Errorf("%s has no argument %q", f.FullName(), argName)
+	}
+	if !arg.isWeakCursor() {
+		return fmt.Errorf("%s.%s is not a weakly typed REF CURSOR", f.FullName(), argName)
+	}
+	// the record gets a name of its own, as it has no type in the database
+	rec := NewArgument("", "PL/SQL RECORD", "PL/SQL RECORD",
+		strings.ToUpper(f.Package+"."+f.Name+"_"+argName+"_ROW"), "", arg.Direction, "", 0, 0, 0, nil)
+	for _, c := range columns {
+		sub := NewArgument(c.Name, c.Type, c.Type, "", "", arg.Direction, "",
+			uint8(c.Precision), uint8(c.Scale), uint(c.Length), nil)
+		rec.RecordOf = append(rec.RecordOf, NamedArgument{Name: sub.Name, Argument: &sub})
+	}
+	arg.TableOf = &rec
+	return nil
+}
+
+// ReadAnnotationsFile reads the annotations from the named file, as the "--genocall:" lines
+// in the package specs, but with the names qualified by the package, as
+//
+//	--genocall:cursor-columns tst_cur.list_rows.p_cur => id NUMBER(9), name VARCHAR2(30)
+//	--genocall:max-table-size tst_cur.list_rows=1000
+//	--genocall:batch-size tst_cur.list_rows=32
+//
+// A missing file has no annotations.
+func ReadAnnotationsFile(fileName string) ([]Annotation, error) {
+	fh, err := os.Open(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer fh.Close()
+	var annotations []Annotation
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !rAnnotationPrefix.MatchString(line) {
+			continue
+		}
+		a, err := parseAnnotation("", line)
+		if err != nil {
+			return annotations, fmt.Errorf("%s: %q: %w", fileName, line, err)
+		}
+		if a.Type == "" || a.Type == "stateful" {
+			continue
+		}
+		var ok bool
+		if a.Package, a.Name, ok = strings.Cut(a.Name, "."); !ok {
+			return annotations, fmt.Errorf("%s: %q: the name is not qualified by the package", fileName, line)
+		}
+		switch a.Type {
+		case "replace", "replace_json", "rename":
+			a.Other = strings.TrimPrefix(a.Other, a.Package+".")
+		}
+		annotations = append(annotations, a)
+	}
+	if err := scanner.Err(); err != nil {
+		return annotations, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return annotations, nil
+}
+
+// AppendAnnotationsFile appends the annotations to the named file, for ReadAnnotationsFile.
+func AppendAnnotationsFile(fileName string, annotations []Annotation) error {
+	fh, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(fh)
+	for _, a := range annotations {
+		if a.Type == "stateful" || a.Name == "" {
+			continue
+		}
+		fmt.Fprintf(w, "--genocall:%s %s", a.Type, a.FullName())
+		switch {
+		case a.Type == "max-table-size" || a.Type == "batch-size":
+			fmt.Fprintf(w, "=%d", a.Size)
+		case a.Other != "":
+			fmt.Fprintf(w, " => %s", a.Other)
+		}
+		w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if closeErr := fh.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type execQuerier interface {
+	querier
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+// DiscoverCursorColumns returns the cursor-columns annotations of the weakly typed REF CURSOR outputs
+// of the functions which have none: the columns of the cursor-query annotation's sample query
+// are described by DBMS_SQL.DESCRIBE_COLUMNS2 (without running it).
+//
+// The cursors without a cursor-query annotation are skipped - or, with call, read from the cursors
+// returned by calling the function once, with NULL inputs.
+// This RUNS the function: a read-only transaction does not stop its autonomous transactions,
+// COMMITs, sequences, UTL_HTTP, DBMS_PIPE or AQ calls, so call only the functions known to be harmless.
+func DiscoverCursorColumns(ctx context.Context, db execQuerier, functions []Function, annotations []Annotation, call bool) ([]Annotation, error) {
+	L := strings.ToLower
+	queries := make(map[string]string)
+	for _, a := range annotations {
+		if a.Type == "cursor-query" {
+			queries[L(a.FullName())] = a.Other
+		}
+	}
+	var discovered []Annotation
+	for _, f := range functions {
+		var called map[string][]cursorColumn
+		for _, arg := range append(append([]Argument(nil), f.Args...), f.returnsArg()...) {
+			if !arg.IsOutput() || !arg.isWeakCursor() {
+				continue
+			}
+			a := Annotation{Package: f.Package, Type: "cursor-columns", Name: L(f.Name) + "." + arg.Name}
+			var columns []cursorColumn
+			var err error
+			if qry, ok := queries[L(a.FullName())]; ok {
+				columns, err = describeQuery(ctx, db, qry)
+			} else if !call {
+				logger.Warn("SKIP cursor without a cursor-query annotation, the function is not called", "cursor", a.FullName())
+				continue
+			} else {
+				if called == nil {
+					if called, err = f.callForCursors(ctx, db); err != nil {
+						err = fmt.Errorf("%w (give a sample query with a cursor-query annotation)", err)
+					}
+				}
+				if columns = called[arg.Name]; err == nil && len(columns) == 0 {
+					err = errors.New("the cursor is not opened (give a sample query with a cursor-query annotation)")
+				}
+			}
+			if err != nil {
+				return discovered, fmt.Errorf("%s: %w", a.FullName(), err)
+			}
+			cols := make([]string, len(columns))
+			for i, c := range columns {
+				cols[i] = c.String()
+			}
+			a.Other = strings.Join(cols, ", ")
+			logger.Info("discovered", "cursor", a.FullName(), "columns", a.Other)
+			discovered = append(discovered, a)
+		}
+	}
+	return discovered, nil
+}
+
+// returnsArg returns the returned argument, as "ret", if any.
+func (f Function) returnsArg() []Argument {
+	if f.Returns == nil {
+		return nil
+	}
+	ret := *f.Returns
+	ret.Name = "ret"
+	return []Argument{ret}
+}
+
+// describeQuery returns the columns of the query, described by DBMS_SQL.DESCRIBE_COLUMNS2.
+func describeQuery(ctx context.Context, db execQuerier, qry string) ([]cursorColumn, error) {
+	const describeQry = `DECLARE
+  v_cur INTEGER := DBMS_SQL.OPEN_CURSOR;
+  v_cnt INTEGER;
+  v_desc DBMS_SQL.DESC_TAB2;
+  v_text VARCHAR2(32767);
+BEGIN
+  DBMS_SQL.PARSE(v_cur, :1, DBMS_SQL.NATIVE);
+  DBMS_SQL.DESCRIBE_COLUMNS2(v_cur, v_cnt, v_desc);
+  DBMS_SQL.CLOSE_CURSOR(v_cur);
+  FOR i IN 1..v_cnt LOOP
+    v_text := v_text||v_desc(i).col_name||CHR(9)||v_desc(i).col_type||CHR(9)||
+      v_desc(i).col_max_len||CHR(9)||v_desc(i).col_precision||CHR(9)||v_desc(i).col_scale||CHR(9)||
+      v_desc(i).col_charsetform||CHR(10);
+  END LOOP;
+  :2 := v_text;
+END;`
+	var text string
+	if _, err := db.ExecContext(ctx, describeQry, qry, sql.Out{Dest: &text}); err != nil {
+		return nil, fmt.Errorf("%s [%q]: %w", describeQry, qry, err)
+	}
+	return parseDescribedColumns(text)
+}
+
+// describedTypes are the names of the DBMS_SQL.DESC_REC col_type codes.
+var describedTypes = map[int]string{
+	1: "VARCHAR2", 2: "NUMBER", 8: "LONG", 11: "ROWID", 12: "DATE",
+	23: "RAW", 24: "LONG RAW", 69: "ROWID", 96: "CHAR",
+	100: "BINARY_FLOAT", 101: "BINARY_DOUBLE", 112: "CLOB", 113: "BLOB",
+	180: "TIMESTAMP", 181: typeTimestampTZ, 182: typeIntervalYM, 183: typeIntervalDS,
+	208: "UROWID", 231: typeTimestampLTZ, 252: "BOOLEAN",
+}
+
+// nationalTypes are the types of the described columns of the national character set
+// (col_charsetform = 2), by the types of their type codes.
+var nationalTypes = map[string]string{
+	"VARCHAR2": "NVARCHAR2", "CHAR": "NCHAR", "CLOB": "NCLOB",
+}
+
+// parseDescribedColumns parses the lines of the name, type code, maximum length, precision,
+// scale and charset form (separated by tabs) of the columns described by describeQuery.
+func parseDescribedColumns(text string) ([]cursorColumn, error) {
+	var columns []cursorColumn
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 6 {
+			return columns, fmt.Errorf("%q: wanted 6 fields", line)
+		}
+		var nums [5]int64
+		for i, s := range fields[1:6] {
+			if s == "" {
+				continue
+			}
+			var err error
+			if nums[i], err = strconv.ParseInt(s, 10, 64); err != nil {
+				return columns, fmt.Errorf("%q: %w", line, err)
+			}
+		}
+		dataType, ok := describedTypes[int(nums[0])]
+		if !ok {
+			return columns, fmt.Errorf("column %q: %w: type code %d", fields[0], UnknownSimpleType, nums[0])
+		}
+		if nt, ok := nationalTypes[dataType]; ok && nums[4] == 2 { // national charset
+			dataType = nt
+		}
+		c, err := newCursorColumn(fields[0], dataType, nums[1], nums[2], nums[3])
+		if err != nil {
+			return columns, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// callForCursors calls the function with NULL inputs, and returns the columns of its
+// weakly typed REF CURSOR outputs, by their argument name ("ret" for the returned one).
+//
+// The function really runs, with all its side effects - even in a read-only transaction.
+func (f Function) callForCursors(ctx context.Context, db execQuerier) (map[string][]cursorColumn, error) {
+	var decls, params []string
+	var names []string
+	var binds []interface{}
+	cursor := func(name string) string {
+		names = append(names, name)
+		binds = append(binds, sql.Out{Dest: new(driver.Rows)})
+		return fmt.Sprintf(":%d", len(binds))
+	}
+	variable := func(arg Argument) string {
+		vn := fmt.Sprintf("v_%d", len(decls)+1)
+		typ := arg.TypeName
+		if arg.Flavor == FLAVOR_SIMPLE || arg.isBfile() || typ == "" {
+			typ = arg.AbsType
+		}
+		decls = append(decls, vn+" "+typ+";")
+		return vn
+	}
+	for _, arg := range f.Args {
+		if arg.implicitResult {
+			continue
+		}
+		if arg.IsOutput() && arg.isWeakCursor() {
+			params = append(params, arg.Name+"=>"+cursor(arg.Name))
+		} else {
+			params = append(params, arg.Name+"=>"+variable(arg))
+		}
+	}
+	call := f.Package + "." + f.Name + "(" + strings.Join(params, ", ") + ");"
+	if f.Returns != nil {
+		if f.Returns.isWeakCursor() {
+			call = cursor("ret") + " := " + call
+		} else {
+			call = variable(*f.Returns) + " := " + call
+		}
+	}
+	qry := "BEGIN " + call + " END;"
+	if len(decls) != 0 {
+		qry = "DECLARE\n  " + strings.Join(decls, "\n  ") + "\n" + qry
+	}
+	if _, err := db.ExecContext(ctx, qry, binds...); err != nil {
+		return nil, fmt.Errorf("%s: %w", qry, err)
+	}
+	columns := make(map[string][]cursorColumn, len(names))
+	var firstErr error
+	for i, name := range names {
+		rows := *(binds[i].(sql.Out).Dest.(*driver.Rows))
+		if rows == nil {
+			continue
+		}
+		cols, err := rowsColumns(rows)
+		rows.Close()
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", name, err)
+		}
+		columns[name] = cols
+	}
+	return columns, firstErr
+}
+
+// rowsColumns returns the columns of the rows, by the column type methods of godror's rows.
+func rowsColumns(rows driver.Rows) ([]cursorColumn, error) {
+	names := rows.Columns()
+	typeNamer, ok := rows.(driver.RowsColumnTypeDatabaseTypeName)
+	if !ok {
+		return nil, fmt.Errorf("%T has no column types", rows)
+	}
+	columns := make([]cursorColumn, 0, len(names))
+	for i, name := range names {
+		var length, precision, scale int64
+		dataType := typeNamer.ColumnTypeDatabaseTypeName(i)
+		switch dataType {
+		case "VARCHAR2", "NVARCHAR2", "CHAR", "NCHAR", "RAW":
+			if r, ok := rows.(driver.RowsColumnTypeLength); ok {
+				length, _ = r.ColumnTypeLength(i)
+			}
+		case "NUMBER":
+			if r, ok := rows.(driver.RowsColumnTypePrecisionScale); ok {
+				precision, scale, _ = r.ColumnTypePrecisionScale(i)
+			}
+		}
+		c, err := newCursorColumn(name, dataType, length, precision, scale)
+		if err != nil {
+			return columns, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
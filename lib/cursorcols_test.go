@@ -0,0 +1,180 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// weakCursorFunctions returns TST_WEAK.LIST(P_ID IN NUMBER, P_CUR OUT SYS_REFCURSOR) RETURN SYS_REFCURSOR,
+// with the columns of P_CUR annotated in the package spec, and the returned cursor's in an annotations file.
+func weakCursorFunctions(t *testing.T) []Function {
+	snap := fixtureSnapshot("TST_WEAK", []fixtureArg{
+		{"LIST", "", "OUT", "REF CURSOR", ""},
+		{"LIST", "P_ID", "IN", "NUMBER", ""},
+		{"LIST", "P_CUR", "OUT", "REF CURSOR", ""},
+	})
+	snap.Packages = []SnapshotPackage{{Name: "TST_WEAK", Source: `CREATE OR REPLACE PACKAGE TST_WEAK IS
+  --genocall:cursor-columns list.p_cur => id NUMBER(9), name VARCHAR2(30 CHAR), amount NUMBER(12,2)
+  FUNCTION list(p_id IN NUMBER, p_cur OUT SYS_REFCURSOR) RETURN SYS_REFCURSOR;
+END TST_WEAK;
+`}}
+	fn := filepath.Join(t.TempDir(), "annotations.txt")
+	if err := AppendAnnotationsFile(fn, []Annotation{{
+		Package: "TST_WEAK", Type: "cursor-columns", Name: "list.ret",
+		Other: "created TIMESTAMP(6) WITH TIME ZONE, doc CLOB, title NVARCHAR2(40)",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	annots, err := ReadAnnotationsFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixtureFunctions(t, snap, annots...)
+}
+
+func TestCursorColumns(t *testing.T) {
+	functions := weakCursorFunctions(t)
+	if len(functions) != 1 {
+		t.Fatalf("got %d functions, wanted 1", len(functions))
+	}
+	fun := functions[0]
+	if fun.Returns == nil || fun.Returns.isWeakCursor() {
+		t.Fatalf("the columns of the returned cursor are not set: %+v", fun.Returns)
+	}
+	for _, arg := range fun.Args {
+		if arg.isWeakCursor() {
+			t.Fatalf("the columns of %s are not set", arg.Name)
+		}
+	}
+	var buf strings.Builder
+	if err := SaveProtobufService(&buf, functions, "weak", "TstWeak", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"rpc List (List_Input) returns (stream List_Output) {}",
+		"repeated ListPCurRow_TstWeak p_cur = 1;",
+		"message ListPCurRow_TstWeak {",
+		"string name = 2;",
+		"message ListRetRow_TstWeak {",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q is not in the proto:\n%s", want, buf.String())
+		}
+	}
+
+	// without the annotations, the function is skipped
+	snap := Snapshot{Arguments: []UserArgument{{
+		PackageName: "TST_WEAK", ObjectName: "LIST", ArgumentName: "P_CUR", InOut: "OUT", DataType: "REF CURSOR",
+	}}}
+	functions, _, err := snap.Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := SaveProtobufService(&buf, functions, "weak", "TstWeak", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "rpc List") {
+		t.Errorf("the function of a weakly typed REF CURSOR without columns is generated:\n%s", buf.String())
+	}
+}
+
+func TestParseCursorColumns(t *testing.T) {
+	for text, want := range map[string]string{
+		"id NUMBER(9), name VARCHAR2(30 CHAR), amount number(12, 2)": "id NUMBER(9), name VARCHAR2(30), amount NUMBER(12,2)",
+		"ts TIMESTAMP(6) WITH  TIME ZONE, d DATE, n NVARCHAR2(10)":   "ts TIMESTAMP WITH TIME ZONE, d DATE, n NVARCHAR2(10)",
+		"f BINARY_DOUBLE, c NCLOB":                                   "f NUMBER, c NCLOB",
+	} {
+		columns, err := parseCursorColumns(text)
+		if err != nil {
+			t.Errorf("%q: %+v", text, err)
+			continue
+		}
+		got := make([]string, len(columns))
+		for i, c := range columns {
+			got[i] = c.String()
+		}
+		if s := strings.Join(got, ", "); s != want {
+			t.Errorf("%q: got %q, wanted %q", text, s, want)
+		}
+	}
+	for _, text := range []string{"", "id", "id XMLTYPE", `"COUNT(*)" NUMBER`} {
+		if _, err := parseCursorColumns(text); err == nil {
+			t.Errorf("%q: no error", text)
+		}
+	}
+}
+
+// describingDB answers the describing of the queries with the described columns,
+// and records the executed statements.
+type describingDB struct {
+	querier
+	described string
+	executed  []string
+}
+
+func (db *describingDB) ExecContext(ctx context.Context, qry string, args ...interface{}) (sql.Result, error) {
+	db.executed = append(db.executed, qry)
+	if strings.Contains(qry, "DBMS_SQL.DESCRIBE_COLUMNS2") {
+		*(args[1].(sql.Out).Dest.(*string)) = db.described
+	}
+	return nil, nil
+}
+
+func TestDiscoverCursorColumns(t *testing.T) {
+	functions := fixtureFunctions(t, fixtureSnapshot("TST_WEAK", []fixtureArg{
+		{"LIST", "", "OUT", "REF CURSOR", ""},
+		{"LIST", "P_CUR", "OUT", "REF CURSOR", ""},
+	}))
+	db := &describingDB{described: "ID\t2\t22\t9\t0\t0\nNAME\t1\t80\t0\t0\t2\n"}
+	discovered, err := DiscoverCursorColumns(context.Background(), db, functions, []Annotation{{
+		Package: "TST_WEAK", Type: "cursor-query", Name: "list.p_cur", Other: "SELECT id, name FROM tbl",
+	}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discovered) != 1 || discovered[0].Name != "list.p_cur" || discovered[0].Other != "id NUMBER(9), name NVARCHAR2(80)" {
+		t.Errorf("got %+v, wanted the columns of list.p_cur", discovered)
+	}
+	// the function is not called, just the sample query is described
+	for _, qry := range db.executed {
+		if strings.Contains(strings.ToLower(qry), "tst_weak.list(") {
+			t.Errorf("the function is called: %s", qry)
+		}
+	}
+	if len(db.executed) != 1 {
+		t.Errorf("got %d statements, wanted the describing: %q", len(db.executed), db.executed)
+	}
+
+	// just when asked for
+	db.executed = db.executed[:0]
+	if _, err = DiscoverCursorColumns(context.Background(), db, functions, nil, true); err == nil {
+		t.Error("no error for the cursor not opened")
+	}
+	if len(db.executed) != 1 || !strings.Contains(strings.ToLower(db.executed[0]), "tst_weak.list(") {
+		t.Errorf("the function is not called: %q", db.executed)
+	}
+}
+
+func TestParseDescribedColumns(t *testing.T) {
+	columns, err := parseDescribedColumns("ID\t2\t22\t9\t0\t0\nNAME\t1\t120\t0\t0\t1\nDOC\t112\t4000\t0\t0\t2\nAT\t181\t13\t0\t6\t0\n" +
+		"NNAME\t1\t80\t0\t0\t2\nCODE\t96\t3\t0\t0\t1\nNCODE\t96\t4\t0\t0\t2\nTEXT\t112\t4000\t0\t0\t1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(columns))
+	for i, c := range columns {
+		got[i] = c.String()
+	}
+	if s, want := strings.Join(got, ", "), "id NUMBER(9), name VARCHAR2(120), doc NCLOB, at TIMESTAMP WITH TIME ZONE, "+
+		"nname NVARCHAR2(80), code CHAR(3), ncode NCHAR(4), text CLOB"; s != want {
+		t.Errorf("got %q, wanted %q", s, want)
+	}
+}
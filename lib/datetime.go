@@ -0,0 +1,85 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The datetime types, as ALL_ARGUMENTS.DATA_TYPE names them.
+//
+// The TIMESTAMPs with time zone are time.Time (google.protobuf.Timestamp) as the DATEs are,
+// bound as TIMESTAMP WITH TIME ZONE, so the instant is kept.
+// INTERVAL DAY TO SECOND is time.Duration (google.protobuf.Duration).
+// INTERVAL YEAR TO MONTH is a string of [+-]years-months (as "+01-02"), as the length
+// of a month varies, and godror reads it as such - the database converts it implicitly.
+const (
+	typeTimestampTZ  = "TIMESTAMP WITH TIME ZONE"
+	typeTimestampLTZ = "TIMESTAMP WITH LOCAL TIME ZONE"
+	typeIntervalDS   = "INTERVAL DAY TO SECOND"
+	typeIntervalYM   = "INTERVAL YEAR TO MONTH"
+)
+
+// datetimeAliases are the names of the datetime types in ALL_PLSQL_TYPE_ATTRS,
+// and of their unconstrained PL/SQL subtypes (ALL_ARGUMENTS.PLS_TYPE).
+var datetimeAliases = map[string]string{
+	"TIMESTAMP_UNCONSTRAINED":     "TIMESTAMP",
+	"TIMESTAMP WITH TZ":           typeTimestampTZ,
+	"TIMESTAMP_TZ_UNCONSTRAINED":  typeTimestampTZ,
+	"TIMESTAMP WITH LOCAL TZ":     typeTimestampLTZ,
+	"TIMESTAMP_LTZ_UNCONSTRAINED": typeTimestampLTZ,
+	"DSINTERVAL_UNCONSTRAINED":    typeIntervalDS,
+	"YMINTERVAL_UNCONSTRAINED":    typeIntervalYM,
+}
+
+var rDatetimePrecision = regexp.MustCompile(`\s*\([0-9]+\)`)
+
+// canonicalDatetime returns the name of the datetime type typ as ALL_ARGUMENTS.DATA_TYPE has it,
+// without the precisions: "TIMESTAMP(6) WITH TZ" is "TIMESTAMP WITH TIME ZONE".
+// Any other type is returned as is.
+func canonicalDatetime(typ string) string {
+	if !strings.HasPrefix(typ, "TIMESTAMP") && !strings.HasPrefix(typ, "INTERVAL") &&
+		!strings.HasSuffix(typ, "_UNCONSTRAINED") {
+		return typ
+	}
+	typ = rDatetimePrecision.ReplaceAllString(typ, "")
+	if s, ok := datetimeAliases[typ]; ok {
+		return s
+	}
+	return typ
+}
+
+// ParseIntervalYM checks that s is an INTERVAL YEAR TO MONTH, as [+-]years-months.
+// The empty string is NULL.
+func ParseIntervalYM(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	unsigned := s
+	if s[0] == '+' || s[0] == '-' {
+		unsigned = s[1:]
+	}
+	years, months, ok := strings.Cut(unsigned, "-")
+	if !ok || !isDigits(years) || !isDigits(months) {
+		return fmt.Errorf("want [+-]years-months, has %q", s)
+	}
+	if m, _ := strconv.Atoi(months); m > 11 {
+		return fmt.Errorf("want at most 11 months, has %q", s)
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || '9' < r {
+			return false
+		}
+	}
+	return s != ""
+}
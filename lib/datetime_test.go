@@ -0,0 +1,102 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"strings"
+	"testing"
+)
+
+// datetimeFunctions returns TST_DT.SHIFT(P_AT IN TIMESTAMP WITH TIME ZONE, P_BY IN INTERVAL DAY TO SECOND,
+// P_AGE IN/OUT INTERVAL YEAR TO MONTH, P_REC OUT REC_T) RETURN TIMESTAMP WITH TIME ZONE,
+// where REC_T is a RECORD (LOCAL_AT TIMESTAMP WITH LOCAL TIME ZONE, SPAN INTERVAL DAY TO SECOND).
+func datetimeFunctions(t *testing.T) []Function {
+	ltzT := &PlsType{TypeName: TypeName{Name: "TIMESTAMP(6) WITH LOCAL TZ"}, Attr: "LOCAL_AT"}
+	dsT := &PlsType{TypeName: TypeName{Name: "INTERVAL DAY(2) TO SECOND(6)"}, Attr: "SPAN"}
+	recT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_DT", Name: "REC_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{ltzT, dsT}}
+
+	return fixtureFunctions(t, fixtureSnapshot("TST_DT", []fixtureArg{
+		{"SHIFT", "", "OUT", typeTimestampTZ, "TIMESTAMP_TZ_UNCONSTRAINED"},
+		{"SHIFT", "P_AT", "IN", typeTimestampTZ, "TIMESTAMP_TZ_UNCONSTRAINED"},
+		{"SHIFT", "P_BY", "IN", typeIntervalDS, "DSINTERVAL_UNCONSTRAINED"},
+		{"SHIFT", "P_AGE", "IN/OUT", typeIntervalYM, "YMINTERVAL_UNCONSTRAINED"},
+		{"SHIFT", "P_REC", "OUT", "PL/SQL RECORD", "OWNR.TST_DT.REC_T"},
+	}, ltzT, dsT, recT))
+}
+
+func TestDatetimeArguments(t *testing.T) {
+	functions := datetimeFunctions(t)
+	if len(functions) != 1 {
+		t.Fatalf("got %d functions, wanted 1", len(functions))
+	}
+	fun := functions[0]
+	want := map[string]string{"p_at": "time.Time", "p_by": "time.Duration", "p_age": "string"}
+	for _, arg := range fun.Args[:3] {
+		if got, err := arg.goType(false); err != nil {
+			t.Errorf("%s: %+v", arg.Name, err)
+		} else if got != want[arg.Name] {
+			t.Errorf("%s: got %q, wanted %q", arg.Name, got, want[arg.Name])
+		}
+	}
+	if got, err := fun.Returns.goType(false); err != nil || got != "time.Time" {
+		t.Errorf("returns %q (%+v), wanted time.Time", got, err)
+	}
+	rec := fun.Args[len(fun.Args)-1]
+	if got := rec.RecordOf[0].Type + "," + rec.RecordOf[1].Type; got != typeTimestampLTZ+","+typeIntervalDS {
+		t.Errorf("record fields are %q", got)
+	}
+
+	var buf strings.Builder
+	if err := SaveProtobuf(&buf, functions, "dt"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`import "google/protobuf/duration.proto";`,
+		"google.protobuf.Timestamp p_at = 1",
+		"google.protobuf.Duration p_by = 2 [(gogoproto.nullable)=false, (gogoproto.stdduration)=true];",
+		"string p_age = 3;",
+		"google.protobuf.Duration span = 2",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q is not in the proto:\n%s", want, buf.String())
+		}
+	}
+
+	defer func(old bool) { ProtoAPIv2 = old }(ProtoAPIv2)
+	ProtoAPIv2 = true
+	fun = datetimeFunctions(t)[0]
+	_, callFun := fun.PlsqlBlock("")
+	for _, want := range []string{
+		"custom.TimeFromTimestamp(input.PAt)",
+		"custom.AsDuration(input.PBy)",
+		"output.PAge = input.PAge",
+		"output.PRec.Span = custom.AsDurationProto(var_",
+	} {
+		if !strings.Contains(callFun, want) {
+			t.Errorf("%q is not in the call:\n%s", want, callFun)
+		}
+	}
+
+	var checks strings.Builder
+	if _, err := fun.GenChecks(&checks); err != nil {
+		t.Fatal(err)
+	}
+	if want := "genocall.ParseIntervalYM(s.PAge)"; !strings.Contains(checks.String(), want) {
+		t.Errorf("%q is not in the checks:\n%s", want, checks.String())
+	}
+}
+
+func TestParseIntervalYM(t *testing.T) {
+	for _, s := range []string{"", "1-2", "+01-02", "-10-11"} {
+		if err := ParseIntervalYM(s); err != nil {
+			t.Errorf("%q: %+v", s, err)
+		}
+	}
+	for _, s := range []string{"1", "1-12", "+-1-2", "1-a", "P1Y2M"} {
+		if err := ParseIntervalYM(s); err == nil {
+			t.Errorf("%q: wanted an error", s)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureArg is an argument of a function of the test fixtures, as a row of user_arguments.
+//
+// Type is the PL/SQL type of a simple argument, or the OWNER.PACKAGE.SUBNAME (OWNER.NAME) of the type of the others.
+type fixtureArg struct {
+	Object, Name, InOut, DataType, Type string
+}
+
+// fixtureSnapshot returns the snapshot of the package's functions of the args, with the types.
+func fixtureSnapshot(pkg string, args []fixtureArg, types ...*PlsType) *Snapshot {
+	snap := Snapshot{Arguments: make([]UserArgument, 0, len(args))}
+	for _, a := range args {
+		ua := UserArgument{
+			PackageName: pkg, ObjectName: a.Object, LastDDL: time.Date(2023, 8, 17, 10, 11, 12, 0, time.UTC),
+			ArgumentName: a.Name, InOut: a.InOut, DataType: a.DataType,
+		}
+		switch parts := strings.Split(a.Type, "."); len(parts) {
+		case 1:
+			ua.PlsType = a.Type
+		case 2:
+			ua.TypeOwner, ua.TypeName = parts[0], parts[1]
+		default:
+			ua.TypeOwner, ua.TypeName, ua.TypeSubname = parts[0], parts[1], parts[2]
+		}
+		snap.Arguments = append(snap.Arguments, ua)
+	}
+	if len(types) != 0 {
+		m := make(map[TypeName]*PlsType, len(types))
+		for _, t := range types {
+			m[t.TypeName] = t
+		}
+		snap.Types = flattenTypes(m)
+	}
+	return &snap
+}
+
+// fixtureFunctions returns the functions of the snapshot, with its annotations and the further ones applied,
+// as the command does.
+func fixtureFunctions(t *testing.T, snap *Snapshot, annotations ...Annotation) []Function {
+	t.Helper()
+	functions, annots, err := snap.Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ApplyAnnotations(functions, append(annots, annotations...))
+}
@@ -0,0 +1,296 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The generated server's HTTPHandler calls the functions with a POST of the JSON input
+// to /<package>/<function> (lowercase), and responds with the JSON output -
+// or, for the functions with REF CURSOR outputs (or streamed LOB outputs), with the outputs as NDJSON.
+// The streamed LOB inputs are in the one JSON input.
+
+// StatusError returns the error of a function as a gRPC status error, for HTTPStatus:
+// orsrv sets it to orsrv.StatusError, mapping the Oracle errors, too.
+var StatusError = func(err error) error {
+	if errors.Is(err, ErrInvalidArgument) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
+
+// HTTPStatus returns the HTTP status of the error of a function, by the code of StatusError(err),
+// as google.rpc.Code maps them - so the HTTP responses match the gRPC ones.
+func HTTPStatus(err error) int {
+	switch status.Code(StatusError(err)) {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// httpImports returns the imports of httpCommon.
+func httpImports() []string {
+	if ProtoAPIv2 && !SQLOnly {
+		return []string{"net/http", "google.golang.org/protobuf/encoding/protojson", "google.golang.org/protobuf/proto"}
+	}
+	return []string{"net/http"}
+}
+
+// httpPath returns the path of the function for HTTPHandler.
+func (f Function) httpPath() string {
+	return "/" + strings.ToLower(f.Package) + "/" + strings.ToLower(f.shortName())
+}
+
+// httpInit returns the registration of the function for HTTPHandler.
+func (f Function) httpInit() string {
+	method := CamelCase(strings.Replace(f.AliasedName(), ".", "__", -1))
+	if f.streamsInput() {
+		return fmt.Sprintf("\thttpRoutes[%q] = httpClientStreaming[%s, %s]((*genocallServer).%s)",
+			f.httpPath(),
+			withPb(CamelCase(f.getStructName(false, false))), withPb(CamelCase(f.getStructName(true, false))),
+			method)
+	}
+	if f.streamsOutput() {
+		return fmt.Sprintf("\thttpRoutes[%q] = httpStreaming[%s, %s]((*genocallServer).%s)",
+			f.httpPath(),
+			withPb(CamelCase(f.getStructName(false, false))), withPb(CamelCase(f.getStructName(true, false))),
+			method)
+	}
+	return fmt.Sprintf("\thttpRoutes[%q] = httpUnary((*genocallServer).%s)", f.httpPath(), method)
+}
+
+// httpCommon returns the HTTPHandler of the generated server, and its helpers.
+func httpCommon() string {
+	marshal := `
+func httpMarshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func httpUnmarshal(b []byte, v interface{}) error { return json.Unmarshal(b, v) }
+`
+	var grpcStream string
+	if !SQLOnly {
+		if ProtoAPIv2 {
+			marshal = `
+func httpMarshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.Marshal(m)
+	}
+	return json.Marshal(v)
+}
+
+func httpUnmarshal(b []byte, v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.Unmarshal(b, m)
+	}
+	return json.Unmarshal(b, v)
+}
+`
+		}
+		grpcStream = `
+// the rest of grpc.ServerStream
+func (s *httpStream[T]) SetHeader(metadata.MD) error  { return nil }
+func (s *httpStream[T]) SendHeader(metadata.MD) error { return nil }
+func (s *httpStream[T]) SetTrailer(metadata.MD)       {}
+func (s *httpStream[T]) SendMsg(m interface{}) error  { return s.Send(m.(*T)) }
+func (s *httpStream[T]) RecvMsg(m interface{}) error  { return io.EOF }
+`
+	}
+	return `
+type httpRoute func(s *genocallServer, w http.ResponseWriter, r *http.Request)
+
+// httpRoutes are the functions for HTTPHandler, by their path.
+var httpRoutes = make(map[string]httpRoute)
+
+// HTTPHandler returns a handler calling the functions with a POST of the JSON input
+// to /<package>/<function> (lowercase), and responding with the JSON output -
+// or, for the functions with REF CURSOR outputs, with the outputs as NDJSON,
+// one line for each batch of rows (or chunk of a streamed LOB), and an {"error": "..."} line if the function fails.
+func (s *genocallServer) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	for path, route := range httpRoutes {
+		route := route
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", http.MethodPost)
+				httpError(w, http.StatusMethodNotAllowed, errors.New("only POST is allowed"))
+				return
+			}
+			route(s, w, r)
+		})
+	}
+	return mux
+}
+` + marshal + `
+// httpDecode decodes the JSON body of the request into input - an empty body is an empty input.
+func httpDecode(r *http.Request, input interface{}) error {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil || len(strings.TrimSpace(string(b))) == 0 {
+		return err
+	}
+	return httpUnmarshal(b, input)
+}
+
+// httpErrorBody is the JSON body of an error, with the violations of the input (see the Check functions).
+type httpErrorBody struct {
+	Error      string              ` + "`json:\"error\"`" + `
+	Violations genocall.Violations ` + "`json:\"violations,omitempty\"`" + `
+}
+
+func newHTTPErrorBody(err error) httpErrorBody {
+	body := httpErrorBody{Error: err.Error()}
+	errors.As(err, &body.Violations)
+	return body
+}
+
+// httpStatus returns the status of the error of a function, by its gRPC code.
+func httpStatus(err error) int { return genocall.HTTPStatus(err) }
+
+func httpError(w http.ResponseWriter, code int, err error) {
+	b, _ := json.Marshal(newHTTPErrorBody(err))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(append(b, '\n'))
+}
+
+func httpUnary[I, O any](call func(*genocallServer, context.Context, *I) (*O, error)) httpRoute {
+	return func(s *genocallServer, w http.ResponseWriter, r *http.Request) {
+		input := new(I)
+		if err := httpDecode(r, input); err != nil {
+			httpError(w, http.StatusBadRequest, err)
+			return
+		}
+		output, err := call(s, r.Context(), input)
+		if err != nil {
+			httpError(w, httpStatus(err), err)
+			return
+		}
+		b, err := httpMarshal(output)
+		if err != nil {
+			httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(b, '\n'))
+	}
+}
+
+func httpStreaming[I, O, S any](call func(*genocallServer, *I, S) error) httpRoute {
+	return func(s *genocallServer, w http.ResponseWriter, r *http.Request) {
+		input := new(I)
+		if err := httpDecode(r, input); err != nil {
+			httpError(w, http.StatusBadRequest, err)
+			return
+		}
+		stream := &httpStream[O]{ctx: r.Context(), w: w}
+		httpStreamEnd(stream, call(s, input, interface{}(stream).(S)))
+	}
+}
+
+func httpClientStreaming[I, O, S any](call func(*genocallServer, S) error) httpRoute {
+	return func(s *genocallServer, w http.ResponseWriter, r *http.Request) {
+		input := new(I)
+		if err := httpDecode(r, input); err != nil {
+			httpError(w, http.StatusBadRequest, err)
+			return
+		}
+		stream := &httpClientStream[I, O]{httpStream: &httpStream[O]{ctx: r.Context(), w: w}, input: input}
+		httpStreamEnd(stream.httpStream, call(s, interface{}(stream).(S)))
+	}
+}
+
+// httpStreamEnd writes the error of the function, if any - as the last NDJSON line, if the outputs are sent already.
+func httpStreamEnd[T any](stream *httpStream[T], err error) {
+	if err == nil {
+		return
+	}
+	if !stream.sent {
+		httpError(stream.w, httpStatus(err), err)
+		return
+	}
+	// the status is sent already
+	b, _ := json.Marshal(newHTTPErrorBody(err))
+	stream.w.Write(append(b, '\n'))
+}
+
+// httpStream writes the outputs as NDJSON.
+type httpStream[T any] struct {
+	ctx  context.Context
+	w    http.ResponseWriter
+	sent bool
+}
+
+func (s *httpStream[T]) Context() context.Context { return s.ctx }
+
+func (s *httpStream[T]) Send(output *T) error {
+	b, err := httpMarshal(output)
+	if err != nil {
+		return err
+	}
+	if !s.sent {
+		s.sent = true
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	if _, err = s.w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// httpClientStream is the stream of the functions with streamed LOB inputs:
+// the whole input is in the request, so it is received at once.
+type httpClientStream[I, O any] struct {
+	*httpStream[O]
+	input *I
+}
+
+func (s *httpClientStream[I, O]) Recv() (*I, error) {
+	input := s.input
+	if input == nil {
+		return nil, io.EOF
+	}
+	s.input = nil
+	return input, nil
+}
+
+// SendAndClose writes the only output, as JSON.
+func (s *httpClientStream[I, O]) SendAndClose(output *O) error {
+	if !s.sent {
+		s.sent = true
+		s.w.Header().Set("Content-Type", "application/json")
+	}
+	return s.Send(output)
+}
+` + grpcStream
+}
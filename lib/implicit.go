@@ -0,0 +1,260 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The implicit results (DBMS_SQL.RETURN_RESULT) of a procedure are not in its arguments,
+// so the record types of its result sets are given by an annotation, in their order:
+//
+//	--genocall:implicit-results list_all => emp_rec_t, dept_rec_t
+//
+// Each result set is an output field named after its record type, and is streamed
+// as the rows of a REF CURSOR output - the result sets are read one after the other,
+// so the outputs have the rows of the first one, then of the next one.
+
+// implicitTypeName returns the name of the record type of an implicit result set,
+// as given in the annotation of the package: of the package, or qualified by its package (and owner).
+func implicitTypeName(owner, pkg, name string) TypeName {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(name)), ".")
+	switch len(parts) {
+	case 1:
+		return TypeName{Owner: owner, Package: pkg, Name: parts[0]}
+	case 2:
+		return TypeName{Owner: owner, Package: parts[0], Name: parts[1]}
+	}
+	return TypeName{Owner: parts[0], Package: parts[1], Name: strings.Join(parts[2:], ".")}
+}
+
+// setImplicitResults adds an output (as a REF CURSOR) for each record type of the implicit results,
+// given by an implicit-results annotation of the package.
+func (f *Function) setImplicitResults(types map[TypeName]*PlsType, owner, pkg, text string) error {
+	args := append([]Argument(nil), f.Args...)
+	seen := make(map[string]int)
+	for _, nm := range strings.Split(text, ",") {
+		tn := implicitTypeName(owner, pkg, nm)
+		t := types[tn]
+		if t == nil || len(t.RecordOf) == 0 {
+			return fmt.Errorf("%s: %w", tn, errors.New("unknown record type"))
+		}
+		rec := argumentOfType("", t, DIR_OUT)
+		name := strings.ToLower(tn.Name)
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+		arg := NewArgument(name, "REF CURSOR", "REF CURSOR", "", "", DIR_OUT, "", 0, 0, 0, nil)
+		arg.TableOf, arg.implicitResult = &rec, true
+		args = append(args, arg)
+	}
+	f.Args = args
+	return nil
+}
+
+// implicitResults returns the outputs of the implicit result sets.
+func (f Function) implicitResults() []Argument {
+	var results []Argument
+	for _, arg := range f.Args {
+		if arg.implicitResult {
+			results = append(results, arg)
+		}
+	}
+	return results
+}
+
+// getConvImplicitResults returns the declarations and the statements of the PL/SQL block
+// binding a cursor of the statement, for reaching its implicit results, and appends
+// the iterator reading the result sets into the results outputs to convOut
+// - or an iterator for each result set, when they are tagged (see TaggedCursors).
+func (fun Function) getConvImplicitResults(
+	convIn, convOut []string,
+	results []Argument,
+	addParam func(string) string,
+) (decls, post, _, _ []string) {
+	vn := getInnerVarName(fun.FullName(), "implicit")
+	paramName := addParam(getParamName(fun.FullName(), "implicit.rset"))
+	decls = append(decls, vn+" SYS_REFCURSOR; --I")
+	post = append(post, ":"+getParamName(fun.FullName(), "implicit.rset")+" := "+vn+";")
+
+	convIn = append(convIn, paramName+" = sql.Out{Dest:new(driver.Rows)} // implicit results")
+	rset := fmt.Sprintf(`
+		rset := *(%s.(sql.Out).Dest.(*driver.Rows))
+		if rset != nil { defer rset.Close() }
+		results := genocall.NewImplicitResults(rset)`, paramName)
+	if fun.tagsCursors() {
+		// each result set is a tagged cursor of its own
+		iterators := make([]string, 0, len(results))
+		for i, arg := range results {
+			name := CamelCase(arg.Name)
+			got, err := arg.goType(true)
+			if err != nil {
+				panic(err)
+			}
+			bv, decl, tag := fun.cursorTag(arg).batchVar(name, withPb(CamelCase("*"+strings.TrimPrefix(got, "*"))), fun.batchRows())
+			convIn = append(convIn, decl)
+			n := len(arg.TableOf.RecordOf)
+			iterators = append(iterators, fmt.Sprintf(`
+			{
+			var pending func() bool
+			iterators = append(iterators, iterator{
+				Reset: func() { %s.Rows = nil },
+				Iterate: func() error {
+					%s.Rows = %s.Rows[:0]
+					%s
+					for ; n < batchRows; n++ {
+						I, err := results.NextOf(%d)
+						if err != nil {
+							return err
+						}
+						if len(I) < %d {
+							return errors.Errorf("implicit result %d has %%d columns, wanted %d", len(I))
+						}
+						%s
+					}
+					return nil
+				},
+				%s
+			})
+			}`, bv, bv, bv, batchStart, i, n, i+1, n, batchAppend(bv+".Rows", arg.getFromRset("I")), tag))
+		}
+		convOut = append(convOut, "{"+rset+strings.Join(iterators, "")+"\n}")
+		return decls, post, convIn, convOut
+	}
+
+	var reset, truncate, cases strings.Builder
+	for i, arg := range results {
+		name := CamelCase(arg.Name)
+		got, err := arg.goType(true)
+		if err != nil {
+			panic(err)
+		}
+		convIn = append(convIn, fmt.Sprintf("output.%s = make([]%s, 0, %d) // implicit",
+			name, withPb(CamelCase("*"+strings.TrimPrefix(got, "*"))), fun.batchRows()))
+		fmt.Fprintf(&reset, "output.%s = nil\n", name)
+		fmt.Fprintf(&truncate, "output.%s = output.%s[:0]\n", name, name)
+		n := len(arg.TableOf.RecordOf)
+		fmt.Fprintf(&cases, `case %d:
+			if len(I) < %d {
+				return errors.Errorf("implicit result %d has %%d columns, wanted %d", len(I))
+			}
+			%s
+			`, i, n, i+1, n, batchAppend("output."+name, arg.getFromRset("I")))
+	}
+	convOut = append(convOut, fmt.Sprintf(`
+	{%s
+		var pending func() bool
+		iterators = append(iterators, iterator{
+			Reset: func() {
+				%s
+			},
+			Iterate: func() error {
+				%s
+				%s
+				for ; n < batchRows; n++ {
+					set, I, err := results.Next()
+					if err != nil {
+						return err
+					}
+					switch set {
+					%s
+					}
+				}
+				return nil
+			},
+		})
+	}`,
+		rset,
+		reset.String(),
+		truncate.String(),
+		batchStart,
+		cases.String(),
+	))
+	return decls, post, convIn, convOut
+}
+
+// ImplicitResults reads the implicit result sets (DBMS_SQL.RETURN_RESULT) of a statement,
+// through a cursor of it, one after the other.
+type ImplicitResults struct {
+	rows driver.Rows
+	set  int
+	row  []driver.Value
+	err  error
+}
+
+// NewImplicitResults returns the reader of the implicit results of the statement of rows
+// (the rows themselves are not read).
+func NewImplicitResults(rows driver.Rows) *ImplicitResults {
+	return &ImplicitResults{rows: rows, set: -1}
+}
+
+// Next returns the next row, and the index of its result set.
+// At the end of a result set, the next one is read; io.EOF is returned after the last.
+//
+// The row is valid till the next call only.
+func (ir *ImplicitResults) Next() (int, []driver.Value, error) {
+	if ir.rows == nil && ir.err == nil {
+		ir.err = io.EOF
+	}
+	for ir.err == nil {
+		if ir.set >= 0 {
+			err := ir.rows.Next(ir.row)
+			if err == nil {
+				return ir.set, ir.row, nil
+			}
+			if !errors.Is(err, io.EOF) {
+				ir.err = err
+				break
+			}
+		}
+		ir.nextSet()
+	}
+	return ir.set, nil, ir.err
+}
+
+// NextOf returns the next row of the set-th result set, skipping the result sets before it,
+// or io.EOF at the end of it.
+//
+// The row is valid till the next call only.
+func (ir *ImplicitResults) NextOf(set int) ([]driver.Value, error) {
+	if ir.rows == nil && ir.err == nil {
+		ir.err = io.EOF
+	}
+	for ir.err == nil && ir.set < set {
+		ir.nextSet()
+	}
+	if ir.err != nil {
+		return nil, ir.err
+	}
+	if ir.set > set {
+		return nil, io.EOF
+	}
+	if err := ir.rows.Next(ir.row); err != nil {
+		if !errors.Is(err, io.EOF) {
+			ir.err = err
+		}
+		return nil, err
+	}
+	return ir.row, nil
+}
+
+// nextSet steps to the next result set, setting err to io.EOF after the last.
+func (ir *ImplicitResults) nextSet() {
+	nrs, ok := ir.rows.(driver.RowsNextResultSet)
+	if !ok || !nrs.HasNextResultSet() {
+		ir.err = io.EOF
+		return
+	}
+	if err := nrs.NextResultSet(); err != nil {
+		ir.err = err
+		return
+	}
+	ir.set++
+	ir.row = make([]driver.Value, len(ir.rows.Columns()))
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// implicitFunctions returns TST_IMPL.LIST_ALL(P_DEPT IN NUMBER), returning the implicit results
+// of EMP_REC_T (ID NUMBER, NAME VARCHAR2(30)) and DEPT_REC_T (ID NUMBER, CREATED DATE) records.
+func implicitFunctions(t *testing.T) []Function {
+	idT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "ID"}
+	nameT := &PlsType{TypeName: TypeName{Name: "VARCHAR2"}, Attr: "NAME", Length: sql.NullInt64{Int64: 30, Valid: true}}
+	createdT := &PlsType{TypeName: TypeName{Name: "DATE"}, Attr: "CREATED"}
+	empT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_IMPL", Name: "EMP_REC_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{idT, nameT}}
+	deptT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_IMPL", Name: "DEPT_REC_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{idT, createdT}}
+	snap := fixtureSnapshot("TST_IMPL", []fixtureArg{
+		{"LIST_ALL", "P_DEPT", "IN", "NUMBER", ""},
+	}, idT, nameT, createdT, empT, deptT)
+	snap.Packages = []SnapshotPackage{{Name: "TST_IMPL", Owner: "OWNR", Source: `CREATE OR REPLACE PACKAGE TST_IMPL IS
+  TYPE emp_rec_t IS RECORD (id NUMBER, name VARCHAR2(30));
+  TYPE dept_rec_t IS RECORD (id NUMBER, created DATE);
+  --genocall:implicit-results list_all => emp_rec_t, dept_rec_t
+  PROCEDURE list_all(p_dept IN NUMBER);
+END TST_IMPL;
+`}}
+	return fixtureFunctions(t, snap)
+}
+
+func TestImplicitResultsCall(t *testing.T) {
+	functions := implicitFunctions(t)
+	if len(functions) != 1 {
+		t.Fatalf("got %d functions, wanted 1", len(functions))
+	}
+	fun := functions[0]
+	if got := len(fun.implicitResults()); got != 2 {
+		t.Fatalf("got %d implicit results, wanted 2", got)
+	}
+	var buf strings.Builder
+	if err := SaveProtobufService(&buf, functions, "impl", "TstImpl", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"rpc ListAll (ListAll_Input) returns (stream ListAll_Output) {}",
+		"repeated TstImpl_EmpRecT_Ownr emp_rec_t = 1;",
+		"repeated TstImpl_DeptRecT_Ownr dept_rec_t = 2;",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q is not in the proto:\n%s", want, buf.String())
+		}
+	}
+
+	plsBlock, callFun := fun.PlsqlBlock("")
+	if !strings.Contains(plsBlock, ".list_all(p_dept=>:1);") {
+		t.Errorf("the implicit results are in the call:\n%s", plsBlock)
+	}
+	if !strings.Contains(plsBlock, " SYS_REFCURSOR;") {
+		t.Errorf("no cursor for the implicit results:\n%s", plsBlock)
+	}
+	for _, want := range []string{
+		"results := genocall.NewImplicitResults(rset)",
+		"row := &pb.TstImpl_EmpRecT_Ownr{",
+		"output.EmpRecT = append(output.EmpRecT, row)",
+		"output.DeptRecT = append(output.DeptRecT, row)",
+	} {
+		if !strings.Contains(callFun, want) {
+			t.Errorf("%q is not in the call:\n%s", want, callFun)
+		}
+	}
+}
+
+// resultSets is a driver.Rows of the result sets, for ImplicitResults.
+type resultSets struct {
+	sets [][][]driver.Value
+	set  int
+	row  int
+}
+
+func (r *resultSets) Columns() []string {
+	if r.set < 0 || len(r.sets[r.set]) == 0 {
+		return nil
+	}
+	return make([]string, len(r.sets[r.set][0]))
+}
+func (r *resultSets) Close() error { return nil }
+func (r *resultSets) Next(dest []driver.Value) error {
+	if r.set < 0 || r.row >= len(r.sets[r.set]) {
+		return io.EOF
+	}
+	copy(dest, r.sets[r.set][r.row])
+	r.row++
+	return nil
+}
+func (r *resultSets) HasNextResultSet() bool { return r.set+1 < len(r.sets) }
+func (r *resultSets) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.set, r.row = r.set+1, 0
+	return nil
+}
+
+func TestImplicitResults(t *testing.T) {
+	ir := NewImplicitResults(&resultSets{set: -1, sets: [][][]driver.Value{
+		{{1, "a"}, {2, "b"}},
+		{},
+		{{"x"}},
+	}})
+	var got []string
+	for {
+		set, row, err := ir.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatal(err)
+			}
+			break
+		}
+		got = append(got, fmt.Sprintf("%d:%v", set, row))
+	}
+	if s, want := strings.Join(got, " "), "0:[1 a] 0:[2 b] 2:[x]"; s != want {
+		t.Errorf("got %q, wanted %q", s, want)
+	}
+	if _, _, err := NewImplicitResults(nil).Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("nil rows: got %v, wanted io.EOF", err)
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// lastDDLMark starts the header lines of the generated Go files
+// recording each package's last DDL time.
+const lastDDLMark = "// gen-o-call:last-ddl "
+
+// fingerprintMark starts the header line of the generated Go files
+// recording the Fingerprint of the generator and its settings.
+const fingerprintMark = "// gen-o-call:fingerprint "
+
+// Fingerprint returns a hash of the generator's version, the package-level options
+// (NumberAsString, MaxTableSize, SkipMissingTableOf, NativeBoolean, BfileContent, LobStreaming,
+// TaggedCursors, BatchSize) and the given settings
+// - everything which changes the output for the same packages.
+func Fingerprint(settings ...string) string {
+	h := sha256.New()
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(h, "%s@%s\n", bi.Main.Path, bi.Main.Version)
+		for _, s := range bi.Settings {
+			if strings.HasPrefix(s.Key, "vcs.") {
+				fmt.Fprintf(h, "%s=%s\n", s.Key, s.Value)
+			}
+		}
+		for _, m := range bi.Deps {
+			if m.Path == "github.com/godror/gen-o-call" {
+				fmt.Fprintf(h, "%s@%s\n", m.Path, m.Version)
+			}
+		}
+	}
+	fmt.Fprintf(h, "NumberAsString=%t\nMaxTableSize=%d\nSkipMissingTableOf=%t\nNativeBoolean=%t\nBfileContent=%t\nLobStreaming=%t\nTaggedCursors=%t\nBatchSize=%d\n",
+		NumberAsString, MaxTableSize, SkipMissingTableOf, NativeBoolean, BfileContent, LobStreaming, TaggedCursors, BatchSize)
+	for _, s := range settings {
+		fmt.Fprintf(h, "%q\n", s)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// PackageLastDDLs returns the latest LastDDL of the functions, per package.
+func PackageLastDDLs(functions []Function) map[string]time.Time {
+	m := make(map[string]time.Time)
+	for _, f := range functions {
+		if t, ok := m[f.Package]; !ok || f.LastDDL.After(t) {
+			m[f.Package] = f.LastDDL
+		}
+	}
+	return m
+}
+
+func writeLastDDLs(w io.Writer, functions []Function, fingerprint string) error {
+	if fingerprint != "" {
+		if _, err := io.WriteString(w, fingerprintMark+fingerprint+"\n"); err != nil {
+			return err
+		}
+	}
+	m := PackageLastDDLs(functions)
+	pkgs := make([]string, 0, len(m))
+	for k := range m {
+		pkgs = append(pkgs, k)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		if t := m[pkg]; !t.IsZero() {
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", lastDDLMark, pkg, t.UTC().Format(time.RFC3339Nano)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReadLastDDLs reads the package DDL times and the fingerprint recorded in the header
+// of a previously generated Go file.
+func ReadLastDDLs(r io.Reader) (map[string]time.Time, string, error) {
+	m := make(map[string]time.Time)
+	var fingerprint string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if strings.HasPrefix(line, fingerprintMark) {
+			fingerprint = strings.TrimSpace(line[len(fingerprintMark):])
+			continue
+		}
+		if !strings.HasPrefix(line, lastDDLMark) {
+			continue
+		}
+		pkg, ts, ok := strings.Cut(line[len(lastDDLMark):], " ")
+		if !ok {
+			return m, fingerprint, fmt.Errorf("bad last-ddl line %q", line)
+		}
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return m, fingerprint, fmt.Errorf("%q: %w", line, err)
+		}
+		m[pkg] = t
+	}
+	return m, fingerprint, scanner.Err()
+}
+
+// ReadLastDDLsFile is ReadLastDDLs for the named file.
+// A missing file has no recorded DDL times, and that is not an error.
+func ReadLastDDLsFile(fileName string) (map[string]time.Time, string, error) {
+	fh, err := os.Open(fileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	defer fh.Close()
+	return ReadLastDDLs(fh)
+}
+
+// SameLastDDLs reports whether the recorded DDL times are the same as the current ones,
+// for the same set of packages.
+// An unknown (zero) DDL time is never the same.
+func SameLastDDLs(recorded, current map[string]time.Time) bool {
+	if len(recorded) != len(current) || len(current) == 0 {
+		return false
+	}
+	for k, t := range current {
+		if r, ok := recorded[k]; !ok || t.IsZero() || !r.Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// PackageFileSuffix is appended to the lowercased package name, to get
+// the name of the Go file written by SavePackages.
+const PackageFileSuffix = "_genocall.go"
+
+// SavePackages writes each package's functions into dir as <package>_genocall.go
+// and their tests as <package>_genocall_test.go,
+// and the common declarations into <common>.go and <common>_test.go.
+//
+// With SQLOnly, the structs and the Client are written into the same files.
+//
+// With incremental, the files of a package are written only if its DDL time or
+// the fingerprint (with the package's annotations) differs from the recorded ones
+// - otherwise they are left intact.
+//
+// Returns the written files, and the package files found in dir
+// which are not for any of the functions' packages (these are not removed).
+func SavePackages(dir string, functions []Function, pkg, pbImport, common, fingerprint string, incremental bool) (written, orphans []string, err error) {
+	if err = os.MkdirAll(dir, 0775); err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	groups := make(map[string][]Function)
+	for _, f := range functions {
+		if _, ok := groups[f.Package]; !ok {
+			names = append(names, f.Package)
+		}
+		groups[f.Package] = append(groups[f.Package], f)
+	}
+
+	var structs bytes.Buffer
+	var commonRecs map[string]struct{}
+	if SQLOnly {
+		if commonRecs, err = SaveStructsCommon(&structs, functions); err != nil {
+			return nil, nil, fmt.Errorf("save common structs: %w", err)
+		}
+		// the package files depend on which records are common
+		fingerprint = Fingerprint(append([]string{fingerprint}, sortedKeys(commonRecs)...)...)
+	}
+
+	var mu sync.Mutex
+	write := func(fileName string, data []byte) error {
+		ok, err := WriteFileIfChanged(fileName, data)
+		if ok {
+			mu.Lock()
+			written = append(written, fileName)
+			mu.Unlock()
+		}
+		return err
+	}
+
+	var grp errgroup.Group
+	grp.Go(func() error {
+		var buf bytes.Buffer
+		if err := SaveFunctionsCommon(&buf, functions, pkg); err != nil {
+			return fmt.Errorf("save common functions: %w", err)
+		}
+		buf.Write(structs.Bytes())
+		if err := write(filepath.Join(dir, common+".go"), buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+		if err := SaveFunctionTestsCommon(&buf, pkg); err != nil {
+			return fmt.Errorf("save common function tests: %w", err)
+		}
+		return write(filepath.Join(dir, common+"_test.go"), buf.Bytes())
+	})
+
+	files := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		name, functions := name, groups[name]
+		fn := filepath.Join(dir, strings.ToLower(name)+PackageFileSuffix)
+		// the output depends on the annotations of the package,
+		// and the names on the other packages, too (see qualifyNames)
+		fingerprint := fingerprint
+		settings := append([]string(nil), functions[0].annotations...)
+		for _, f := range functions {
+			if f.qualified {
+				settings = append(settings, f.AliasedName())
+			}
+		}
+		if len(settings) != 0 {
+			fingerprint = Fingerprint(append([]string{fingerprint}, settings...)...)
+		}
+		files[fn] = struct{}{}
+		grp.Go(func() error {
+			if incremental {
+				recorded, recordedFingerprint, err := ReadLastDDLsFile(fn)
+				if err != nil {
+					return fmt.Errorf("read %s: %w", fn, err)
+				}
+				if recordedFingerprint == fingerprint && SameLastDDLs(recorded, PackageLastDDLs(functions)) {
+					return nil
+				}
+			}
+			var buf bytes.Buffer
+			if err := SaveFunctionsPackage(&buf, functions, pkg, pbImport, fingerprint, false); err != nil {
+				return fmt.Errorf("save functions of %s: %w", name, err)
+			}
+			if SQLOnly {
+				if err := SaveStructsPackage(&buf, functions, commonRecs); err != nil {
+					return fmt.Errorf("save structs of %s: %w", name, err)
+				}
+			}
+			if err := write(fn, buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+			if err := SaveFunctionTestsPackage(&buf, functions, pkg, pbImport); err != nil {
+				return fmt.Errorf("save function tests of %s: %w", name, err)
+			}
+			return write(strings.TrimSuffix(fn, ".go")+"_test.go", buf.Bytes())
+		})
+	}
+	if err = grp.Wait(); err != nil {
+		return written, nil, err
+	}
+	sort.Strings(written)
+
+	existing, err := filepath.Glob(filepath.Join(dir, "*"+PackageFileSuffix))
+	if err != nil {
+		return written, nil, err
+	}
+	for _, fn := range existing {
+		if _, ok := files[fn]; !ok {
+			orphans = append(orphans, fn)
+		}
+	}
+	return written, orphans, nil
+}
+
+// WriteFileIfChanged writes data into the named file, iff its content differs,
+// and reports whether it has written it.
+func WriteFileIfChanged(fileName string, data []byte) (bool, error) {
+	if old, err := os.ReadFile(fileName); err == nil && bytes.Equal(old, data) {
+		return false, nil
+	}
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastDDLs(t *testing.T) {
+	functions, _, err := testSnapshot().Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generate := func() []byte {
+		var buf bytes.Buffer
+		if err := SaveFunctions(&buf, functions, "snap", "pb", false); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	first := generate()
+	if second := generate(); !bytes.Equal(first, second) {
+		t.Errorf("output differs between runs")
+	}
+
+	recorded, _, err := ReadLastDDLs(bytes.NewReader(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(recorded)
+	current := PackageLastDDLs(functions)
+	if !SameLastDDLs(recorded, current) {
+		t.Errorf("got %v, wanted %v", recorded, current)
+	}
+
+	current["TST_SNAP"] = current["TST_SNAP"].Add(time.Second)
+	if SameLastDDLs(recorded, current) {
+		t.Errorf("changed DDL time is the same")
+	}
+	delete(current, "TST_SNAP")
+	current["OTHER"] = time.Now()
+	if SameLastDDLs(recorded, current) {
+		t.Errorf("other package is the same")
+	}
+	if SameLastDDLs(nil, nil) {
+		t.Errorf("nothing is the same")
+	}
+
+	// an unknown DDL time is not the time of the generation
+	unknown := append([]Function(nil), functions...)
+	for i := range unknown {
+		unknown[i].LastDDL = time.Time{}
+	}
+	var buf bytes.Buffer
+	if err := SaveFunctionsCommon(&buf, unknown, "snap"); err != nil {
+		t.Fatal(err)
+	}
+	if want := `const LastDDL = "0001-01-01T00:00:00Z"`; !strings.Contains(buf.String(), want) {
+		t.Errorf("%q is not in the common file", want)
+	}
+
+	fp := Fingerprint("except=")
+	if fp != Fingerprint("except=") {
+		t.Error("fingerprint is not stable")
+	}
+	if fp == Fingerprint("except=a") {
+		t.Error("fingerprint ignores the settings")
+	}
+	old := MaxTableSize
+	MaxTableSize++
+	defer func() { MaxTableSize = old }()
+	if fp == Fingerprint("except=") {
+		t.Error("fingerprint ignores MaxTableSize")
+	}
+}
+
+func TestSavePackages(t *testing.T) {
+	dir := t.TempDir()
+	functions := twoPackageFunctions(t)
+	// tst_snap and tst_other
+	snapFn, otherFn := filepath.Join(dir, "tst_snap"+PackageFileSuffix), filepath.Join(dir, "tst_other"+PackageFileSuffix)
+
+	save := func(functions []Function, fingerprint string, wantWritten ...string) {
+		t.Helper()
+		written, orphans, err := SavePackages(dir, functions, "snap", "pb", "genocall", fingerprint, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(orphans) != 0 {
+			t.Errorf("orphans: %q", orphans)
+		}
+		got := make(map[string]bool, len(written))
+		for _, fn := range written {
+			got[fn] = true
+		}
+		for _, fn := range wantWritten {
+			if !got[fn] {
+				t.Errorf("%s is not written (written: %q)", fn, written)
+			}
+			delete(got, fn)
+		}
+		for fn := range got {
+			if fn != filepath.Join(dir, "genocall.go") {
+				t.Errorf("%s is written", fn)
+			}
+		}
+	}
+	testFn := func(fn string) string { return fn[:len(fn)-3] + "_test.go" }
+
+	save(functions, "fp1",
+		filepath.Join(dir, "genocall.go"), filepath.Join(dir, "genocall_test.go"),
+		snapFn, testFn(snapFn), otherFn, testFn(otherFn))
+	snapBefore, err := os.ReadFile(snapFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapStat, err := os.Stat(snapFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing changed
+	save(functions, "fp1")
+
+	// just the other package changed
+	changed := append([]Function(nil), functions...)
+	changed[1].LastDDL = changed[1].LastDDL.Add(time.Hour)
+	save(changed, "fp1", otherFn)
+	if b, err := os.ReadFile(snapFn); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(b, snapBefore) {
+		t.Errorf("%s changed", snapFn)
+	}
+	if fi, err := os.Stat(snapFn); err != nil {
+		t.Fatal(err)
+	} else if !fi.ModTime().Equal(snapStat.ModTime()) {
+		t.Errorf("%s is rewritten", snapFn)
+	}
+
+	// the generator (or its settings) changed
+	save(changed, "fp2", snapFn, otherFn)
+
+	// an annotation of the other package changed
+	annotated := ApplyAnnotations(append([]Function(nil), changed...),
+		[]Annotation{{Package: changed[1].Package, Type: "batch-size", Name: changed[1].Name, Size: 32}})
+	save(annotated, "fp2", otherFn)
+
+	// a package is gone, so the names of the other are not qualified any more
+	alone := qualifyNames(append([]Function(nil), changed[:1]...))
+	written, orphans, err := SavePackages(dir, alone, "snap", "pb", "genocall", "fp2", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 1 || orphans[0] != otherFn {
+		t.Errorf("got orphans %q, wanted %q", orphans, otherFn)
+	}
+	var rewritten bool
+	for _, fn := range written {
+		rewritten = rewritten || fn == snapFn
+	}
+	if !rewritten {
+		t.Errorf("%s is not written (written: %q)", snapFn, written)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	errors "golang.org/x/xerrors"
+)
+
+// JSONSchemaDraft is the $schema of the documents of JSONSchemas.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemas returns a JSON Schema for each input and output message of the functions,
+// by the file name (<message>.schema.json) - with the same lengths, numeric bounds
+// and NUMBER patterns as the generated Check functions enforce.
+//
+// The records of the message are in its $defs, so each document stands alone.
+func JSONSchemas(functions []Function) (map[string][]byte, error) {
+	files := make(map[string][]byte, 2*len(functions))
+FunLoop:
+	for _, f := range functions {
+		var docs [2]jsonSchemaDoc
+		for i, dirmap := range []direction{DIR_IN, DIR_OUT} {
+			b := schemaBuilder{schemas: make(map[string]*openAPISchema), refPrefix: "#/$defs/", jsonSchema: true}
+			if _, err := b.message(f, dirmap); err != nil {
+				if SkipMissingTableOf && (errors.Is(err, ErrMissingTableOf) || errors.Is(err, UnknownSimpleType)) {
+					logger.Warn("SKIP function, missing TableOf info", "function", f.FullName())
+					continue FunLoop
+				}
+				return nil, fmt.Errorf("%s: %w", f.FullName(), err)
+			}
+			name := f.messageName(dirmap)
+			docs[i] = jsonSchemaDoc{Schema: JSONSchemaDraft, Title: name, openAPISchema: b.schemas[name]}
+			delete(b.schemas, name)
+			if len(b.schemas) != 0 {
+				docs[i].Defs = b.schemas
+			}
+		}
+		for _, doc := range docs {
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(doc); err != nil {
+				return nil, fmt.Errorf("%s: %w", doc.Title, err)
+			}
+			files[doc.Title+".schema.json"] = buf.Bytes()
+		}
+	}
+	return files, nil
+}
+
+type jsonSchemaDoc struct {
+	Schema string `json:"$schema"`
+	Title  string `json:"title"`
+	*openAPISchema
+	Defs map[string]*openAPISchema `json:"$defs,omitempty"`
+}
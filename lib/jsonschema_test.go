@@ -0,0 +1,78 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONSchemas(t *testing.T) {
+	functions, annotations, err := testSnapshot().Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions = ApplyAnnotations(functions, annotations)
+	functions = append(functions, cursorFunctions(t)...)
+	files, err := JSONSchemas(functions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2*len(functions) {
+		t.Errorf("got %d files, wanted %d", len(files), 2*len(functions))
+	}
+
+	type schema struct {
+		Schema     string             `json:"$schema"`
+		Ref        string             `json:"$ref"`
+		Type       string             `json:"type"`
+		MaxLength  int                `json:"maxLength"`
+		Pattern    string             `json:"pattern"`
+		Items      *schema            `json:"items"`
+		Properties map[string]*schema `json:"properties"`
+		Defs       map[string]*schema `json:"$defs"`
+	}
+	parse := func(name string) *schema {
+		t.Helper()
+		b, ok := files[name]
+		if !ok {
+			t.Fatalf("no %s", name)
+		}
+		t.Log(string(b))
+		var s schema
+		if err := json.Unmarshal(b, &s); err != nil {
+			t.Fatalf("%s: %+v", name, err)
+		}
+		if s.Schema != JSONSchemaDraft || s.Type != "object" {
+			t.Errorf("%s: got %q %q, wanted an object of %s", name, s.Schema, s.Type, JSONSchemaDraft)
+		}
+		return &s
+	}
+
+	recIn := parse("RecIn_Input.schema.json")
+	if p := recIn.Properties["p_rec"]; p == nil || p.Ref != "#/$defs/TstSnap_RecT_Ownr" {
+		t.Fatalf("p_rec: got %+v", p)
+	}
+	if rec := recIn.Defs["TstSnap_RecT_Ownr"]; rec == nil || rec.Properties["num"] == nil || rec.Properties["num"].Pattern == "" {
+		t.Errorf("TstSnap_RecT_Ownr: got %+v, wanted num as a string of digits", rec)
+	}
+
+	listIn := parse("ListRows_Input.schema.json")
+	if len(listIn.Defs) != 0 {
+		t.Errorf("ListRows_Input: got $defs %+v, wanted none", listIn.Defs)
+	}
+	if amount := listIn.Properties["p_amount"]; amount == nil || amount.Pattern != `^-?[0-9]{1,7}(\.[0-9]{1,2})?$` {
+		t.Errorf("p_amount: got %+v, wanted a NUMBER(9,2) string", amount)
+	}
+	if name := listIn.Properties["p_name"]; name == nil || name.MaxLength != 20 {
+		t.Errorf("p_name: got %+v, wanted maxLength 20", name)
+	}
+	listOut := parse("ListRows_Output.schema.json")
+	if ret := listOut.Properties["ret"]; ret == nil || ret.Items == nil || listOut.Defs[strings.TrimPrefix(ret.Items.Ref, "#/$defs/")] == nil {
+		t.Errorf("ret: got %+v, wanted an array of records in $defs (%+v)", ret, listOut.Defs)
+	}
+}
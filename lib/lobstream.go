@@ -0,0 +1,235 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/godror/godror"
+)
+
+// With LobStreaming, the CLOB, NCLOB and BLOB arguments are not read into memory as a whole,
+// but streamed in chunks, so they are not limited by the maximum message size of gRPC:
+//
+// The functions with such inputs are client streaming: the first input message has the
+// other fields, and the value of each LOB is the concatenation of its field in all the
+// input messages - the following ones need just the chunks of the LOBs.
+//
+// The functions with such outputs are server streaming: first the chunks are sent,
+// each in an output with just the field of its LOB, one LOB after the other,
+// then the output with the other fields.
+//
+// Just the arguments are streamed, the LOB fields of records are not.
+
+// LobStreaming makes the generated functions stream their LOB arguments in chunks.
+var LobStreaming bool
+
+// LobChunkSize is the maximum size of the chunks the generated functions send the output LOBs in.
+var LobChunkSize = 1 << 20
+
+// streamsLob reports whether the argument is a LOB streamed in chunks.
+func (arg Argument) streamsLob() bool {
+	if !LobStreaming || SQLOnly || arg.Flavor != FLAVOR_SIMPLE {
+		return false
+	}
+	switch arg.Type {
+	case "CLOB", "NCLOB", "BLOB":
+		return true
+	}
+	return false
+}
+
+// streamedLobs returns the LOB arguments of the function streamed in the dir direction.
+func (f Function) streamedLobs(dir direction) []Argument {
+	var lobs []Argument
+	for _, arg := range f.Args {
+		if arg.Direction&dir != 0 && arg.streamsLob() {
+			lobs = append(lobs, arg)
+		}
+	}
+	if dir.IsOutput() && f.Returns != nil && f.Returns.streamsLob() {
+		lobs = append(lobs, *f.Returns)
+	}
+	return lobs
+}
+
+// streamsInput reports whether the inputs of the function are streamed (with chunks of LOBs).
+func (f Function) streamsInput() bool { return len(f.streamedLobs(DIR_IN)) != 0 }
+
+// streamsOutput reports whether the outputs of the function are streamed:
+// with batches of REF CURSOR rows, or chunks of LOBs.
+func (f Function) streamsOutput() bool {
+	return f.HasCursorOut() || len(f.streamedLobs(DIR_OUT)) != 0
+}
+
+// lobChunk returns the chunk of the LOB argument in the message msg, as []byte.
+func (arg Argument) lobChunk(msg string) string {
+	if arg.Type == "BLOB" {
+		return msg + "." + CamelCase(arg.Name)
+	}
+	return "[]byte(" + msg + "." + CamelCase(arg.Name) + ")"
+}
+
+// lobReceiver returns the creation of the genocall.LobReceiver (lobs) of the streamed LOB inputs,
+// from the first input, and the rest of the stream.
+func (f Function) lobReceiver() string {
+	lobs := f.streamedLobs(DIR_IN)
+	first, next := make([]string, len(lobs)), make([]string, len(lobs))
+	for i, arg := range lobs {
+		first[i], next[i] = arg.lobChunk("input"), arg.lobChunk("in")
+	}
+	return fmt.Sprintf(`lobs := genocall.NewLobReceiver(func() ([][]byte, error) {
+		in, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{%s}, nil
+	}, %s)`, strings.Join(next, ", "), strings.Join(first, ", "))
+}
+
+// getConvStreamedLob returns the declarations and statements of the PL/SQL block
+// for the streamed LOB argument - an IN OUT LOB needs a variable, bound in and out separately -,
+// and appends its binding to convIn, and the sending of its chunks (in outType outputs) to convOut.
+//
+// The lobIdx-th reader of the lobs LobReceiver (see lobReceiver) is bound for the input.
+func (arg Argument) getConvStreamedLob(
+	convIn, convOut []string,
+	funName, outType string, lobIdx int,
+	addParam func(string) string,
+) (decls, pre, post, _, _ []string, callArg string) {
+	isClob := arg.Type != "BLOB"
+	inParam, outParam := arg.Name, arg.Name
+	if arg.IsInput() && arg.IsOutput() {
+		callArg = getInnerVarName(funName, arg.Name)
+		outParam = getParamName(funName, callArg+".out")
+		decls = append(decls, callArg+" "+arg.AbsType+"; --S="+arg.Name)
+		pre = append(pre, callArg+" := :"+inParam+";")
+		post = append(post, ":"+outParam+" := "+callArg+";")
+	}
+	if arg.IsInput() {
+		convIn = append(convIn, fmt.Sprintf("%s = godror.Lob{IsClob:%t, Reader:lobs.Reader(%d)} // streamed",
+			addParam(inParam), isClob, lobIdx))
+	}
+	if arg.IsOutput() {
+		varName := mkVarName(outParam)
+		chunk := "b"
+		if isClob {
+			chunk = "string(b)"
+		}
+		convIn = append(convIn, fmt.Sprintf("%s := godror.Lob{IsClob:%t}; %s = sql.Out{Dest:&%s} // streamed",
+			varName, isClob, addParam(outParam), varName))
+		convOut = append(convOut, fmt.Sprintf(`if err = genocall.SendLobChunks(&%s, func(b []byte) error {
+			return stream.Send(&%s{%s: %s})
+		}); err != nil {
+			return
+		}`, varName, outType, CamelCase(arg.Name), chunk))
+	}
+	return decls, pre, post, convIn, convOut, callArg
+}
+
+// LobReceiver reads the streamed LOB inputs of a generated function:
+// the chunks of each LOB are concatenated, across the messages of the stream.
+type LobReceiver struct {
+	recv func() ([][]byte, error)
+	bufs []bytes.Buffer
+	err  error
+}
+
+// NewLobReceiver returns a LobReceiver of the chunks of the first message,
+// then of the chunks returned by recv, till it returns an error (io.EOF at the end of the stream).
+func NewLobReceiver(recv func() ([][]byte, error), first ...[]byte) *LobReceiver {
+	lr := &LobReceiver{recv: recv, bufs: make([]bytes.Buffer, len(first))}
+	lr.add(first)
+	return lr
+}
+
+func (lr *LobReceiver) add(chunks [][]byte) {
+	for i, b := range chunks {
+		if i < len(lr.bufs) {
+			lr.bufs[i].Write(b)
+		}
+	}
+}
+
+// Reader returns the reader of the i-th LOB.
+//
+// The chunks of the other LOBs received while reading it are buffered,
+// so the LOBs should be sent one after the other.
+func (lr *LobReceiver) Reader(i int) io.Reader { return lobReader{lr: lr, i: i} }
+
+type lobReader struct {
+	lr *LobReceiver
+	i  int
+}
+
+func (r lobReader) Read(p []byte) (int, error) {
+	lr, buf := r.lr, &r.lr.bufs[r.i]
+	for buf.Len() == 0 && lr.err == nil {
+		chunks, err := lr.recv()
+		if err != nil {
+			lr.err = err
+			break
+		}
+		lr.add(chunks)
+	}
+	if buf.Len() != 0 {
+		return buf.Read(p)
+	}
+	return 0, lr.err
+}
+
+// SendLobChunks reads the LOB, and sends it in chunks of at most LobChunkSize bytes.
+// The chunks of a CLOB are not split inside a UTF-8 sequence.
+//
+// The chunk is valid during the call of send only.
+func SendLobChunks(lob *godror.Lob, send func(chunk []byte) error) error {
+	if lob == nil || lob.Reader == nil {
+		return nil
+	}
+	size := LobChunkSize
+	if size < utf8.UTFMax {
+		size = utf8.UTFMax
+	}
+	buf := make([]byte, size)
+	var n int
+	for {
+		k, err := io.ReadFull(lob.Reader, buf[n:])
+		n += k
+		eof := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !eof {
+			return err
+		}
+		end := n
+		if lob.IsClob && !eof {
+			end = lastRuneEnd(buf[:n])
+		}
+		if end != 0 {
+			if err := send(buf[:end]); err != nil {
+				return err
+			}
+		}
+		if eof {
+			return nil
+		}
+		n = copy(buf, buf[end:n])
+	}
+}
+
+// lastRuneEnd returns the length of p without its last, incomplete UTF-8 sequence.
+func lastRuneEnd(p []byte) int {
+	i := len(p) - 1
+	for i > 0 && i > len(p)-utf8.UTFMax && !utf8.RuneStart(p[i]) {
+		i--
+	}
+	if i <= 0 || utf8.FullRune(p[i:]) {
+		return len(p)
+	}
+	return i
+}
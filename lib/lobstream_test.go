@@ -0,0 +1,149 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/godror/godror"
+)
+
+// lobStreamFunctions returns TST_LOBS.PUT(P_DOC IN CLOB, P_IMG IN/OUT BLOB) RETURN NCLOB,
+// TST_LOBS.GET(P_ID IN NUMBER, P_DATA OUT BLOB) and TST_LOBS.SEND(P_DOC IN CLOB).
+func lobStreamFunctions(t *testing.T) []Function {
+	return fixtureFunctions(t, fixtureSnapshot("TST_LOBS", []fixtureArg{
+		{"PUT", "", "OUT", "NCLOB", "NCLOB"},
+		{"PUT", "P_DOC", "IN", "CLOB", "CLOB"},
+		{"PUT", "P_IMG", "IN/OUT", "BLOB", "BLOB"},
+		{"GET", "P_ID", "IN", "NUMBER", "NUMBER"},
+		{"GET", "P_DATA", "OUT", "BLOB", "BLOB"},
+		{"SEND", "P_DOC", "IN", "CLOB", "CLOB"},
+	}))
+}
+
+func TestLobStreaming(t *testing.T) {
+	defer func(old bool) { LobStreaming = old }(LobStreaming)
+	LobStreaming = true
+	functions := lobStreamFunctions(t)
+	if len(functions) != 3 {
+		t.Fatalf("got %d functions, wanted 3", len(functions))
+	}
+	var buf strings.Builder
+	if err := SaveProtobufService(&buf, functions, "lobs", "TstLobs", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"rpc Put (stream Put_Input) returns (stream Put_Output) {}",
+		"rpc Get (Get_Input) returns (stream Get_Output) {}",
+		"rpc Send (stream Send_Input) returns (Send_Output) {}",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q is not in the proto:\n%s", want, buf.String())
+		}
+	}
+
+	for _, fun := range functions {
+		plsBlock, callFun := fun.PlsqlBlock("")
+		var want []string
+		switch fun.Name {
+		case "put":
+			want = []string{
+				"func (s *genocallServer) Put(stream pb.TstLobs_PutServer) (err error)",
+				"lobs := genocall.NewLobReceiver(",
+				"godror.Lob{IsClob: true, Reader: lobs.Reader(0)}",
+				"godror.Lob{IsClob: false, Reader: lobs.Reader(1)}",
+				"stream.Send(&pb.Put_Output{PImg: b})",
+				"stream.Send(&pb.Put_Output{Ret: string(b)})",
+			}
+			if !strings.Contains(plsBlock, "p_img=>v") {
+				t.Errorf("the IN OUT BLOB is not copied through a variable:\n%s", plsBlock)
+			}
+		case "get":
+			want = []string{
+				"func (s *genocallServer) Get(input *pb.Get_Input, stream pb.TstLobs_GetServer) (err error)",
+				"stream.Send(&pb.Get_Output{PData: b})",
+			}
+		case "send":
+			want = []string{
+				"func (s *genocallServer) Send(stream pb.TstLobs_SendServer) (err error)",
+				"err = stream.SendAndClose(output)",
+			}
+		}
+		for _, w := range want {
+			if !strings.Contains(callFun, w) {
+				t.Errorf("%s: %q is not in the call:\n%s", fun.Name, w, callFun)
+			}
+		}
+	}
+}
+
+// TestLobStreamingCompile type checks the generated server of the functions of just streamed LOBs
+// (no cursors) against their generated messages.
+func TestLobStreamingCompile(t *testing.T) {
+	defer func(old bool) { LobStreaming = old }(LobStreaming)
+	LobStreaming = true
+	compileGenerated(t, lobStreamFunctions)
+}
+
+func TestLobReceiver(t *testing.T) {
+	msgs := [][][]byte{
+		{[]byte("b"), []byte("2")},
+		{nil, []byte("3")},
+		{[]byte("c"), nil},
+	}
+	lr := NewLobReceiver(func() ([][]byte, error) {
+		if len(msgs) == 0 {
+			return nil, io.EOF
+		}
+		chunks := msgs[0]
+		msgs = msgs[1:]
+		return chunks, nil
+	}, []byte("a"), []byte("1"))
+	for i, want := range []string{"abc", "123"} {
+		if b, err := io.ReadAll(lr.Reader(i)); err != nil {
+			t.Fatalf("%d: %+v", i, err)
+		} else if string(b) != want {
+			t.Errorf("%d: got %q, wanted %q", i, b, want)
+		}
+	}
+}
+
+func TestSendLobChunks(t *testing.T) {
+	defer func(old int) { LobChunkSize = old }(LobChunkSize)
+	LobChunkSize = 5
+	const text = "árvíztűrő tükörfúrógép"
+	var chunks []string
+	if err := SendLobChunks(&godror.Lob{IsClob: true, Reader: strings.NewReader(text)}, func(b []byte) error {
+		if len(b) > LobChunkSize {
+			t.Errorf("%q is longer than %d", b, LobChunkSize)
+		}
+		if !utf8.Valid(b) {
+			t.Errorf("%q is not valid UTF-8", b)
+		}
+		chunks = append(chunks, string(b))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(chunks, ""); got != text {
+		t.Errorf("got %q, wanted %q", got, text)
+	}
+
+	data := bytes.Repeat([]byte{0xff}, 12)
+	var n int
+	if err := SendLobChunks(&godror.Lob{Reader: bytes.NewReader(data)}, func(b []byte) error {
+		n++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d chunks, wanted 3", n)
+	}
+}
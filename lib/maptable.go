@@ -0,0 +1,221 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+)
+
+// The PL/SQL associative arrays indexed by VARCHAR2 are maps (map<string, T> in the messages):
+// they cannot be bound, so the PL/SQL block copies them from (and to) parallel arrays
+// of the keys and of the values - or of each field of the record values.
+// The OUT maps have at most tableSize elements: a cursor over TABLE() can read neither them,
+// nor the arrays (of types declared in the block).
+
+// mapKeyType is the type of the array of the keys of the maps.
+const mapKeyType = "VARCHAR2(32767)"
+
+// isMap reports whether the argument is a PL/SQL associative array indexed by VARCHAR2.
+func (arg Argument) isMap() bool {
+	if arg.Flavor != FLAVOR_TABLE || arg.Type == "REF CURSOR" {
+		return false
+	}
+	switch strings.ToUpper(arg.IndexBy) {
+	case "VARCHAR2", "VARCHAR", "STRING", "LONG":
+		return true
+	}
+	return false
+}
+
+// checkMap returns an error if the values of the map cannot be copied through parallel arrays:
+// just the simple values, and the records of simple fields can be.
+func (arg Argument) checkMap() error {
+	elem := arg.TableOf
+	if elem == nil {
+		return errors.Errorf("no table of data for %v: %w", arg, ErrMissingTableOf)
+	}
+	switch elem.Flavor {
+	case FLAVOR_SIMPLE:
+		return nil
+	case FLAVOR_RECORD:
+		for _, f := range elem.RecordOf {
+			if f.Flavor != FLAVOR_SIMPLE {
+				return errors.Errorf("%s: field %s of the map values is not simple", arg.Name, f.Name)
+			}
+		}
+		return nil
+	}
+	return errors.Errorf("%s: the map values are tables", arg.Name)
+}
+
+// mapValueOra returns the Go type of the array the simple values are bound in,
+// and the conversion of the field x of the message to it.
+func (arg Argument) mapValueOra(x string) (typ, value string, err error) {
+	got, err := arg.goType(true)
+	if err != nil {
+		return "", "", err
+	}
+	switch got = strings.TrimPrefix(got, "*"); got {
+	case "bool":
+		// bound as a number, see NativeBoolean
+		if !NativeBoolean {
+			return "int32", "custom.AsInt32(" + x + ")", nil
+		}
+		return got, x, nil
+	case "string", "[]byte", "int32", "int64", "float64":
+		return got, x, nil
+	case "godror.Number":
+		return got, "godror.Number(" + x + ")", nil
+	case "time.Time":
+		return got, "custom.AsTime(" + x + ")", nil
+	case "time.Duration":
+		return got, "custom.AsDuration(" + x + ")", nil
+	}
+	return "", "", errors.Errorf("%v: %w", arg, UnknownSimpleType)
+}
+
+// mapValueGo returns the Go type of the values of the map field of the message.
+func (arg Argument) mapValueGo() (string, error) {
+	elem := arg.TableOf
+	got, err := elem.goType(true)
+	if err != nil {
+		return "", err
+	}
+	if elem.Flavor == FLAVOR_RECORD {
+		return withPb(CamelCase(got)), nil
+	}
+	switch got = strings.TrimPrefix(got, "*"); got {
+	case "godror.Number":
+		return "string", nil
+	case "time.Time":
+		if ProtoAPIv2 {
+			return "*timestamppb.Timestamp", nil
+		}
+		if !SQLOnly {
+			return "*custom.DateTime", nil
+		}
+	case "time.Duration":
+		if ProtoAPIv2 {
+			return "*durationpb.Duration", nil
+		}
+	}
+	return got, nil
+}
+
+// mapArrays returns the names of the Go variables of the arrays of the map:
+// of the keys, and of the values - or of each field of the record values.
+func (arg Argument) mapArrays(name string) (keys string, values []string) {
+	keys = "x__" + name + "__keys"
+	if arg.TableOf.Flavor == FLAVOR_SIMPLE {
+		return keys, []string{"x__" + name + "__values"}
+	}
+	values = make([]string, len(arg.TableOf.RecordOf))
+	for i, f := range arg.TableOf.RecordOf {
+		values[i] = "x__" + name + "__" + CamelCase(f.Name)
+	}
+	return keys, values
+}
+
+// getConvMap appends the conversions of the map to (and from) the parallel arrays,
+// bound as the keysParam and valueParams (one for each field of the record values).
+func (arg Argument) getConvMap(
+	convIn, convOut []string,
+	name, keysParam string, valueParams []string,
+	tableSize int,
+) ([]string, []string, error) {
+	elem := *arg.TableOf
+	fields := []Argument{elem}
+	if elem.Flavor == FLAVOR_RECORD {
+		fields = fields[:0]
+		for _, f := range elem.RecordOf {
+			fields = append(fields, *f.Argument)
+		}
+	}
+	valueGo, err := arg.mapValueGo()
+	if err != nil {
+		return convIn, convOut, err
+	}
+	keys, values := arg.mapArrays(name)
+	types := make([]string, len(fields))
+	ins := make([]string, len(fields))
+	for i, f := range fields {
+		x := "v"
+		if elem.Flavor == FLAVOR_RECORD {
+			x = "v." + CamelCase(f.Name)
+		}
+		if types[i], ins[i], err = f.mapValueOra(x); err != nil {
+			return convIn, convOut, err
+		}
+	}
+
+	buf := Buffers.Get()
+	defer Buffers.Put(buf)
+	size := fmt.Sprintf("%d", tableSize)
+	if arg.IsInput() {
+		size = fmt.Sprintf("len(input.%s)", name)
+		if arg.IsOutput() {
+			size = fmt.Sprintf("len(input.%s)+%d", name, tableSize)
+		}
+	}
+	fmt.Fprintf(buf, "%s := make([]string, 0, %s)  // gcm1\n", keys, size)
+	for i, v := range values {
+		fmt.Fprintf(buf, "%s := make([]%s, 0, %s)\n", v, types[i], size)
+	}
+	if arg.IsInput() {
+		fmt.Fprintf(buf, "for k, v := range input.%s {\n%s = append(%s, k)\n", name, keys, keys)
+		if elem.Flavor == FLAVOR_RECORD {
+			fmt.Fprintf(buf, "if v == nil {\nv = new(%s)\n}\n", strings.TrimPrefix(valueGo, "*"))
+		}
+		for i, v := range values {
+			fmt.Fprintf(buf, "%s = append(%s, %s)\n", v, v, ins[i])
+		}
+		io.WriteString(buf, "}\n")
+	}
+	bind := func(param, v string) {
+		if arg.IsOutput() {
+			fmt.Fprintf(buf, "%s = sql.Out{Dest: &%s, In: %t} // gcm1\n", param, v, arg.IsInput())
+		} else {
+			fmt.Fprintf(buf, "%s = %s // gcm1\n", param, v)
+		}
+	}
+	bind(keysParam, keys)
+	for i, v := range values {
+		bind(valueParams[i], v)
+	}
+	convIn = append(convIn, buf.String())
+	if !arg.IsOutput() {
+		return convIn, convOut, nil
+	}
+
+	buf.Reset()
+	fmt.Fprintf(buf, "output.%s = make(map[string]%s, len(%s))  // gcm2\n", name, valueGo, keys)
+	fmt.Fprintf(buf, "for i, k := range %s {\n", keys)
+	if elem.Flavor == FLAVOR_RECORD {
+		fmt.Fprintf(buf, "x := new(%s)\n", strings.TrimPrefix(valueGo, "*"))
+	}
+	for i, f := range fields {
+		out, err := f.objValueOut(values[i] + "[i]")
+		if err != nil {
+			return convIn, convOut, err
+		}
+		fmt.Fprintf(buf, "if i < len(%s) {\n", values[i])
+		if elem.Flavor == FLAVOR_RECORD {
+			fmt.Fprintf(buf, "x.%s = %s\n", CamelCase(f.Name), out)
+		} else {
+			fmt.Fprintf(buf, "output.%s[k] = %s\n", name, out)
+		}
+		io.WriteString(buf, "}\n")
+	}
+	if elem.Flavor == FLAVOR_RECORD {
+		fmt.Fprintf(buf, "output.%s[k] = x\n", name)
+	}
+	io.WriteString(buf, "}")
+	convOut = append(convOut, buf.String())
+	return convIn, convOut, nil
+}
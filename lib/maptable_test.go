@@ -0,0 +1,89 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// mapFunctions returns TST_MAP.PUT_MAPS(P_NAMES IN/OUT NAMES_T, P_ROWS OUT ROWS_T),
+// where NAMES_T is a TABLE OF VARCHAR2(20), and ROWS_T is a TABLE OF ROW_T (NUM NUMBER(5), WHEN DATE),
+// both INDEX BY VARCHAR2(30).
+func mapFunctions(t *testing.T) []Function {
+	vcT := &PlsType{TypeName: TypeName{Name: "VARCHAR2"}, Length: sql.NullInt64{Int64: 20, Valid: true}}
+	numT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "NUM", Prec: sql.NullInt64{Int64: 5, Valid: true}}
+	dateT := &PlsType{TypeName: TypeName{Name: "DATE"}, Attr: "WHEN"}
+	namesT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_MAP", Name: "NAMES_T"}, TypeCode: "PL/SQL INDEX TABLE", IndexBy: "VARCHAR2", CollectionOf: vcT}
+	rowT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_MAP", Name: "ROW_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{numT, dateT}}
+	rowsT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_MAP", Name: "ROWS_T"}, TypeCode: "PL/SQL INDEX TABLE", IndexBy: "VARCHAR2", CollectionOf: rowT}
+
+	return fixtureFunctions(t, fixtureSnapshot("TST_MAP", []fixtureArg{
+		{"PUT_MAPS", "P_NAMES", "IN/OUT", "PL/SQL TABLE", "OWNR.TST_MAP.NAMES_T"},
+		{"PUT_MAPS", "P_ROWS", "OUT", "PL/SQL TABLE", "OWNR.TST_MAP.ROWS_T"},
+	}, vcT, numT, dateT, namesT, rowT, rowsT))
+}
+
+func TestMapArguments(t *testing.T) {
+	functions := mapFunctions(t)
+	if len(functions) != 1 {
+		t.Fatalf("got %d functions, wanted 1", len(functions))
+	}
+	fun := functions[0]
+	for _, arg := range fun.Args {
+		if !arg.isMap() || arg.bindsAsObject() {
+			t.Errorf("%s: map=%t object=%t", arg.Name, arg.isMap(), arg.bindsAsObject())
+		}
+	}
+	if tableFunctions(t)[0].Args[0].isMap() {
+		t.Error("a PLS_INTEGER indexed table is a map")
+	}
+
+	plsql, callFun := fun.PlsqlBlock("")
+	for _, want := range []string{
+		"k1 VARCHAR2(32767);",
+		"p002#keys$ VARCHAR2_32767_tab_typ;",
+		"v001(p002#keys$(i1)) := p002#values$(i1);",
+		"k1 := v001.FIRST; i1 := 1;",
+		"p006#num(i1) := v005(k1).num;",
+		"TST_map.put_maps(p_names=>v001,\n\t\tp_rows=>v005)",
+	} {
+		if !strings.Contains(plsql, want) {
+			t.Errorf("%q is not in the block:\n%s", want, plsql)
+		}
+	}
+	for _, want := range []string{
+		"for k, v := range input.PNames {",
+		"x__PNames__values = append(x__PNames__values, v)",
+		"output.PRows = make(map[string]*pb.TstMap_RowT_Ownr, len(x__PRows__keys))",
+		"x.When = custom.AsDate(x__PRows__When[i])",
+	} {
+		if !strings.Contains(callFun, want) {
+			t.Errorf("%q is not in the call:\n%s", want, callFun)
+		}
+	}
+
+	var buf strings.Builder
+	if err := SaveProtobuf(&buf, functions, "maps"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"map<string, string> p_names = 1;", "map<string, TstMap_RowT_Ownr> p_rows = 2;"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q is not in the proto:\n%s", want, buf.String())
+		}
+	}
+	fd, err := ProtoDescriptor("maps.proto", functions, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err = writeStructs(&sb, fd); err != nil {
+		t.Fatal(err)
+	}
+	if want := "map[string]*TstMap_RowT_Ownr `json:\"p_rows,omitempty\"`"; !strings.Contains(sb.String(), want) {
+		t.Errorf("%q is not in the structs:\n%s", want, sb.String())
+	}
+}
@@ -0,0 +1,401 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+)
+
+// The SQL objects and collections (OBJECT, TABLE, VARRAY), and the PL/SQL records and tables
+// that cannot be flattened into PL/SQL tables of simple types (as prepareCall does),
+// are bound directly, as godror.Objects.
+//
+// The objects are created by the conversions on the connection of the call,
+// so a *sql.DB is pinned to one of its connections for the call.
+
+// tableItems is the field of the message holding the inner table of a table of tables,
+// as Protocol Buffers has no repeated repeated fields.
+const tableItems = "items"
+
+// itemsArg returns the record holding the element table of the table of tables.
+func (arg Argument) itemsArg() Argument {
+	items := *arg.TableOf
+	items.Name = tableItems
+	return Argument{
+		Name: arg.TableOf.Name, Type: "PL/SQL RECORD", TypeName: arg.TableOf.TypeName,
+		PlsType: arg.TableOf.PlsType, Flavor: FLAVOR_RECORD, Direction: arg.Direction,
+		RecordOf: []NamedArgument{{Name: items.Name, Argument: &items}},
+	}
+}
+
+// bindsAsObject reports whether the argument is bound as a godror.Object.
+func (arg Argument) bindsAsObject() bool {
+	switch arg.Type {
+	case "OBJECT", "TABLE", "VARRAY":
+		return true
+	case "PL/SQL RECORD":
+		for _, f := range arg.RecordOf {
+			if f.bindsAsObject() || f.Flavor == FLAVOR_TABLE && f.TableOf != nil && f.TableOf.Flavor != FLAVOR_SIMPLE {
+				return true
+			}
+		}
+	case "PL/SQL TABLE":
+		elem := arg.TableOf
+		if elem == nil || elem.Flavor == FLAVOR_SIMPLE || arg.isMap() {
+			return false
+		}
+		if elem.Flavor == FLAVOR_TABLE || elem.bindsAsObject() {
+			return true
+		}
+		for _, f := range elem.RecordOf {
+			if f.Flavor != FLAVOR_SIMPLE {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasObjects reports whether any argument of the function is bound as a godror.Object.
+func (fun Function) hasObjects() bool {
+	if fun.Returns != nil && fun.Returns.bindsAsObject() {
+		return true
+	}
+	for _, arg := range fun.Args {
+		if arg.bindsAsObject() {
+			return true
+		}
+	}
+	return false
+}
+
+// getConvObject appends the conversions of the argument bound as a godror.Object:
+// the object is built from the input, and read into the output.
+func (arg Argument) getConvObject(
+	convIn, convOut []string,
+	name, paramName string,
+) ([]string, []string, error) {
+	if arg.TypeName == "" {
+		return convIn, convOut, errors.Errorf("%v: no type name for the object", arg)
+	}
+	objName := "obj" + name
+	buf := Buffers.Get()
+	defer Buffers.Put(buf)
+
+	fmt.Fprintf(buf, `var %s *godror.Object  // gco1
+	if %s, err = func() (obj0 *godror.Object, err error) {
+		ot0, err := godror.GetObjectType(ctx, conn, %q)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				obj0.Close()
+				obj0 = nil
+			}
+		}()
+	`, objName, objName, arg.TypeName)
+	arg.newObject(buf, 0)
+	if arg.IsInput() {
+		if arg.Flavor == FLAVOR_TABLE {
+			fmt.Fprintf(buf, "x0 := input.%s\n", name)
+			if err := arg.objIn(buf, 0); err != nil {
+				return convIn, convOut, err
+			}
+		} else {
+			fmt.Fprintf(buf, "if x0 := input.%s; x0 != nil {\n", name)
+			if err := arg.objIn(buf, 0); err != nil {
+				return convIn, convOut, err
+			}
+			io.WriteString(buf, "}\n")
+		}
+	}
+	fmt.Fprintf(buf, `return obj0, nil
+	}(); err != nil {
+		err = errors.Errorf("%%s: %%w", %q, err)
+		return
+	}
+	defer %s.Close()
+	`, arg.TypeName, objName)
+	if arg.IsOutput() {
+		fmt.Fprintf(buf, "%s = sql.Out{Dest: %s, In: %t} // gco1", paramName, objName, arg.IsInput())
+	} else {
+		fmt.Fprintf(buf, "%s = %s // gco1", paramName, objName)
+	}
+	convIn = append(convIn, buf.String())
+	if !arg.IsOutput() {
+		return convIn, convOut, nil
+	}
+
+	buf.Reset()
+	fmt.Fprintf(buf, `if err = func() (err error) {  // gco2
+		var v interface{}
+		_ = v
+		if obj0 := %s; obj0 != nil {
+	`, objName)
+	if arg.Flavor == FLAVOR_TABLE {
+		if err := arg.objOut(buf, 0, "output."+name); err != nil {
+			return convIn, convOut, err
+		}
+	} else {
+		msg, err := arg.objMessage()
+		if err != nil {
+			return convIn, convOut, err
+		}
+		fmt.Fprintf(buf, "x0 := new(%s)\n", msg)
+		if err = arg.objOut(buf, 0, "x0"); err != nil {
+			return convIn, convOut, err
+		}
+		fmt.Fprintf(buf, "output.%s = x0\n", name)
+	}
+	fmt.Fprintf(buf, `}
+		return nil
+	}(); err != nil {
+		err = errors.Errorf("%%s: %%w", %q, err)
+		return
+	}`, arg.TypeName)
+	convOut = append(convOut, buf.String())
+	return convIn, convOut, nil
+}
+
+// newObject writes the creation of obj<d>, the object (or collection) of type ot<d>.
+func (arg Argument) newObject(w io.Writer, d int) {
+	if d != 0 {
+		fmt.Fprintf(w, "var obj%d *godror.Object\n", d)
+	}
+	if arg.Flavor == FLAVOR_TABLE {
+		fmt.Fprintf(w, `var c%d godror.ObjectCollection
+		if c%d, err = ot%d.NewCollection(); err != nil {
+			return
+		}
+		obj%d = c%d.Object
+		`, d, d, d, d, d)
+	} else {
+		fmt.Fprintf(w, "if obj%d, err = ot%d.NewObject(); err != nil {\nreturn\n}\n", d, d)
+	}
+	if d != 0 {
+		fmt.Fprintf(w, "defer obj%d.Close()\n", d)
+	}
+}
+
+// objIn writes the filling of obj<d> (of type ot<d>) from x<d>:
+// the (non-nil) message of the record, or the slice of the table.
+func (arg Argument) objIn(w io.Writer, d int) error {
+	if arg.Flavor == FLAVOR_TABLE {
+		elem := *arg.TableOf
+		switch elem.Flavor {
+		case FLAVOR_SIMPLE:
+			value, _, err := elem.objValueIn(fmt.Sprintf("x%d", d+1))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, `for _, x%d := range x%d {
+				if err = obj%d.Collection().Append(%s); err != nil {
+					return
+				}
+			}
+			`, d+1, d, d, value)
+			return nil
+		case FLAVOR_RECORD:
+			fmt.Fprintf(w, "for _, x%d := range x%d {\not%d := ot%d.CollectionOf\n", d+1, d, d+1, d)
+			elem.newObject(w, d+1)
+			fmt.Fprintf(w, "if x%d != nil {\n", d+1)
+		default:
+			fmt.Fprintf(w, "for _, w%d := range x%d {\not%d := ot%d.CollectionOf\n", d+1, d, d+1, d)
+			elem.newObject(w, d+1)
+			fmt.Fprintf(w, "if w%d != nil {\nx%d := w%d.%s\n", d+1, d+1, d+1, CamelCase(tableItems))
+		}
+		if err := elem.objIn(w, d+1); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, `}
+			if err = obj%d.Collection().AppendObject(obj%d); err != nil {
+				return
+			}
+		}
+		`, d, d+1)
+		return nil
+	}
+
+	for _, f := range arg.RecordOf {
+		attr, src := strings.ToUpper(f.Name), fmt.Sprintf("x%d.%s", d, CamelCase(f.Name))
+		switch f.Flavor {
+		case FLAVOR_SIMPLE:
+			value, notNull, err := f.objValueIn(src)
+			if err != nil {
+				return err
+			}
+			if notNull == "" {
+				fmt.Fprintf(w, "if err = obj%d.Set(%q, %s); err != nil {\nreturn\n}\n", d, attr, value)
+			} else {
+				fmt.Fprintf(w, "if v := %s; %s {\nif err = obj%d.Set(%q, v); err != nil {\nreturn\n}\n}\n",
+					value, notNull, d, attr)
+			}
+			continue
+		case FLAVOR_RECORD:
+			fmt.Fprintf(w, "if x%d := %s; x%d != nil {\n", d+1, src, d+1)
+		default:
+			fmt.Fprintf(w, "{\nx%d := %s\n", d+1, src)
+		}
+		fmt.Fprintf(w, "ot%d := ot%d.Attributes[%q].ObjectType\n", d+1, d, attr)
+		f.newObject(w, d+1)
+		if err := f.objIn(w, d+1); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "if err = obj%d.Set(%q, obj%d); err != nil {\nreturn\n}\n}\n", d, attr, d+1)
+	}
+	return nil
+}
+
+// objOut writes the reading of obj<d> into dst: the (non-nil) message of the record,
+// or the slice of the table.
+func (arg Argument) objOut(w io.Writer, d int, dst string) error {
+	if arg.Flavor == FLAVOR_TABLE {
+		fmt.Fprintf(w, `if coll%d := obj%d.Collection(); coll%d.Object != nil {
+			%s = %s[:0]
+			for i%d, iErr := coll%d.First(); iErr == nil; i%d, iErr = coll%d.Next(i%d) {
+				if v, err = coll%d.Get(i%d); err != nil {
+					return
+				}
+			`, d, d, d,
+			dst, dst,
+			d, d, d, d, d,
+			d, d)
+		elem := *arg.TableOf
+		if elem.Flavor == FLAVOR_SIMPLE {
+			value, err := elem.objValueOut("v")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s = append(%s, %s)\n", dst, dst, value)
+		} else {
+			msg, err := arg.objMessage()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "x%d := new(%s)\nif obj%d, _ := v.(*godror.Object); obj%d != nil {\n", d+1, msg, d+1, d+1)
+			if elem.Flavor == FLAVOR_TABLE {
+				err = elem.objOut(w, d+1, fmt.Sprintf("x%d.%s", d+1, CamelCase(tableItems)))
+			} else {
+				err = elem.objOut(w, d+1, fmt.Sprintf("x%d", d+1))
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "}\n%s = append(%s, x%d)\n", dst, dst, d+1)
+		}
+		io.WriteString(w, "}\n}\n")
+		return nil
+	}
+
+	for _, f := range arg.RecordOf {
+		attr, fDst := strings.ToUpper(f.Name), dst+"."+CamelCase(f.Name)
+		fmt.Fprintf(w, "if v, err = obj%d.Get(%q); err != nil {\nreturn\n}\n", d, attr)
+		switch f.Flavor {
+		case FLAVOR_SIMPLE:
+			value, err := f.objValueOut("v")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s = %s\n", fDst, value)
+		case FLAVOR_RECORD:
+			msg, err := f.objMessage()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, `if obj%d, _ := v.(*godror.Object); obj%d != nil {
+				defer obj%d.Close()
+				x%d := new(%s)
+				`, d+1, d+1, d+1, d+1, msg)
+			if err = f.objOut(w, d+1, fmt.Sprintf("x%d", d+1)); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s = x%d\n}\n", fDst, d+1)
+		default:
+			fmt.Fprintf(w, `if c%d, _ := v.(*godror.ObjectCollection); c%d != nil && c%d.Object != nil {
+				defer c%d.Close()
+				obj%d := c%d.Object
+				`, d+1, d+1, d+1, d+1, d+1, d+1)
+			if err := f.objOut(w, d+1, fDst); err != nil {
+				return err
+			}
+			io.WriteString(w, "}\n")
+		}
+	}
+	return nil
+}
+
+// objMessage returns the message of the record, or of the element of the table.
+func (arg Argument) objMessage() (string, error) {
+	got, err := arg.goType(true)
+	if err != nil {
+		return "", err
+	}
+	return withPb(CamelCase(strings.TrimLeft(got, "[]*"))), nil
+}
+
+// objValueIn returns the value of the simple argument to set as an attribute (element)
+// of an object, from the field x of the message - and the condition of v (the value)
+// not being NULL, if it can be NULL.
+func (arg Argument) objValueIn(x string) (value, notNull string, err error) {
+	got, err := arg.goType(true)
+	if err != nil {
+		return "", "", err
+	}
+	switch strings.TrimPrefix(got, "*") {
+	case "string", "godror.Number":
+		return x, `v != ""`, nil
+	case "[]byte":
+		return x, "len(v) != 0", nil
+	case "time.Time":
+		return "custom.AsTime(" + x + ")", "!v.IsZero()", nil
+	case "time.Duration":
+		return "custom.AsDuration(" + x + ")", "", nil
+	case "int32", "int64", "float64", "bool":
+		return x, "", nil
+	}
+	return "", "", errors.Errorf("%v: %w", arg, UnknownSimpleType)
+}
+
+// objValueOut returns the conversion of the value v of an attribute (element) of an object,
+// to the field of the message of the simple argument.
+func (arg Argument) objValueOut(v string) (string, error) {
+	got, err := arg.goType(true)
+	if err != nil {
+		return "", err
+	}
+	switch strings.TrimPrefix(got, "*") {
+	case "string", "godror.Number":
+		return "custom.AsString(" + v + ")", nil
+	case "[]byte":
+		return "custom.AsBytes(" + v + ")", nil
+	case "time.Time":
+		if ProtoAPIv2 {
+			return "custom.AsTimestamp(" + v + ")", nil
+		}
+		if SQLOnly {
+			return "custom.AsTime(" + v + ")", nil
+		}
+		return "custom.AsDate(" + v + ")", nil
+	case "time.Duration":
+		if ProtoAPIv2 {
+			return "custom.AsDurationProto(" + v + ")", nil
+		}
+		return "custom.AsDuration(" + v + ")", nil
+	case "int32":
+		return "custom.AsInt32(" + v + ")", nil
+	case "int64":
+		return "custom.AsInt64(" + v + ")", nil
+	case "float64":
+		return "custom.AsFloat64(" + v + ")", nil
+	case "bool":
+		return "custom.AsBool(" + v + ")", nil
+	}
+	return "", errors.Errorf("%v: %w", arg, UnknownSimpleType)
+}
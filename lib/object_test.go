@@ -0,0 +1,102 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// objectFunctions returns TST_OBJ.PUT_SHAPES, with an SQL OBJECT (with a nested table of
+// VARCHAR2s), a PL/SQL TABLE of records holding a nested table of that OBJECT,
+// and a VARRAY of nested tables - read without their deeper levels, as ReadSnapshot reads them.
+func objectFunctions(t *testing.T) []Function {
+	num5 := func(attr string) *PlsType {
+		return &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: attr, Prec: sql.NullInt64{Int64: 5, Valid: true}}
+	}
+	vc := func(attr string, length int64) *PlsType {
+		return &PlsType{TypeName: TypeName{Name: "VARCHAR2"}, Attr: attr, Length: sql.NullInt64{Int64: length, Valid: true}}
+	}
+	tagTab := &PlsType{TypeName: TypeName{Owner: "OWNR", Name: "TAG_TAB"}, TypeCode: "TABLE", CollectionOf: vc("", 10)}
+	tagsF := *tagTab
+	tagsF.Attr = "TAGS"
+	pointT := &PlsType{TypeName: TypeName{Owner: "OWNR", Name: "POINT_T"}, TypeCode: "OBJECT",
+		RecordOf: []*PlsType{num5("X"), num5("Y"), &tagsF}}
+	pointTab := &PlsType{TypeName: TypeName{Owner: "OWNR", Name: "POINT_TAB"}, TypeCode: "TABLE", CollectionOf: pointT}
+	pointsF := *pointTab
+	pointsF.Attr = "POINTS"
+	shapeT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_OBJ", Name: "SHAPE_T"}, TypeCode: "PL/SQL RECORD",
+		RecordOf: []*PlsType{vc("NAME", 20), &pointsF}}
+	shapesT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_OBJ", Name: "SHAPES_T"}, TypeCode: "PL/SQL INDEX TABLE", CollectionOf: shapeT}
+	numTab := &PlsType{TypeName: TypeName{Owner: "OWNR", Name: "NUM_TAB"}, TypeCode: "TABLE",
+		CollectionOf: &PlsType{TypeName: TypeName{Name: "NUMBER"}}}
+	matrixT := &PlsType{TypeName: TypeName{Owner: "OWNR", Name: "MATRIX_T"}, TypeCode: "VARYING ARRAY", CollectionOf: numTab}
+
+	return fixtureFunctions(t, fixtureSnapshot("TST_OBJ", []fixtureArg{
+		{"PUT_SHAPES", "P_ORIGIN", "IN/OUT", "OBJECT", "OWNR.POINT_T"},
+		{"PUT_SHAPES", "P_SHAPES", "IN", "PL/SQL TABLE", "OWNR.TST_OBJ.SHAPES_T"},
+		{"PUT_SHAPES", "P_MATRIX", "IN/OUT", "VARRAY", "OWNR.MATRIX_T"},
+	}, tagTab, pointT, pointTab, shapeT, shapesT, numTab, matrixT))
+}
+
+func TestObjectArguments(t *testing.T) {
+	functions := objectFunctions(t)
+	if len(functions) != 1 {
+		t.Fatalf("got %d functions, wanted 1", len(functions))
+	}
+	fun := functions[0]
+	origin, shapes, matrix := fun.Args[0], fun.Args[1], fun.Args[2]
+	if origin.Flavor != FLAVOR_RECORD || origin.TypeName != "OWNR.POINT_T" || len(origin.RecordOf) != 3 {
+		t.Fatalf("p_origin: got %v (%q)", origin, origin.TypeName)
+	}
+	if tags := origin.RecordOf[2]; tags.Name != "tags" || tags.Flavor != FLAVOR_TABLE || tags.TableOf.Type != "VARCHAR2" || tags.TableOf.Charlength != 10 {
+		t.Errorf("p_origin.tags: got %v", tags)
+	}
+	points := shapes.TableOf.RecordOf[1]
+	if points.Name != "points" || points.Flavor != FLAVOR_TABLE || points.TableOf.Type != "OBJECT" || len(points.TableOf.RecordOf) != 3 {
+		t.Errorf("p_shapes.points: got %v", points)
+	}
+	if matrix.Flavor != FLAVOR_TABLE || matrix.TableOf.Flavor != FLAVOR_TABLE || matrix.TableOf.TableOf.Type != "NUMBER" {
+		t.Errorf("p_matrix: got %v", matrix)
+	}
+	for _, arg := range fun.Args {
+		if !arg.bindsAsObject() {
+			t.Errorf("%s is not bound as an object", arg.Name)
+		}
+	}
+	if tableFunctions(t)[0].hasObjects() {
+		t.Error("a PL/SQL TABLE of simple records is bound as an object")
+	}
+
+	plsql, callFun := fun.PlsqlBlock("")
+	if want := "p_origin=>:1,\n\t\tp_shapes=>:2,\n\t\tp_matrix=>:3"; !strings.Contains(plsql, want) {
+		t.Errorf("the objects are not bound directly:\n%s", plsql)
+	}
+	for _, want := range []string{
+		`godror.GetObjectType(ctx, conn, "OWNR.POINT_T")`,
+		`godror.GetObjectType(ctx, conn, "OWNR.TST_OBJ.SHAPES_T")`,
+		"sql.Out{Dest: objPOrigin, In: true}",
+		"sql.Out{Dest: objPMatrix, In: true}",
+		`ot2 := ot1.Attributes["POINTS"].ObjectType`,
+		"x1.Items = append(x1.Items, custom.AsString(v))",
+		"x1 := w1.Items",
+	} {
+		if !strings.Contains(callFun, want) {
+			t.Errorf("%q is not in the call:\n%s", want, callFun)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := SaveProtobuf(&buf, functions, "objects"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"repeated string items = 1 ", "repeated NumTab_Ownr p_matrix = 2;"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q is not in the proto:\n%s", want, buf.String())
+		}
+	}
+}
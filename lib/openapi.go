@@ -0,0 +1,367 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// SaveOpenAPI writes an OpenAPI 3 document of the functions, as called by the HTTPHandler
+// of the generated server: POST /<package>/<function> with the input message,
+// returning the output message - with the lengths, numeric bounds and descriptions
+// of the arguments.
+func SaveOpenAPI(dst io.Writer, functions []Function, title string) error {
+	var lastDDL time.Time
+	for _, f := range functions {
+		if f.LastDDL.After(lastDDL) {
+			lastDDL = f.LastDDL
+		}
+	}
+	doc := openAPIDoc{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: title, Version: lastDDL.UTC().Format(time.RFC3339)},
+		Paths:   make(map[string]openAPIPath, len(functions)),
+		Components: openAPIComponents{Schemas: map[string]*openAPISchema{
+			"Error": {Type: "object", Properties: map[string]*openAPISchema{
+				"error": {Type: "string"},
+				"violations": {Type: "array", Description: "the fields of the input out of their bounds",
+					Items: &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{
+						"field": {Type: "string"}, "description": {Type: "string"},
+					}}},
+			}},
+		}},
+	}
+	b := schemaBuilder{schemas: doc.Components.Schemas, refPrefix: "#/components/schemas/"}
+
+FunLoop:
+	for _, f := range functions {
+		var refs [2]*openAPISchema
+		for i, dirmap := range []direction{DIR_IN, DIR_OUT} {
+			var err error
+			if refs[i], err = b.message(f, dirmap); err != nil {
+				if SkipMissingTableOf && (errors.Is(err, ErrMissingTableOf) || errors.Is(err, UnknownSimpleType)) {
+					logger.Warn("SKIP function, missing TableOf info", "function", f.FullName())
+					continue FunLoop
+				}
+				return fmt.Errorf("%s: %w", f.FullName(), err)
+			}
+		}
+		contentType := "application/json"
+		if f.streamsOutput() {
+			// one output for each batch of rows, or chunk of a LOB
+			contentType = "application/x-ndjson"
+		}
+		doc.Paths[f.httpPath()] = openAPIPath{Post: &openAPIOperation{
+			OperationID: CamelCase(f.Package) + CamelCase(dot2D.Replace(strings.ToLower(f.AliasedName()))),
+			Summary:     f.FullName(),
+			Description: strings.TrimSpace(f.Documentation),
+			Tags:        []string{strings.ToLower(f.Package)},
+			RequestBody: &openAPIRequestBody{Required: true, Content: map[string]openAPIMediaType{
+				"application/json": {Schema: refs[0]},
+			}},
+			Responses: map[string]openAPIResponse{
+				"200": {Description: "the output", Content: map[string]openAPIMediaType{
+					contentType: {Schema: refs[1]},
+				}},
+				"default": {Description: "the error", Content: map[string]openAPIMediaType{
+					"application/json": {Schema: &openAPISchema{Ref: "#/components/schemas/Error"}},
+				}},
+			},
+		}}
+	}
+
+	enc := json.NewEncoder(dst)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// schemaBuilder builds the schemas of the messages for SaveOpenAPI and JSONSchemas.
+type schemaBuilder struct {
+	// schemas are the objects, by their name
+	schemas map[string]*openAPISchema
+	// refPrefix is the prefix of the references to the schemas
+	refPrefix string
+	// jsonSchema is for JSON Schema (2020-12), instead of OpenAPI 3.0
+	jsonSchema bool
+}
+
+// message adds the schema of the input or output message of the function,
+// and returns a reference to it.
+func (b schemaBuilder) message(f Function, dirmap direction) (*openAPISchema, error) {
+	args := make([]Argument, 0, len(f.Args)+1)
+	for _, arg := range f.Args {
+		if arg.Direction&dirmap > 0 {
+			args = append(args, arg)
+		}
+	}
+	if dirmap == DIR_OUT && f.Returns != nil {
+		args = append(args, *f.Returns)
+	}
+	var tagged []Argument
+	if dirmap == DIR_OUT && f.tagsCursors() {
+		others := args[:0:0]
+		for _, arg := range args {
+			if f.isTaggedCursor(arg) {
+				tagged = append(tagged, arg)
+			} else {
+				others = append(others, arg)
+			}
+		}
+		args = others
+	}
+	D := getDirDoc(f.Documentation, dirmap)
+	ref, err := b.object(f.messageName(dirmap), D, args...)
+	if err != nil || len(tagged) == 0 {
+		return ref, err
+	}
+	return ref, b.cursorBatches(f, b.schemas[f.messageName(dirmap)], D, tagged)
+}
+
+// cursorBatches adds the batches of the tagged cursors (see TaggedCursors) to the output object
+// - as the cursor_rows oneof is marshaled: by protojson, as the field of the cursor,
+// by encoding/json, as the field of the cursor in a CursorRows object.
+func (b schemaBuilder) cursorBatches(f Function, obj *openAPISchema, D argDocs, tagged []Argument) error {
+	props := obj.Properties
+	if !ProtoAPIv2 {
+		oneof := &openAPISchema{Type: "object", Description: "the batch of one of the cursors",
+			Properties: make(map[string]*openAPISchema, len(tagged))}
+		props[CamelCase(cursorOneof)] = oneof
+		props = oneof.Properties
+	}
+	for _, arg := range tagged {
+		rows, err := b.field(arg, D.Map[arg.Name])
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", f.messageName(DIR_OUT), arg.Name, err)
+		}
+		name := f.cursorBatchName(arg)
+		b.schemas[name] = &openAPISchema{
+			Type: "object", Description: strings.TrimSpace(D.Map[arg.Name]),
+			Properties: map[string]*openAPISchema{
+				"rows": rows,
+				"last": {Type: "boolean", Description: "the last batch of the cursor"},
+			},
+		}
+		aName := CamelCase(arg.Name)
+		if ProtoAPIv2 {
+			aName = protoJSONName(arg.Name)
+		}
+		props[aName] = &openAPISchema{Ref: b.refPrefix + name}
+	}
+	return nil
+}
+
+// messageName returns the name of the input or output message of the function.
+func (f Function) messageName(dirmap direction) string {
+	dirname := "input"
+	if dirmap == DIR_OUT {
+		dirname = "output"
+	}
+	return CamelCase(dot2D.Replace(strings.ToLower(f.AliasedName())) + "__" + dirname)
+}
+
+// object adds the schema of the message (or record), and returns a reference to it.
+func (b schemaBuilder) object(name string, D argDocs, args ...Argument) (*openAPISchema, error) {
+	ref := &openAPISchema{Ref: b.refPrefix + name}
+	if _, ok := b.schemas[name]; ok {
+		return ref, nil
+	}
+	obj := &openAPISchema{
+		Type:        "object",
+		Description: strings.TrimSpace(D.Pre + D.Post),
+		Properties:  make(map[string]*openAPISchema, len(args)),
+	}
+	b.schemas[name] = obj
+	for _, arg := range args {
+		if strings.HasSuffix(arg.Name, "#") {
+			arg.Name = replHidden(arg.Name)
+		}
+		prop, err := b.field(arg, D.Map[arg.Name])
+		if err != nil {
+			delete(b.schemas, name)
+			return nil, fmt.Errorf("%s.%s: %w", name, arg.Name, err)
+		}
+		aName := arg.Name
+		if ProtoAPIv2 {
+			aName = protoJSONName(aName)
+		}
+		obj.Properties[aName] = prop
+	}
+	return ref, nil
+}
+
+// field returns the schema of the argument, adding the schemas of its records.
+func (b schemaBuilder) field(arg Argument, doc string) (*openAPISchema, error) {
+	elem := arg
+	if arg.Flavor == FLAVOR_TABLE {
+		if arg.TableOf == nil {
+			return nil, fmt.Errorf("no table of data for %v: %w", arg, ErrMissingTableOf)
+		}
+		elem = *arg.TableOf
+	}
+	var s *openAPISchema
+	if elem.Flavor == FLAVOR_SIMPLE {
+		var err error
+		if s, err = b.scalar(elem); err != nil {
+			return nil, err
+		}
+		s.Description = strings.TrimSpace(doc)
+	} else {
+		_, typ, _, err := protoField(arg)
+		if err != nil {
+			return nil, err
+		}
+		if s, err = b.object(CamelCase(typ), argDocs{Pre: doc}, protoRecordArgs(arg)...); err != nil {
+			return nil, err
+		}
+	}
+	if arg.Flavor != FLAVOR_TABLE {
+		return s, nil
+	}
+	if arg.isMap() {
+		return &openAPISchema{Type: "object", AdditionalProperties: s}, nil
+	}
+	return &openAPISchema{Type: "array", Items: s}, nil
+}
+
+// scalar returns the schema of the simple argument, as its message field is marshaled to JSON.
+func (b schemaBuilder) scalar(arg Argument) (*openAPISchema, error) {
+	switch arg.Type {
+	case "CHAR", "VARCHAR2", "NCHAR", "NVARCHAR2", "ROWID", "UROWID":
+		s := &openAPISchema{Type: "string"}
+		if arg.Charlength > 0 {
+			s.MaxLength = &arg.Charlength
+		}
+		return s, nil
+	case "CLOB", "NCLOB", "LONG":
+		return &openAPISchema{Type: "string"}, nil
+	case "RAW", "BLOB", "LONG RAW":
+		if b.jsonSchema {
+			return &openAPISchema{Type: "string", ContentEncoding: "base64"}, nil
+		}
+		return &openAPISchema{Type: "string", Format: "byte"}, nil
+	case "NUMBER":
+		// godror.Number, a string, to not lose precision
+		return &openAPISchema{Type: "string", Pattern: numberPattern(arg.Precision, arg.Scale)}, nil
+	case "INTEGER", "PLS_INTEGER", "BINARY_INTEGER":
+		if NumberAsString {
+			return &openAPISchema{Type: "string", Pattern: numberPattern(0, 0)}, nil
+		}
+		format := "int32"
+		if arg.Type == "INTEGER" {
+			format = "int64"
+		}
+		return &openAPISchema{Type: "integer", Format: format}, nil
+	case "BINARY_DOUBLE", "BINARY_FLOAT", "FLOAT":
+		if NumberAsString {
+			return &openAPISchema{Type: "string", Pattern: numberPattern(arg.Precision, arg.Scale)}, nil
+		}
+		s := &openAPISchema{Type: "number", Format: "double"}
+		if arg.Precision > 0 {
+			// the same bounds as the generated Check functions
+			max := math.Pow10(int(arg.Precision)) - 1
+			min := -max
+			if b.jsonSchema {
+				s.Maximum, s.ExclusiveMinimum = &max, min
+			} else {
+				s.Minimum, s.Maximum, s.ExclusiveMinimum = &min, &max, true
+			}
+		}
+		return s, nil
+	case "BOOLEAN", "PL/SQL BOOLEAN":
+		return &openAPISchema{Type: "boolean"}, nil
+	case "DATE", "DATETIME", "TIME", "TIMESTAMP", typeTimestampTZ, typeTimestampLTZ:
+		return &openAPISchema{Type: "string", Format: "date-time"}, nil
+	case typeIntervalDS:
+		if ProtoAPIv2 {
+			// protojson writes the google.protobuf.Duration as seconds
+			return &openAPISchema{Type: "string", Pattern: `^-?[0-9]+(\.[0-9]+)?s$`}, nil
+		}
+		// time.Duration is marshaled as nanoseconds
+		return &openAPISchema{Type: "integer", Format: "int64"}, nil
+	case typeIntervalYM:
+		return &openAPISchema{Type: "string", Pattern: `^[-+]?[0-9]+-[0-9]+$`}, nil
+	}
+	return nil, fmt.Errorf("%v: %w", arg, UnknownSimpleType)
+}
+
+// numberPattern returns the pattern of a NUMBER(precision, scale) as a string.
+func numberPattern(precision, scale uint8) string {
+	if precision == 0 {
+		return `^-?[0-9]+(\.[0-9]+)?$`
+	}
+	if scale == 0 {
+		return fmt.Sprintf(`^-?[0-9]{1,%d}$`, precision)
+	}
+	if scale >= precision {
+		return fmt.Sprintf(`^-?0?\.[0-9]{1,%d}$`, scale)
+	}
+	return fmt.Sprintf(`^-?[0-9]{1,%d}(\.[0-9]{1,%d})?$`, precision-scale, scale)
+}
+
+type openAPIDoc struct {
+	OpenAPI    string                 `json:"openapi"`
+	Info       openAPIInfo            `json:"info"`
+	Paths      map[string]openAPIPath `json:"paths"`
+	Components openAPIComponents      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIPath struct {
+	Post *openAPIOperation `json:"post,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	MaxLength            *uint                     `json:"maxLength,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	ContentEncoding      string                    `json:"contentEncoding,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	ExclusiveMinimum     interface{}               `json:"exclusiveMinimum,omitempty"` // bool in OpenAPI 3.0, number in JSON Schema
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
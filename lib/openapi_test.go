@@ -0,0 +1,93 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSaveOpenAPI(t *testing.T) {
+	functions, annotations, err := testSnapshot().Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions = ApplyAnnotations(functions, annotations)
+	functions = append(functions, nestedRecordFunctions(t)...)
+	functions = append(functions, cursorFunctions(t)...)
+	var buf strings.Builder
+	if err := SaveOpenAPI(&buf, functions, "snap"); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(buf.String())
+
+	type schema struct {
+		Ref         string             `json:"$ref"`
+		Type        string             `json:"type"`
+		Format      string             `json:"format"`
+		Description string             `json:"description"`
+		MaxLength   int                `json:"maxLength"`
+		Pattern     string             `json:"pattern"`
+		Items       *schema            `json:"items"`
+		Properties  map[string]*schema `json:"properties"`
+	}
+	type media map[string]struct{ Schema schema }
+	var doc struct {
+		OpenAPI string
+		Paths   map[string]struct {
+			Post struct {
+				OperationID string
+				RequestBody struct{ Content media }
+				Responses   map[string]struct{ Content media }
+			}
+		}
+		Components struct{ Schemas map[string]*schema }
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" || len(doc.Paths) != len(functions) {
+		t.Fatalf("got %s with %d paths, wanted 3.0.3 with %d", doc.OpenAPI, len(doc.Paths), len(functions))
+	}
+
+	recIn := doc.Paths["/tst_snap/rec_in"].Post
+	if recIn.OperationID != "TstSnapRecIn" {
+		t.Errorf("operationId: got %q", recIn.OperationID)
+	}
+	if got := recIn.RequestBody.Content["application/json"].Schema.Ref; got != "#/components/schemas/RecIn_Input" {
+		t.Errorf("request body: got %q", got)
+	}
+	input := doc.Components.Schemas["RecIn_Input"]
+	if input == nil || input.Properties["p_rec"] == nil || input.Properties["p_rec"].Ref != "#/components/schemas/TstSnap_RecT_Ownr" {
+		t.Fatalf("RecIn_Input: got %+v", input)
+	}
+	rec := doc.Components.Schemas["TstSnap_RecT_Ownr"]
+	if rec == nil || rec.Description != "the record" {
+		t.Fatalf("TstSnap_RecT_Ownr: got %+v", rec)
+	}
+	if num := rec.Properties["num"]; num == nil || num.Type != "string" || num.Pattern == "" {
+		t.Errorf("num: got %+v, wanted a string of digits", num)
+	}
+	listIn := doc.Components.Schemas["ListRows_Input"]
+	if amount := listIn.Properties["p_amount"]; amount == nil || amount.Type != "string" || amount.Pattern != `^-?[0-9]{1,7}(\.[0-9]{1,2})?$` {
+		t.Errorf("p_amount: got %+v, wanted a NUMBER(9,2) string", amount)
+	}
+	if name := listIn.Properties["p_name"]; name == nil || name.Type != "string" || name.MaxLength != 20 {
+		t.Errorf("p_name: got %+v, wanted a string with maxLength 20", name)
+	}
+	if when := doc.Components.Schemas["TstTypes_OwnT_Ownr"].Properties["when"]; when == nil || when.Format != "date-time" {
+		t.Errorf("when: got %+v, wanted a date-time", when)
+	}
+
+	list := doc.Paths["/tst_cur/list_rows"].Post
+	if got := list.Responses["200"].Content["application/x-ndjson"].Schema.Ref; got != "#/components/schemas/ListRows_Output" {
+		t.Errorf("REF CURSOR output: got %q, wanted NDJSON of ListRows_Output", got)
+	}
+	if ret := doc.Components.Schemas["ListRows_Output"].Properties["ret"]; ret == nil || ret.Type != "array" || ret.Items == nil || ret.Items.Ref == "" {
+		t.Errorf("ret: got %+v, wanted an array of records", ret)
+	}
+}
@@ -20,11 +20,11 @@ import (
 	errors "golang.org/x/xerrors"
 )
 
-// MaxTableSize is the maximum size of the array elements
+// MaxTableSize is the maximum size of the output maps (VARCHAR2-indexed associative arrays):
+// these are copied through arrays, as the TABLE operator cannot read them.
+// The other output tables (in records, too) are read through cursors, so their size is not limited.
 var MaxTableSize = 128
 
-const batchSize = 128
-
 // SavePlsqlBlock saves the plsql block definition into writer
 func (fun Function) PlsqlBlock(checkName string) (plsql, callFun string) {
 	decls, pre, call, post, convIn, convOut, err := fun.prepareCall()
@@ -79,31 +79,86 @@ func (fun Function) PlsqlBlock(checkName string) (plsql, callFun string) {
 	defer Buffers.Put(callBuf)
 	callBuf.Reset()
 
-	hasCursorOut := fun.HasCursorOut()
-	if hasCursorOut {
-		fmt.Fprintf(callBuf, `func (s *genocallServer) %s(input *pb.%s, stream pb.%s_%sServer) (err error) {
+	streamIn, streamOut := fun.streamsInput(), fun.streamsOutput()
+	if streamIn {
+		// the LOBs are read from the stream by the Lob readers, while executing the statement
+		fmt.Fprintf(callBuf, `func (s *genocallServer) %s(stream %s_%sServer) (err error) {
 			ctx := stream.Context()
+			var input *%s
+			if input, err = stream.Recv(); err != nil {
+				return
+			}
+			%s
 			%s
-			output := new(pb.%s)
+			output := new(%s)
 			iterators := make([]iterator, 0, 1)
+			_ = iterators
 		`,
-			CamelCase(fn), CamelCase(fun.getStructName(false, false)), CamelCase(fun.Package), CamelCase(fn),
+			CamelCase(fn), withPb(CamelCase(fun.Package)), CamelCase(fn),
+			withPb(CamelCase(fun.getStructName(false, false))),
 			check,
-			CamelCase(fun.getStructName(true, false)),
+			fun.lobReceiver(),
+			withPb(CamelCase(fun.getStructName(true, false))),
 		)
+	} else if streamOut {
+		fmt.Fprintf(callBuf, `func (s *genocallServer) %s(input *%s, stream %s_%sServer) (err error) {
+			ctx := stream.Context()
+			%s
+			output := new(%s)
+			iterators := make([]iterator, 0, 1)
+		`,
+			CamelCase(fn), withPb(CamelCase(fun.getStructName(false, false))), withPb(CamelCase(fun.Package)), CamelCase(fn),
+			check,
+			withPb(CamelCase(fun.getStructName(true, false))),
+		)
+		if fun.HasCursorOut() {
+			callBuf.WriteString(fun.batchLimits())
+		}
 	} else {
-		fmt.Fprintf(callBuf, `func (s *genocallServer) %s(ctx context.Context, input *pb.%s) (output *pb.%s, err error) {
+		fmt.Fprintf(callBuf, `func (s *genocallServer) %s(ctx context.Context, input *%s) (output *%s, err error) {
 		%s
-		output = new(pb.%s)
+		output = new(%s)
 		iterators := make([]iterator, 0, 1) // just temporary
 		_ = iterators
     `,
-			CamelCase(fn), CamelCase(fun.getStructName(false, false)), CamelCase(fun.getStructName(true, false)),
+			CamelCase(fn), withPb(CamelCase(fun.getStructName(false, false))), withPb(CamelCase(fun.getStructName(true, false))),
 			check,
-			CamelCase(fun.getStructName(true, false)),
+			withPb(CamelCase(fun.getStructName(true, false))),
 		)
 	}
 	fmt.Fprintf(callBuf, "\nif err = ctx.Err(); err != nil { return }\n")
+	callBuf.WriteString(`
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	conn := s.conn
+`)
+	if fun.stateful {
+		callBuf.WriteString(`
+	// the package is stateful: the client's calls must use the same session
+	sess, sessErr := s.session(ctx)
+	if sessErr != nil {
+		err = errors.Errorf("session: %w", sessErr)
+		return
+	}
+	if sess != nil {
+		defer func() { sess.release(err) }()
+		conn = sess.conn
+	}
+`)
+	}
+	if fun.hasObjects() {
+		callBuf.WriteString(`
+	if db, ok := conn.(*sql.DB); ok {
+		// the objects are bound to the connection they are created on
+		var c *sql.Conn
+		if c, err = db.Conn(ctx); err != nil {
+			return
+		}
+		defer c.Close()
+		conn = c
+	}
+`)
+	}
 	for _, line := range convIn {
 		io.WriteString(callBuf, line+"\n")
 	}
@@ -134,7 +189,7 @@ func (fun Function) PlsqlBlock(checkName string) (plsql, callFun string) {
 	fmt.Fprintf(callBuf, `
 if s.DBLog != nil {
 	const funName = "%s"
-	if err := s.DBLog(ctx, s.db, funName, input); err != nil {
+	if err := s.DBLog(ctx, conn, funName, input); err != nil {
 		Log("dbLog", funName, "error", err)
 	}
 }
@@ -143,28 +198,31 @@ if true || DebugLevel > 0 {
 	Log("calling", callText, "stmt", `+"`%s`"+`, "params", params)
 }
 	qry := %s
+	const traceName = %q
 `,
 		fun.FullName(),
 		call[i:j], rIdentifier.ReplaceAllString(pls, "'%#v'"),
-		fun.getPlsqlConstName())
+		fun.getPlsqlConstName(),
+		strings.ToLower(fun.FullName()))
 	callBuf.WriteString(`
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	stmt, stmtErr := s.db.PrepareContext(ctx, qry)
+	prepareCtx, prepared := genocall.TraceCall(ctx, traceName, "prepare")
+	stmt, stmtErr := conn.PrepareContext(prepareCtx, qry)
+	prepared(stmtErr)
 	if stmtErr != nil {
 		err = errors.Errorf("%s: %w", qry, stmtErr)
 		return
 	}
 	defer stmt.Close()
-	if _, err = stmt.ExecContext(ctx, append(params, godror.PlSQLArrays)...); err != nil {
-		if c, ok := err.(interface{ Code() int }); ok && c.Code() == 4068 {
-			// "existing state of packages has been discarded"
-			_, err = stmt.ExecContext(ctx, append(params, godror.PlSQLArrays)...)
-		}
-		if err != nil {
-			err = errors.Errorf("%q %+v: %w",  qry, params, err)
-			return
-		}
+	execCtx, executed := genocall.TraceCall(ctx, traceName, "exec")
+	_, err = stmt.ExecContext(execCtx, append(params, godror.PlSQLArrays)...)
+	if c, ok := err.(interface{ Code() int }); ok && c.Code() == 4068 {
+		// "existing state of packages has been discarded"
+		_, err = stmt.ExecContext(execCtx, append(params, godror.PlSQLArrays)...)
+	}
+	executed(err)
+	if err != nil {
+		err = errors.Errorf("%q %+v: %w",  qry, params, err)
+		return
 	}
     `)
 
@@ -172,9 +230,20 @@ if true || DebugLevel > 0 {
 	for _, line := range convOut {
 		io.WriteString(callBuf, line+"\n")
 	}
-	if !hasCursorOut {
-		fmt.Fprintf(callBuf, "\nreturn\n")
+	if !streamOut {
+		if streamIn {
+			fmt.Fprintf(callBuf, "\nerr = stream.SendAndClose(output)\nreturn\n")
+		} else {
+			fmt.Fprintf(callBuf, "\nreturn\n")
+		}
+	} else if fun.tagsCursors() {
+		fmt.Fprintf(callBuf, taggedCursorsLoop, withPb(CamelCase(fun.getStructName(true, false))))
 	} else {
+		// the batches of the cursors are limited (see batchLimits), the chunks of the LOBs are not
+		var batchFixed, batchRoom string
+		if fun.HasCursorOut() {
+			batchFixed, batchRoom = "batchFixed := genocall.MessageSize(output)", "batchRoom, batchEmpty = batchBytes-batchFixed, true"
+		}
 		fmt.Fprintf(callBuf, `
 		if len(iterators) == 0 {
 			err = stream.Send(output)
@@ -182,7 +251,9 @@ if true || DebugLevel > 0 {
 		}
 		reseters := make([]func(), 0, len(iterators))
 		iterators2 := make([]iterator, 0, len(iterators))
+		%s
 		for {
+			%s
 			for _, it := range iterators {
 				if err = ctx.Err(); err != nil { return }
 				if err = it.Iterate(); err != nil {
@@ -212,7 +283,7 @@ if true || DebugLevel > 0 {
 			iterators2 = iterators2[:0]
 			reseters = reseters[:0]
 		}
-		`)
+		`, batchFixed, batchRoom)
 	}
 	callBuf.WriteString("\n}\n")
 	callFun = callBuf.String()
@@ -419,7 +490,7 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 
 	var (
 		vn, tmp, typ string
-		ok           bool
+		ok, hasMaps  bool
 	)
 	decls = append(decls, "i1 PLS_INTEGER;", "i2 PLS_INTEGER;")
 	convIn = append(convIn,
@@ -436,11 +507,58 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 	if maxTableSize <= 0 {
 		maxTableSize = MaxTableSize
 	}
+	// the index of the streamed LOB inputs in the LobReceiver
+	lobIdx := make(map[string]int)
+	for i, arg := range fun.streamedLobs(DIR_IN) {
+		lobIdx[arg.Name] = i
+	}
 	for _, arg := range args {
+		if arg.implicitResult {
+			continue
+		}
+		if arg.bindsAsObject() {
+			if convIn, convOut, err = arg.getConvObject(convIn, convOut,
+				CamelCase(arg.Name), addParam(arg.Name)); err != nil {
+				return
+			}
+			continue
+		}
+		if arg.isBfile() {
+			vn = getInnerVarName(fun.FullName(), arg.Name)
+			callArgs[arg.Name] = vn
+			var bDecls, bPre, bPost []string
+			if bDecls, bPre, bPost, convIn, convOut, err = arg.getConvBfile(convIn, convOut,
+				fun.FullName(), vn, addParam); err != nil {
+				return
+			}
+			decls, pre, post = append(decls, bDecls...), append(pre, bPre...), append(post, bPost...)
+			continue
+		}
+		if arg.streamsLob() {
+			var sDecls, sPre, sPost []string
+			sDecls, sPre, sPost, convIn, convOut, vn = arg.getConvStreamedLob(convIn, convOut,
+				fun.FullName(), withPb(CamelCase(fun.getStructName(true, false))), lobIdx[arg.Name], addParam)
+			if vn != "" {
+				callArgs[arg.Name] = vn
+			}
+			decls, pre, post = append(decls, sDecls...), append(pre, sPre...), append(post, sPost...)
+			continue
+		}
 		switch arg.Flavor {
 		case FLAVOR_SIMPLE:
 			name := (CamelCase(arg.Name))
 			//name := capitalize(replHidden(arg.Name))
+			if arg.isBoolean() && !NativeBoolean {
+				vn = getInnerVarName(fun.FullName(), arg.Name)
+				callArgs[arg.Name] = vn
+				decls = append(decls, vn+" BOOLEAN; --L="+arg.Name)
+				if arg.IsInput() {
+					pre = append(pre, vn+" := "+boolFromNumber(":"+arg.Name)+";")
+				}
+				if arg.IsOutput() {
+					post = append(post, ":"+arg.Name+" := "+boolToNumber(vn)+";")
+				}
+			}
 			convIn, convOut = arg.getConvSimple(convIn, convOut,
 				name, addParam(arg.Name))
 
@@ -467,7 +585,18 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 				if got, err = arg.goType(false); err != nil {
 					return
 				}
-				if arg.IsInput() {
+				if arg.IsInput() && ProtoAPIv2 && !SQLOnly {
+					// the APIv2 messages must not be copied (see protoimpl.MessageState)
+					convIn = append(convIn, fmt.Sprintf(`
+					if input.%s != nil {
+						output.%s = proto.Clone(input.%s).(*%s)  // sr1
+					} else {
+						output.%s = new(%s)
+					}
+					`, aname,
+						aname, aname, withPb(CamelCase(got[1:])),
+						aname, withPb(CamelCase(got[1:]))))
+				} else if arg.IsInput() {
 					convIn = append(convIn, fmt.Sprintf(`
 					output.%s = new(%s)  // sr1
 					if input.%s != nil { *output.%s = *input.%s }
@@ -493,11 +622,47 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 				kName := (CamelCase(k))
 				//kName := capitalize(replHidden(k))
 				name := aname + "." + kName
+				if v.Flavor == FLAVOR_TABLE && v.TableOf != nil && v.TableOf.Flavor == FLAVOR_SIMPLE && !v.isMap() {
+					// as the tables of simple values: copied from an array, and read through a cursor
+					if arg.IsInput() {
+						if typ = getTableType(v.TableOf.AbsType); strings.IndexByte(typ, '/') >= 0 {
+							err = errors.Errorf("nonsense table type of %s.%s", arg.Name, k)
+							return
+						}
+						decls = append(decls, tmp+" "+typ+"; --F="+arg.Name)
+						pre = append(pre,
+							tmp+" := :"+tmp+";",
+							vn+"."+k+".DELETE;",
+							"i1 := "+tmp+".FIRST;",
+							"WHILE i1 IS NOT NULL LOOP",
+							"  "+vn+"."+k+"(i1) := "+tmp+"(i1);",
+							"  i1 := "+tmp+".NEXT(i1);",
+							"END LOOP;")
+						convIn = v.getConvRecTable(convIn, [2]string{aname, kName}, addParam(tmp))
+					}
+					if arg.IsOutput() {
+						cur := getParamName(fun.FullName(), vn+"."+k+".rows$")
+						post = append(post, v.tableCursor(cur, vn+"."+k))
+						if convIn, convOut, err = v.getConvTableCursor(convIn, convOut,
+							name, addParam(cur)); err != nil {
+							return
+						}
+					}
+					continue
+				}
 				if arg.IsInput() {
-					pre = append(pre, vn+"."+k+" := :"+tmp+";")
+					if v.isBoolean() && !NativeBoolean {
+						pre = append(pre, vn+"."+k+" := "+boolFromNumber(":"+tmp)+";")
+					} else {
+						pre = append(pre, vn+"."+k+" := :"+tmp+";")
+					}
 				}
 				if arg.IsOutput() {
-					post = append(post, ":"+tmp+" := "+vn+"."+k+";")
+					if v.isBoolean() && !NativeBoolean {
+						post = append(post, ":"+tmp+" := "+boolToNumber(vn+"."+k)+";")
+					} else {
+						post = append(post, ":"+tmp+" := "+vn+"."+k+";")
+					}
 				}
 				convIn, convOut = v.getConvRec(convIn, convOut,
 					name, addParam(tmp),
@@ -511,25 +676,116 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 				}
 				name := (CamelCase(arg.Name))
 				//name := capitalize(replHidden(arg.Name))
-				convIn, convOut = arg.getConvSimpleTable(convIn, convOut,
-					name, addParam(arg.Name), maxTableSize)
-			} else {
-				switch arg.TableOf.Flavor {
-				case FLAVOR_SIMPLE: // like simple, but for the arg.TableOf
-					typ = getTableType(arg.TableOf.AbsType)
-					if strings.IndexByte(typ, '/') >= 0 {
+				convIn, convOut = arg.getConvRefCursor(convIn, convOut,
+					name, addParam(arg.Name), maxTableSize, fun.cursorTag(arg))
+			} else if arg.isMap() {
+				if err = arg.checkMap(); err != nil {
+					return
+				}
+				if !hasMaps {
+					hasMaps = true
+					decls = append(decls, "k1 "+mapKeyType+";")
+				}
+				vn = getInnerVarName(fun.FullName(), arg.Name)
+				callArgs[arg.Name] = vn
+				decls = append(decls, vn+" "+arg.TypeName+"; --M="+arg.Name)
+				keys := getParamName(fun.FullName(), vn+".keys$")
+				decls = append(decls, keys+" "+getTableType(mapKeyType)+"; --M="+arg.Name)
+				// the value (or the fields of the record value) of the element, by the arrays
+				elems := map[string]string{getParamName(fun.FullName(), vn+".values$"): ""}
+				values := []string{getParamName(fun.FullName(), vn+".values$")}
+				fields := []Argument{*arg.TableOf}
+				if arg.TableOf.Flavor == FLAVOR_RECORD {
+					elems, values, fields = make(map[string]string, len(arg.TableOf.RecordOf)), values[:0], fields[:0]
+					for _, a := range arg.TableOf.RecordOf {
+						tmp = getParamName(fun.FullName(), vn+"."+a.Name)
+						elems[tmp] = "." + a.Name
+						values = append(values, tmp)
+						fields = append(fields, *a.Argument)
+					}
+				}
+				// the BOOLEANs are copied from (and to) arrays of numbers, see NativeBoolean
+				numBools := make(map[string]bool, len(values))
+				for i, tmp := range values {
+					absType := fields[i].AbsType
+					if fields[i].isBoolean() {
+						absType = "BOOLEAN"
+						if numBools[tmp] = !NativeBoolean; numBools[tmp] {
+							absType = "NUMBER"
+						}
+					}
+					if typ = getTableType(absType); strings.IndexByte(typ, '/') >= 0 {
 						err = errors.Errorf("nonsense table type of %s", arg)
 						return
 					}
+					decls = append(decls, tmp+" "+typ+"; --M="+arg.Name)
+				}
 
-					setvar := ""
-					if arg.IsInput() {
-						setvar = " := :" + arg.Name
+				pre = append(pre, vn+".DELETE;")
+				if arg.IsInput() {
+					pre = append(pre, keys+" := :"+keys+";")
+					for _, tmp := range values {
+						pre = append(pre, tmp+" := :"+tmp+";")
 					}
-					decls = append(decls, arg.Name+" "+typ+setvar+"; --A="+arg.Name)
-
+					pre = append(pre,
+						"i1 := "+keys+".FIRST;",
+						"WHILE i1 IS NOT NULL LOOP")
+					for _, tmp := range values {
+						value := tmp + "(i1)"
+						if numBools[tmp] {
+							value = boolFromNumber(value)
+						}
+						pre = append(pre, "  "+vn+"("+keys+"(i1))"+elems[tmp]+" := "+value+";")
+					}
+					pre = append(pre,
+						"  i1 := "+keys+".NEXT(i1);",
+						"END LOOP;")
+				}
+				if arg.IsOutput() {
+					post = append(post, keys+".DELETE;")
+					for _, tmp := range values {
+						post = append(post, tmp+".DELETE;")
+					}
+					post = append(post,
+						"k1 := "+vn+".FIRST; i1 := 1;",
+						"WHILE k1 IS NOT NULL LOOP",
+						"  "+keys+"(i1) := k1;")
+					for _, tmp := range values {
+						value := vn + "(k1)" + elems[tmp]
+						if numBools[tmp] {
+							value = boolToNumber(value)
+						}
+						post = append(post, "  "+tmp+"(i1) := "+value+";")
+					}
+					post = append(post,
+						"  k1 := "+vn+".NEXT(k1); i1 := i1 + 1;",
+						"END LOOP;",
+						":"+keys+" := "+keys+";")
+					for _, tmp := range values {
+						post = append(post, ":"+tmp+" := "+tmp+";")
+					}
+				}
+				valueParams := make([]string, len(values))
+				for i, tmp := range values {
+					valueParams[i] = addParam(tmp)
+				}
+				if convIn, convOut, err = arg.getConvMap(convIn, convOut,
+					CamelCase(arg.Name), addParam(keys), valueParams, maxTableSize); err != nil {
+					return
+				}
+			} else {
+				switch arg.TableOf.Flavor {
+				case FLAVOR_SIMPLE: // like simple, but for the arg.TableOf
 					vn = getInnerVarName(fun.FullName(), arg.Name)
 					callArgs[arg.Name] = vn
+					if arg.IsInput() {
+						typ = getTableType(arg.TableOf.AbsType)
+						if strings.IndexByte(typ, '/') >= 0 {
+							err = errors.Errorf("nonsense table type of %s", arg)
+							return
+						}
+						decls = append(decls, arg.Name+" "+typ+" := :"+arg.Name+"; --A="+arg.Name)
+					}
 					decls = append(decls, vn+" "+arg.TypeName+"; --B="+arg.Name)
 					if arg.IsInput() {
 						pre = append(pre,
@@ -540,20 +796,19 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 							"  i1 := "+arg.Name+".NEXT(i1);",
 							"END LOOP;")
 					}
-					if arg.IsOutput() {
-						post = append(post,
-							arg.Name+".DELETE;",
-							"i1 := "+vn+".FIRST;",
-							"WHILE i1 IS NOT NULL LOOP",
-							"  "+arg.Name+"(i1) := "+vn+"(i1);",
-							"  i1 := "+vn+".NEXT(i1);",
-							"END LOOP;",
-							":"+arg.Name+" := "+arg.Name+";")
-					}
 					name := (CamelCase(arg.Name))
 					//name := capitalize(replHidden(arg.Name))
-					convIn, convOut = arg.getConvSimpleTable(convIn, convOut,
-						name, addParam(arg.Name), maxTableSize)
+					if arg.IsInput() {
+						convIn = arg.getConvSimpleTable(convIn, name, addParam(arg.Name))
+					}
+					if arg.IsOutput() {
+						tmp = getParamName(fun.FullName(), vn+".rows$")
+						post = append(post, arg.tableCursor(tmp, vn))
+						if convIn, convOut, err = arg.getConvTableCursor(convIn, convOut,
+							name, addParam(tmp)); err != nil {
+							return
+						}
+					}
 
 				case FLAVOR_RECORD:
 					vn = getInnerVarName(fun.FullName(), arg.Name+"."+arg.TableOf.Name)
@@ -562,100 +817,56 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 
 					aname := (CamelCase(arg.Name))
 					//aname := capitalize(replHidden(arg.Name))
-					if arg.IsOutput() {
-						var tgot string
-						if tgot, err = arg.TableOf.goType(true); err != nil {
-							return
-						}
-						st := withPb(CamelCase(tgot))
-						convOut = append(convOut, fmt.Sprintf(`
-					if m := %d - cap(output.%s); m > 0 { // %s
-						output.%s = append(output.%s[:cap(output.%s)], make([]%s, m)...) // fr1
-                    }
-					output.%s = output.%s[:%d]
-					`,
-							maxTableSize, aname, tgot,
-							aname, aname, aname, st,
-							aname, aname, maxTableSize))
-					}
 					if !arg.IsInput() {
 						pre = append(pre, vn+".DELETE;")
-					}
-
-					// declarations go first
-					for _, a := range arg.TableOf.RecordOf {
-						a := a
-						k, v := a.Name, a.Argument
-						typ = getTableType(v.AbsType)
-						if strings.IndexByte(typ, '/') >= 0 {
-							err = errors.Errorf("nonsense table type of %s", arg)
-							return
-						}
-						decls = append(decls, getParamName(fun.FullName(), vn+"."+k)+" "+typ+"; --D="+arg.Name)
-
-						tmp = getParamName(fun.FullName(), vn+"."+k)
-						if arg.IsInput() {
+					} else {
+						// declarations go first
+						for _, a := range arg.TableOf.RecordOf {
+							a := a
+							k, v := a.Name, a.Argument
+							typ = getTableType(v.AbsType)
+							if strings.IndexByte(typ, '/') >= 0 {
+								err = errors.Errorf("nonsense table type of %s", arg)
+								return
+							}
+							tmp = getParamName(fun.FullName(), vn+"."+k)
+							decls = append(decls, tmp+" "+typ+"; --D="+arg.Name)
 							pre = append(pre, tmp+" := :"+tmp+";")
-						} else {
-							pre = append(pre, tmp+".DELETE;")
 						}
-					}
-
-					// here comes the loops
-					var idxvar string
-					for _, a := range arg.TableOf.RecordOf {
-						a := a
-						k, v := a.Name, a.Argument
 
-						tmp = getParamName(fun.FullName(), vn+"."+k)
+						// here comes the loop
+						var idxvar string
+						for _, a := range arg.TableOf.RecordOf {
+							a := a
+							k, v := a.Name, a.Argument
 
-						if idxvar == "" {
-							idxvar = getParamName(fun.FullName(), vn+"."+k)
-							if arg.IsInput() {
+							tmp = getParamName(fun.FullName(), vn+"."+k)
+							if idxvar == "" {
+								idxvar = tmp
 								pre = append(pre, "",
 									"i1 := "+idxvar+".FIRST;",
 									"WHILE i1 IS NOT NULL LOOP")
 							}
-							if arg.IsOutput() {
-								post = append(post, "",
-									"i1 := "+vn+".FIRST; i2 := 1;",
-									"WHILE i1 IS NOT NULL LOOP")
-							}
-						}
-						kName := (CamelCase(k))
-						//kName := capitalize(replHidden(k))
-						//name := aname + "." + kName
+							kName := (CamelCase(k))
+							//kName := capitalize(replHidden(k))
 
-						convIn, convOut = v.getConvTableRec(
-							convIn, convOut,
-							[2]string{aname, kName},
-							addParam(tmp),
-							uint(maxTableSize),
-							k, *arg.TableOf)
+							convIn = v.getConvTableRec(convIn,
+								[2]string{aname, kName},
+								addParam(tmp))
 
-						if arg.IsInput() {
 							pre = append(pre,
 								"  "+vn+"(i1)."+k+" := "+tmp+"(i1);")
 						}
-						if arg.IsOutput() {
-							post = append(post,
-								"  "+tmp+"(i2) := "+vn+"(i1)."+k+";")
-						}
-					}
-					if arg.IsInput() {
 						pre = append(pre,
 							"  i1 := "+idxvar+".NEXT(i1);",
 							"END LOOP;")
 					}
 					if arg.IsOutput() {
-						post = append(post,
-							"  i1 := "+vn+".NEXT(i1); i2 := i2 + 1;",
-							"END LOOP;")
-						for _, a := range arg.TableOf.RecordOf {
-							a := a
-							k := a.Name
-							tmp = getParamName(fun.FullName(), vn+"."+k)
-							post = append(post, ":"+tmp+" := "+tmp+";")
+						tmp = getParamName(fun.FullName(), vn+".rows$")
+						post = append(post, arg.tableCursor(tmp, vn))
+						if convIn, convOut, err = arg.getConvTableCursor(convIn, convOut,
+							aname, addParam(tmp)); err != nil {
+							return
 						}
 					}
 				default:
@@ -669,15 +880,28 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 		}
 	}
 
+	if results := fun.implicitResults(); len(results) != 0 {
+		var iDecls, iPost []string
+		iDecls, iPost, convIn, convOut = fun.getConvImplicitResults(convIn, convOut, results, addParam)
+		decls, post = append(decls, iDecls...), append(post, iPost...)
+	}
+
 	callb := Buffers.Get()
 	defer Buffers.Put(callb)
 	if fun.Returns != nil {
-		callb.WriteString(":ret := ")
+		if vn, ok = callArgs[fun.Returns.Name]; !ok {
+			vn = ":" + fun.Returns.Name
+		}
+		callb.WriteString(vn + " := ")
 	}
 	//Log("msg","prepareCall", "callArgs", callArgs)
 	callb.WriteString(fun.RealName() + "(")
-	for i, arg := range fun.Args {
-		if i > 0 {
+	var n int
+	for _, arg := range fun.Args {
+		if arg.implicitResult {
+			continue
+		}
+		if n++; n > 1 {
 			callb.WriteString(",\n\t\t")
 		}
 		if vn, ok = callArgs[arg.Name]; !ok {
@@ -710,7 +934,7 @@ func (arg Argument) getConvSimple(
 		} else if arg.IsInput() {
 			convIn = append(convIn, fmt.Sprintf(`output.%s = input.%s  // gcs3`, name, name))
 		}
-		if got == "time.Time" {
+		if got == "time.Time" && !ProtoAPIv2 && !SQLOnly {
 			convOut = append(convOut, fmt.Sprintf("if output.%s != nil && output.%s.IsZero() { output.%s = nil }", name, name, name))
 		}
 		src := "output." + name
@@ -725,137 +949,189 @@ func (arg Argument) getConvSimple(
 	return convIn, convOut
 }
 
+// getConvSimpleTable appends the binding of the input table of simple values.
 func (arg Argument) getConvSimpleTable(
-	convIn, convOut []string,
+	convIn []string,
 	name, paramName string,
-	tableSize int,
-) ([]string, []string) {
-	if arg.IsOutput() {
-		got, err := arg.goType(true)
-		if err != nil {
-			panic(err)
+) []string {
+	if got, _ := arg.goType(true); strings.TrimPrefix(got, "*") == "[]godror.Number" {
+		return append(convIn,
+			fmt.Sprintf(`if len(input.%s) == 0 { %s = []godror.Number{} } else {
+			%s = *custom.NumbersFromStrings(&input.%s) // gcst2
+		}`,
+				name, paramName,
+				paramName, name))
+	}
+	return append(convIn, fmt.Sprintf("%s = input.%s // gcst2", paramName, name))
+}
+
+// getConvRecTable appends the binding of the input table of simple values,
+// the field (name[1]) of the input record (name[0]) - an empty table for a nil record.
+func (arg Argument) getConvRecTable(
+	convIn []string,
+	name [2]string,
+	paramName string,
+) []string {
+	got, _ := arg.goType(true)
+	got = strings.TrimPrefix(got, "*")
+	in := arg.getConvSimpleTable(nil, name[0]+"."+name[1], paramName)[0]
+	return append(convIn, fmt.Sprintf(`if input.%s == nil {
+			%s = %s{} // gcrt1
+		} else {
+			%s
+		}`,
+		name[0],
+		paramName, got,
+		in))
+}
+
+// tableCursor returns the opening of the cursor (bound as cur) over the elements of the
+// PL/SQL table vn, for reading the output table without the limit of the array binds.
+// The rows are ordered by the position of the elements (the ROWNUM of the collection iterator),
+// as the index of the elements cannot be selected.
+//
+// This needs the TABLE operator on PL/SQL tables (Oracle 12.1).
+func (arg Argument) tableCursor(cur, vn string) string {
+	// the BOOLEANs are read as numbers, see NativeBoolean
+	col := func(f Argument, name string) string {
+		if f.isBoolean() && !NativeBoolean {
+			return boolToNumber(name) + " AS " + name
 		}
-		if arg.Type == "REF CURSOR" {
-			return arg.getConvRefCursor(convIn, convOut, name, paramName, tableSize)
+		return name
+	}
+	cols := col(*arg.TableOf, "COLUMN_VALUE")
+	if arg.TableOf.Flavor == FLAVOR_RECORD {
+		names := make([]string, len(arg.TableOf.RecordOf))
+		for i, f := range arg.TableOf.RecordOf {
+			names[i] = col(*f.Argument, `"`+strings.ToUpper(f.Name)+`"`)
 		}
-		if strings.HasPrefix(got, "*[]") { // FIXME(tgulacsi): just a hack, ProtoBuf never generates a pointer to a slice
-			got = got[1:]
+		cols = strings.Join(names, ", ")
+	}
+	return "OPEN :" + cur + " FOR SELECT " + cols +
+		` FROM (SELECT ROWNUM AS "I$", t.* FROM TABLE(` + vn + `) t) ORDER BY "I$";`
+}
+
+// getConvTableCursor appends the binding of the cursor opened by tableCursor,
+// and the reading of its rows into the output table, in batches.
+func (arg Argument) getConvTableCursor(
+	convIn, convOut []string,
+	name, paramName string,
+) ([]string, []string, error) {
+	elem := *arg.TableOf
+	fields := []Argument{elem}
+	if elem.Flavor == FLAVOR_RECORD {
+		fields = fields[:0]
+		for _, f := range elem.RecordOf {
+			fields = append(fields, *f.Argument)
 		}
-		if false {
-			if got == "*[]string" {
-				got = "[]string"
-			}
+	}
+	buf := Buffers.Get()
+	defer Buffers.Put(buf)
+	if elem.Flavor == FLAVOR_RECORD {
+		msg, err := arg.objMessage()
+		if err != nil {
+			return convIn, convOut, err
 		}
-		if got[0] == '*' {
-			convIn = append(convIn, fmt.Sprintf(`
-		if output.%s == nil { // %#v
-			x := make(%s, 0, %d)
-			output.%s = &x
-		} else if cap((*output.%s)) < %d { // simpletable
-			*output.%s = make(%s, 0, %d)
-		} else {
-			*(output.%s) = (*output.%s)[:0]
-		}`, name, arg,
-				strings.TrimLeft(got, "*"), tableSize,
-				name,
-				name, tableSize,
-				name, strings.TrimLeft(got, "*"), tableSize,
-				name, name))
-			if arg.IsInput() {
-				convIn = append(convIn, fmt.Sprintf(`*output.%s = append(*output.%s, input.%s)`, name, name, name))
-			}
-		} else {
-			if arg.IsInput() {
-				convIn = append(convIn, fmt.Sprintf("output.%s = input.%s", name, name))
-			} else {
-				got = CamelCase(got)
-				if got == "[]godror.Number" {
-					convIn = append(convIn,
-						fmt.Sprintf("output.%s = make([]string, 0, %d) // gcst3", name, tableSize))
-				} else {
-					convIn = append(convIn,
-						fmt.Sprintf("output.%s = make(%s, 0, %d) // gcst3", name, got, tableSize))
-				}
+		fmt.Fprintf(buf, "x := new(%s)\n", msg)
+		for i, f := range fields {
+			value, err := f.objValueOut(fmt.Sprintf("I[%d]", i))
+			if err != nil {
+				return convIn, convOut, err
 			}
+			fmt.Fprintf(buf, "x.%s = %s\n", CamelCase(f.Name), value)
 		}
-		in, varName := arg.ToOra(
-			strings.Replace(strings.Replace(paramName, `[{{paramsIdx "`, "__", 1), `"}}]`, "", 1),
-			"output."+name,
-			arg.Direction)
-		convIn = append(convIn,
-			//fmt.Sprintf(`if cap(input.%s) == 0 { input.%s = append(input.%s, make(%s, 1)...)[:0] }`, name, name, name, arg.goType(true)[1:]),
-			fmt.Sprintf(`// in=%q varName=%q`, in, varName))
-		if got == "[]godror.Number" { // don't copy, hack
-			convIn = append(convIn,
-				fmt.Sprintf(`if cap(output.%s) == 0 { output.%s = make([]string, 0, %d) }`, name, name, tableSize),
-				fmt.Sprintf(`%s = sql.Out{Dest: custom.NumbersFromStrings(&output.%s)}  // gcst1`, paramName, name))
-		} else {
-			convIn = append(convIn, fmt.Sprintf(`%s = output.%s // gcst1`, paramName, name))
-		}
+		fmt.Fprintf(buf, "output.%s = append(output.%s, x)", name, name)
 	} else {
-		in, varName := arg.ToOra(
-			strings.Replace(strings.Replace(paramName, `[{{paramsIdx "`, "__", 1), `"}}]`, "", 1),
-			"output."+name,
-			arg.Direction)
-		convIn = append(convIn,
-			fmt.Sprintf(`// in=%q varName=%q`, in, varName))
-		if got, _ := arg.goType(true); got == "[]godror.Number" {
-			convIn = append(convIn,
-				fmt.Sprintf(`if len(input.%s) == 0 { %s = []godror.Number{} } else {
-			%s = *custom.NumbersFromStrings(&input.%s) // gcst2
-		}`,
-					name, paramName,
-					paramName, name))
-		} else {
-			convIn = append(convIn, fmt.Sprintf("%s = input.%s // gcst2", paramName, name))
+		value, err := elem.objValueOut("I[0]")
+		if err != nil {
+			return convIn, convOut, err
 		}
+		fmt.Fprintf(buf, "output.%s = append(output.%s, %s)", name, name, value)
 	}
-	return convIn, convOut
+
+	convIn = append(convIn, fmt.Sprintf("%s = sql.Out{Dest: new(driver.Rows)} // gctc1", paramName))
+	convOut = append(convOut, fmt.Sprintf(`if err = func() error { // gctc2
+		rset := *(%s.(sql.Out).Dest.(*driver.Rows))
+		if rset == nil {
+			return nil
+		}
+		defer rset.Close()
+		output.%s = output.%s[:0]
+		I := make([]driver.Value, %d)
+		for {
+			if err := rset.Next(I); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+			%s
+		}
+	}(); err != nil {
+		err = errors.Errorf("%%s: %%w", %q, err)
+		return
+	}`,
+		paramName,
+		name, name,
+		len(fields),
+		buf.String(),
+		arg.Name))
+	return convIn, convOut, nil
 }
 
+// The rows of a tagged cursor (see TaggedCursors) are read into its batch, not into the output.
 func (arg Argument) getConvRefCursor(
 	convIn, convOut []string,
 	name, paramName string,
 	tableSize int,
+	ct cursorTag,
 ) ([]string, []string) {
 	got, err := arg.goType(true)
 	if err != nil {
 		panic(err)
 	}
-	GoT := withPb(CamelCase(got))
-	convIn = append(convIn, fmt.Sprintf(`output.%s = make([]%s, 0, %d)  // gcrf1
-		%s = sql.Out{Dest:new(driver.Rows)} // gcrf1 %q`,
-		name, GoT, tableSize,
-		paramName, got))
+	// the rows are pointers to the messages (goType returns it only when cached)
+	GoT := withPb(CamelCase("*" + strings.TrimPrefix(got, "*")))
+	dest, tag := "output."+name, ""
+	if ct.Batch != "" {
+		var vn, decl string
+		vn, decl, tag = ct.batchVar(name, GoT, tableSize)
+		dest = vn + ".Rows"
+		convIn = append(convIn, decl)
+	} else {
+		convIn = append(convIn, fmt.Sprintf("output.%s = make([]%s, 0, %d)  // gcrf1", name, GoT, tableSize))
+	}
+	convIn = append(convIn, fmt.Sprintf("%s = sql.Out{Dest:new(driver.Rows)} // gcrf1 %q", paramName, got))
 
 	convOut = append(convOut, fmt.Sprintf(`
 	{
 		rset := *(%s.(sql.Out).Dest.(*driver.Rows))
 		if rset != nil { defer rset.Close() }
+		var pending func() bool
 		iterators = append(iterators, iterator{
-			Reset: func() { output.%s = nil },
+			Reset: func() { %s = nil },
 			Iterate: func() error {
-		a := output.%s[:0]
+		%s = %s[:0]
+		%s
 		I := make([]driver.Value, %d)
-		var err error
-		for i := 0; i < %d; i++ {
-			if err = rset.Next(I); err != nil {
-				break
+		for ; n < batchRows; n++ {
+			if err := rset.Next(I); err != nil {
+				return err
 			}
-			a = append(a, %s)
+			%s
 		}
-		output.%s = a
-		return err
+		return nil
 		},
+		%s
 		})
 	}`,
 		paramName,
-		name,
-		name,
+		dest,
+		dest, dest,
+		batchStart,
 		len(arg.TableOf.RecordOf),
-		batchSize,
-		arg.getFromRset("I"),
-		name,
+		batchAppend(dest, arg.getFromRset("I")),
+		tag,
 	))
 	return convIn, convOut
 }
@@ -964,8 +1240,8 @@ func (arg Argument) getConvRec(
 
 		convIn = append(convIn, too+" // gcr2 var="+varName)
 		if varName != "" {
-			convIn = append(convIn, fmt.Sprintf("%s = sql.Out{Dest:&%s} // gcr2out", paramName, varName))
-			//convOut = append(convOut, arg.FromOra("output."+name, varName, varName)+" // gcr2out")
+			// the field is bound through the variable (with its IN value), so it is copied back
+			convOut = append(convOut, arg.FromOra("output."+name, varName, varName)+" // gcr2out")
 		}
 	} else if arg.IsInput() {
 		parts := strings.Split(name, ".")
@@ -979,14 +1255,13 @@ func (arg Argument) getConvRec(
 	return convIn, convOut
 }
 
+// getConvTableRec appends the binding of the array of the field (name[1])
+// of the elements of the input table of records (name[0]).
 func (arg Argument) getConvTableRec(
-	convIn, convOut []string,
+	convIn []string,
 	name [2]string,
 	paramName string,
-	tableSize uint,
-	key string,
-	parent Argument,
-) ([]string, []string) {
+) []string {
 	absName := "x__" + name[0] + "__" + name[1]
 	typ, err := arg.goType(true)
 	if err != nil {
@@ -996,66 +1271,18 @@ func (arg Argument) getConvTableRec(
 	switch oraTyp {
 	case "custom.Date", "custom.DateTime":
 		oraTyp = "time.Time"
-	case "float64":
-		oraTyp = "float64"
-	case "int32":
-		oraTyp = "int32"
 	}
-	if arg.IsInput() {
-		lengthS := "len(input." + name[0] + ")"
-		too, _ := arg.ToOra(absName+"[i]", "v."+name[1], arg.Direction)
-		setParams := absName
-		if arg.IsOutput() {
-			setParams = fmt.Sprintf("sql.Out{Dest:&%s,In:true} //gctr1", absName)
-		}
-		convIn = append(convIn, fmt.Sprintf(`
-			%s := make([]%s, %s, %d)  // gctr1
+	too, _ := arg.ToOra(absName+"[i]", "v."+name[1], DIR_IN)
+	return append(convIn, fmt.Sprintf(`
+			%s := make([]%s, len(input.%s))  // gctr1
 			for i,v := range input.%s {
 				%s
 			} // gctr1
 			%s = %s`,
-			absName, oraTyp, lengthS, tableSize,
-			name[0],
-			too,
-			paramName, setParams))
-		_ = too
-	}
-	if arg.IsOutput() {
-		if !arg.IsInput() {
-			convIn = append(convIn,
-				fmt.Sprintf(`%s := make([]%s, 0, %d)  // gctr2
-				%s = sql.Out{Dest:&%s} // gctr2`,
-					absName, oraTyp, tableSize,
-					paramName, absName))
-		}
-		got, err := parent.goType(true)
-		if err != nil {
-			panic(err)
-		}
-		convOut = append(convOut,
-			fmt.Sprintf(`if m := len(%s)-cap(output.%s); m > 0 { // gctr3
-			output.%s = append(output.%s, make([]%s, m)...)
-		}
-		output.%s = output.%s[:len(%s)]
-		for i, v := range %s {
-			if output.%s[i] == nil {
-				output.%s[i] = new(%s)
-			}
-			%s // gctr3
-		}`,
-				absName, name[0],
-				name[0], name[0], withPb(CamelCase(got)),
-				name[0], name[0], absName,
-				absName,
-				name[0],
-				name[0], withPb(CamelCase(got[1:])),
-				arg.FromOra(
-					fmt.Sprintf("output.%s[i].%s", name[0], name[1]),
-					"v",
-					"v",
-				)))
-	}
-	return convIn, convOut
+		absName, oraTyp, name[0],
+		name[0],
+		too,
+		paramName, absName))
 }
 
 var varNames = make(map[string]map[string]string, 4)
@@ -1088,8 +1315,10 @@ func getParamName(funName, paramName string) string {
 	return getVarName(funName, paramName, "p")
 }
 
+// withPb qualifies the type name with the "pb" package of the messages,
+// except with SQLOnly, where the structs are in the same package.
 func withPb(s string) string {
-	if s == "" {
+	if s == "" || SQLOnly {
 		return s
 	}
 	if s[0] == '*' || s[0] == '&' {
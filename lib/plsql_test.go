@@ -0,0 +1,130 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// outTableFunctions returns TST_OUT.GET_ROWS(P_ROWS OUT ROWS_T, P_NUMS IN/OUT NUMS_T)
+// and TST_OUT.GET_BAG(P_BAG IN/OUT BAG_T),
+// where ROWS_T is a TABLE OF ROW_T (NUM NUMBER(5), TEXT VARCHAR2(10)), NUMS_T is a TABLE OF NUMBER,
+// and BAG_T is a RECORD (TEXT VARCHAR2(10), NUMS NUMS_T).
+func outTableFunctions(t *testing.T) []Function {
+	numT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "NUM", Prec: sql.NullInt64{Int64: 5, Valid: true}}
+	vcT := &PlsType{TypeName: TypeName{Name: "VARCHAR2"}, Attr: "TEXT", Length: sql.NullInt64{Int64: 10, Valid: true}}
+	rowT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_OUT", Name: "ROW_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{numT, vcT}}
+	rowsT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_OUT", Name: "ROWS_T"}, TypeCode: "PL/SQL INDEX TABLE", IndexBy: "PLS_INTEGER", CollectionOf: rowT}
+	numsT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_OUT", Name: "NUMS_T"}, TypeCode: "PL/SQL INDEX TABLE", IndexBy: "PLS_INTEGER",
+		CollectionOf: &PlsType{TypeName: TypeName{Name: "NUMBER"}}}
+	numsF := *numsT
+	numsF.Attr = "NUMS"
+	bagT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_OUT", Name: "BAG_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{vcT, &numsF}}
+
+	return fixtureFunctions(t, fixtureSnapshot("TST_OUT", []fixtureArg{
+		{"GET_ROWS", "P_ROWS", "OUT", "PL/SQL TABLE", "OWNR.TST_OUT.ROWS_T"},
+		{"GET_ROWS", "P_NUMS", "IN/OUT", "PL/SQL TABLE", "OWNR.TST_OUT.NUMS_T"},
+		{"GET_BAG", "P_BAG", "IN/OUT", "PL/SQL RECORD", "OWNR.TST_OUT.BAG_T"},
+	}, numT, vcT, rowT, rowsT, numsT, bagT))
+}
+
+func TestOutTableCursor(t *testing.T) {
+	functions := outTableFunctions(t)
+	if len(functions) != 2 {
+		t.Fatalf("got %d functions, wanted 2", len(functions))
+	}
+	plsql, callFun := functions[0].PlsqlBlock("")
+	for _, want := range []string{
+		`OPEN :2 FOR SELECT "NUM", "TEXT" FROM (SELECT ROWNUM AS "I$", t.* FROM TABLE(v001) t) ORDER BY "I$";`,
+		"p_nums NUMBER_tab_typ := :1;",
+		`OPEN :3 FOR SELECT COLUMN_VALUE FROM (SELECT ROWNUM AS "I$", t.* FROM TABLE(v004) t) ORDER BY "I$";`,
+	} {
+		if !strings.Contains(plsql, want) {
+			t.Errorf("%q is not in the block:\n%s", want, plsql)
+		}
+	}
+	for _, want := range []string{
+		"params[1] = sql.Out{Dest: new(driver.Rows)}",
+		"output.PRows = append(output.PRows, x)",
+		"x.Text = custom.AsString(I[1])",
+		"params[0] = *custom.NumbersFromStrings(&input.PNums)",
+		"output.PNums = append(output.PNums, custom.AsString(I[0]))",
+	} {
+		if !strings.Contains(callFun, want) {
+			t.Errorf("%q is not in the call:\n%s", want, callFun)
+		}
+	}
+	if strings.Contains(callFun, "128") {
+		t.Errorf("the output tables are limited:\n%s", callFun)
+	}
+
+	// the table in the record
+	plsql, callFun = functions[1].PlsqlBlock("")
+	for _, want := range []string{
+		"v001.nums(i1) := p002#nums(i1);",
+		`OPEN :4 FOR SELECT COLUMN_VALUE FROM (SELECT ROWNUM AS "I$", t.* FROM TABLE(v001.nums) t) ORDER BY "I$";`,
+	} {
+		if !strings.Contains(plsql, want) {
+			t.Errorf("%q is not in the block:\n%s", want, plsql)
+		}
+	}
+	for _, want := range []string{
+		"params[1] = *custom.NumbersFromStrings(&input.PBag.Nums)",
+		"output.PBag.Nums = append(output.PBag.Nums, custom.AsString(I[0]))",
+	} {
+		if !strings.Contains(callFun, want) {
+			t.Errorf("%q is not in the call:\n%s", want, callFun)
+		}
+	}
+	if strings.Contains(callFun, "128") {
+		t.Errorf("the table in the record is limited:\n%s", callFun)
+	}
+}
+
+// outRecordFunctions returns TST_REC.GET_DOC(P_DOC IN/OUT DOC_T),
+// where DOC_T is a RECORD (TXT CLOB, DATA BLOB, WHEN DATE, NUM NUMBER(5)).
+func outRecordFunctions(t *testing.T) []Function {
+	clobT := &PlsType{TypeName: TypeName{Name: "CLOB"}, Attr: "TXT"}
+	blobT := &PlsType{TypeName: TypeName{Name: "BLOB"}, Attr: "DATA"}
+	dateT := &PlsType{TypeName: TypeName{Name: "DATE"}, Attr: "WHEN"}
+	numT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "NUM", Prec: sql.NullInt64{Int64: 5, Valid: true}}
+	docT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_REC", Name: "DOC_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{clobT, blobT, dateT, numT}}
+
+	return fixtureFunctions(t, fixtureSnapshot("TST_REC", []fixtureArg{
+		{"GET_DOC", "P_DOC", "IN/OUT", "PL/SQL RECORD", "OWNR.TST_REC.DOC_T"},
+	}, clobT, blobT, dateT, numT, docT))
+}
+
+// TestOutRecordFields checks that the fields of the OUT records bound through variables
+// (the LOBs, and the dates of APIv2) are copied back into the output - and the IN values are bound.
+func TestOutRecordFields(t *testing.T) {
+	defer func(old bool) { ProtoAPIv2 = old }(ProtoAPIv2)
+	for _, v2 := range []bool{false, true} {
+		ProtoAPIv2 = v2
+		_, callFun := outRecordFunctions(t)[0].PlsqlBlock("")
+		wants := []string{
+			"output.PDoc.Txt = string(b)",
+			"output.PDoc.Data, err = ioutil.ReadAll(var_",
+		}
+		if v2 {
+			wants = append(wants, "output.PDoc.When = custom.AsTimestamp(var_")
+		}
+		for _, want := range wants {
+			if !strings.Contains(callFun, want) {
+				t.Errorf("v2=%t: %q is not in the call:\n%s", v2, want, callFun)
+			}
+		}
+		for _, line := range strings.Split(callFun, "\n") {
+			if strings.Contains(line, "sql.Out{") && strings.Contains(line, "gcr2out") {
+				t.Errorf("v2=%t: the variable is rebound: %q", v2, line)
+			}
+			if strings.Contains(line, "// DATE") && !strings.Contains(line, "In: true") {
+				t.Errorf("v2=%t: the IN date is not bound: %q", v2, line)
+			}
+		}
+	}
+}
@@ -12,15 +12,17 @@ import (
 	//"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
+	"sort"
 	"strings"
 	"unicode"
 
 	fstructs "github.com/fatih/structs"
-	"golang.org/x/exp/slog"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
 )
 
 var (
-	logger *slog.Logger
+	logger = slog.Default()
 
 	SkipMissingTableOf = true
 
@@ -31,179 +33,340 @@ func SetLogger(lgr *slog.Logger) { logger = lgr }
 
 // build: protoc --go_out=plugins=grpc:. my.proto
 
+// SaveProtobuf writes the proto file of the functions, with one service (named after pkg)
+// for all of them - the text of ProtoDescriptor.
 func SaveProtobuf(dst io.Writer, functions []Function, pkg string) error {
-	var err error
-	w := errWriter{Writer: dst, err: &err}
+	return SaveProtobufService(dst, functions, pkg, CamelCase(pkg), "", nil)
+}
 
-	io.WriteString(w, `syntax = "proto3";`+"\n\n")
+// SaveProtobufService writes the messages of the functions, and a service for them
+// - the text of ProtoDescriptorService.
+//
+// The record messages in common are not written, but imported from commonImport.
+func SaveProtobufService(dst io.Writer, functions []Function, pkg, service, commonImport string, common map[string]struct{}) error {
+	fd, err := ProtoDescriptorService("", functions, pkg, service, commonImport, common)
+	if err != nil {
+		return err
+	}
+	return WriteProtoFile(dst, fd)
+}
 
-	if pkg != "" {
-		fmt.Fprintf(w, "package %s;\n", pkg)
+// SaveProtobufCommon writes the record messages used by the functions of more than one package
+// - the text of ProtoDescriptorCommon -, and returns their names - these should be skipped by SaveProtobufService.
+func SaveProtobufCommon(dst io.Writer, functions []Function, pkg string) (map[string]struct{}, error) {
+	fd, common, err := ProtoDescriptorCommon("", functions, pkg)
+	if err != nil {
+		return common, err
 	}
-	seen := make(map[string]struct{}, 16)
+	return common, WriteProtoFile(dst, fd)
+}
 
-	services := make([]string, 0, len(functions))
+// WriteProtoFile writes the .proto text of the descriptor,
+// with the leading comments of its SourceCodeInfo.
+func WriteProtoFile(dst io.Writer, fd *descriptor.FileDescriptorProto) error {
+	var err error
+	w := errWriter{Writer: dst, err: &err}
+	fmt.Fprintf(w, "syntax = %q;\n\n", fd.GetSyntax())
+	if pkg := fd.GetPackage(); pkg != "" {
+		fmt.Fprintf(w, "package %s;\n", pkg)
+	}
+	if len(fd.Dependency) != 0 {
+		io.WriteString(w, "\n")
+	}
+	for _, imp := range fd.Dependency {
+		fmt.Fprintf(w, "import %q;\n", imp)
+	}
+	t := newProtoText(fd)
+	for i, m := range fd.MessageType {
+		t.message(w, i, m)
+	}
+	for i, s := range fd.Service {
+		t.service(w, i, s)
+	}
+	return err
+}
 
-FunLoop:
+// protoCommonRecords returns the record messages used by the functions of more than one package,
+// with their names in order.
+func protoCommonRecords(functions []Function) (map[string]protoRecord, map[string]struct{}, []string, error) {
+	records := make(map[string]protoRecord)
+	users := make(map[string]map[string]struct{})
 	for _, fun := range functions {
-		//b, _ := json.Marshal(struct{Name, Documentation string}{Name:fun.FullName(), Documentation:fun.Documentation})
-		//fmt.Println(string(b))
-		fName := fun.AliasedName()
-		fName = strings.ToLower(fName)
-		if err := fun.SaveProtobuf(w, seen); err != nil {
+		recs := make(map[string]protoRecord)
+		if err := fun.protoRecords(recs); err != nil {
 			if SkipMissingTableOf && (errors.Is(err, ErrMissingTableOf) ||
 				errors.Is(err, UnknownSimpleType)) {
-				logger.Warn("SKIP function, missing TableOf info", "function", fName)
-				continue FunLoop
+				continue
 			}
-			return fmt.Errorf("%s: %w", fun.Name, err)
-		}
-		var streamQual string
-		if fun.HasCursorOut() {
-			streamQual = "stream "
-		}
-		name := CamelCase(dot2D.Replace(fName))
-		var comment string
-		if fun.Documentation != "" {
-			comment = asComment(fun.Documentation, "")
-		}
-		services = append(services,
-			fmt.Sprintf(`%srpc %s (%s) returns (%s%s) {}`,
-				comment,
-				name,
-				CamelCase(fun.getStructName(false, false)),
-				streamQual,
-				CamelCase(fun.getStructName(true, false)),
-			),
-		)
+			return nil, nil, nil, fmt.Errorf("%s: %w", fun.Name, err)
+		}
+		for k, v := range recs {
+			if _, ok := records[k]; !ok {
+				records[k] = v
+			}
+			if users[k] == nil {
+				users[k] = make(map[string]struct{})
+			}
+			users[k][fun.Package] = struct{}{}
+		}
 	}
-
-	fmt.Fprintf(w, "\nservice %s {\n", CamelCase(pkg))
-	for _, s := range services {
-		fmt.Fprintf(w, "\t%s\n", s)
+	common := make(map[string]struct{})
+	names := make([]string, 0, len(users))
+	for k, pkgs := range users {
+		if len(pkgs) > 1 {
+			common[k] = struct{}{}
+			names = append(names, k)
+		}
 	}
-	w.Write([]byte("}"))
+	sort.Strings(names)
+	return records, common, names, nil
+}
 
-	return nil
+// protoRecord is a record message's documentation and fields.
+type protoRecord struct {
+	Doc  string
+	Args []Argument
 }
 
-func (f Function) SaveProtobuf(dst io.Writer, seen map[string]struct{}) error {
-	var buf bytes.Buffer
-	if err := f.saveProtobufDir(&buf, seen, false); err != nil {
-		return fmt.Errorf("%s: %w", "input", err)
-	}
-	if err := f.saveProtobufDir(&buf, seen, true); err != nil {
-		return fmt.Errorf("%s: %w", "output", err)
+// protoRecords collects the record messages used by the function's arguments.
+func (f Function) protoRecords(records map[string]protoRecord) error {
+	for _, dirmap := range []direction{DIR_IN, DIR_OUT} {
+		args := make([]Argument, 0, len(f.Args)+1)
+		for _, arg := range f.Args {
+			if arg.Direction&dirmap > 0 {
+				args = append(args, arg)
+			}
+		}
+		if dirmap == DIR_OUT && f.Returns != nil {
+			args = append(args, *f.Returns)
+		}
+		if err := protoCollectRecords(records, getDirDoc(f.Documentation, dirmap), args...); err != nil {
+			return err
+		}
 	}
-	_, err := dst.Write(buf.Bytes())
-	return err
+	return nil
 }
-func (f Function) saveProtobufDir(dst io.Writer, seen map[string]struct{}, out bool) error {
-	dirmap, dirname := DIR_IN, "input"
-	if out {
-		dirmap, dirname = DIR_OUT, "output"
-	}
-	args := make([]Argument, 0, len(f.Args)+1)
-	for _, arg := range f.Args {
-		if arg.Direction&dirmap > 0 {
-			args = append(args, arg)
+
+func protoCollectRecords(records map[string]protoRecord, D argDocs, args ...Argument) error {
+	for _, arg := range args {
+		if strings.HasSuffix(arg.Name, "#") {
+			arg.Name = replHidden(arg.Name)
+		}
+		if arg.Flavor == FLAVOR_TABLE && arg.TableOf == nil {
+			return fmt.Errorf("no table of data for %v: %w", arg, ErrMissingTableOf)
+		}
+		if arg.Flavor == FLAVOR_SIMPLE || arg.Flavor == FLAVOR_TABLE && arg.TableOf.Flavor == FLAVOR_SIMPLE {
+			continue
+		}
+		_, typ, _, err := protoField(arg)
+		if err != nil {
+			return err
+		}
+		typ = CamelCase(typ)
+		if _, ok := records[typ]; ok {
+			continue
+		}
+		subArgs := protoRecordArgs(arg)
+		records[typ] = protoRecord{Doc: D.Map[arg.Name], Args: subArgs}
+		if err := protoCollectRecords(records, argDocs{Pre: D.Map[arg.Name]}, subArgs...); err != nil {
+			return err
 		}
 	}
-	// return variable for function out structs
-	if out && f.Returns != nil {
-		args = append(args, *f.Returns)
-	}
+	return nil
+}
 
-	nm := f.Name
-	if f.Alias != "" {
-		nm = f.Alias
+// SaveProtobuf writes the input and output messages of the function,
+// and the record messages of their fields not seen yet.
+func (f Function) SaveProtobuf(dst io.Writer, seen map[string]struct{}) error {
+	d := newProtoDesc("", "")
+	messages, err := d.functionMessages(f, seen)
+	if err != nil {
+		return err
 	}
-	return protoWriteMessageTyp(dst,
-		CamelCase(dot2D.Replace(strings.ToLower(nm))+"__"+dirname),
-		seen, getDirDoc(f.Documentation, dirmap), args...)
+	d.fd.MessageType = messages
+	d.finish()
+	w := errWriter{Writer: dst, err: &err}
+	t := newProtoText(d.fd)
+	for i, m := range d.fd.MessageType {
+		t.message(w, i, m)
+	}
+	return err
 }
 
 var dot2D = strings.NewReplacer(".", "__")
 
-func protoWriteMessageTyp(dst io.Writer, msgName string, seen map[string]struct{}, D argDocs, args ...Argument) error {
-	for _, arg := range args {
-		if arg.Flavor == FLAVOR_TABLE && arg.TableOf == nil {
-			return fmt.Errorf("no table of data for %s.%s (%v): %w", msgName, arg, arg, ErrMissingTableOf)
+// protoText writes the .proto text of the declarations of a descriptor.
+type protoText struct {
+	fd       *descriptor.FileDescriptorProto
+	comments map[string]string
+}
+
+func newProtoText(fd *descriptor.FileDescriptorProto) protoText {
+	t := protoText{fd: fd, comments: make(map[string]string)}
+	for _, loc := range fd.GetSourceCodeInfo().GetLocation() {
+		if c := loc.GetLeadingComments(); c != "" {
+			t.comments[fmt.Sprint(loc.GetPath())] = c
 		}
 	}
+	return t
+}
 
-	var err error
-	w := &errWriter{Writer: dst, err: &err}
-	fmt.Fprintf(w, "%smessage %s {\n", asComment(strings.TrimRight(D.Pre+D.Post, " \n\t"), ""), msgName)
+// comment writes the leading comment of the declaration at path, each line after the prefix,
+// and reports whether there is any.
+func (t protoText) comment(w io.Writer, prefix string, path ...int32) bool {
+	c, ok := t.comments[fmt.Sprint(path)]
+	if !ok {
+		return false
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(c, "\n"), "\n") {
+		fmt.Fprintf(w, "%s//%s\n", prefix, line)
+	}
+	return true
+}
 
-	buf := Buffers.Get()
-	defer Buffers.Put(buf)
-	for i, arg := range args {
-		var rule string
-		if strings.HasSuffix(arg.Name, "#") {
-			arg.Name = replHidden(arg.Name)
-		}
-		if arg.Flavor == FLAVOR_TABLE {
-			if arg.TableOf == nil {
-				return fmt.Errorf("no table of data for %s.%s (%v): %w", msgName, arg, arg, ErrMissingTableOf)
-			}
-			rule = "repeated "
-		}
-		aName := arg.Name
-		got, err := arg.goType(false)
-		if err != nil {
-			return fmt.Errorf("%s: %w", msgName, err)
-		}
-		got = strings.TrimPrefix(got, "*")
-		if strings.HasPrefix(got, "[]") {
-			rule = "repeated "
-			got = got[2:]
+// message writes the i-th message of the file.
+//
+// The commented fields are separated by an empty line, the oneof fields are after the others.
+func (t protoText) message(w io.Writer, i int, m *descriptor.DescriptorProto) {
+	io.WriteString(w, "\n")
+	t.comment(w, "", 4, int32(i))
+	fmt.Fprintf(w, "message %s {\n", m.GetName())
+	oneofs := make([]strings.Builder, len(m.OneofDecl))
+	var fields strings.Builder
+	for j, f := range m.Field {
+		path := []int32{4, int32(i), 2, int32(j)}
+		if f.OneofIndex != nil {
+			o := &oneofs[f.GetOneofIndex()]
+			t.comment(o, "\t\t", path...)
+			fmt.Fprintf(o, "\t\t%s %s = %d;\n", t.fieldType(m, f), f.GetName(), f.GetNumber())
+			continue
 		}
-		got = strings.TrimPrefix(got, "*")
-		if got == "" {
-			got = mkRecTypName(arg.Name)
+		fields.Reset()
+		if t.comment(&fields, "\t", path...) {
+			io.WriteString(w, "\n")
 		}
-		typ, pOpts := protoType(got, arg.Name, arg.AbsType)
 		var optS string
-		if s := pOpts.String(); s != "" {
+		if s := protoOptionsOf(f.Options).String(); s != "" {
 			optS = " " + s
 		}
-		if arg.Flavor == FLAVOR_SIMPLE || arg.Flavor == FLAVOR_TABLE && arg.TableOf.Flavor == FLAVOR_SIMPLE {
-			fmt.Fprintf(w, "%s\t// %s\n\t%s%s %s = %d%s;\n", asComment(D.Map[aName], "\t"), arg.AbsType, rule, typ, aName, i+1, optS)
-			continue
+		fmt.Fprintf(w, "%s\t%s %s = %d%s;\n", fields.String(), t.fieldType(m, f), f.GetName(), f.GetNumber(), optS)
+	}
+	for k, o := range m.OneofDecl {
+		fmt.Fprintf(w, "\toneof %s {\n%s\t}\n", o.GetName(), oneofs[k].String())
+	}
+	io.WriteString(w, "}\n")
+}
+
+// service writes the i-th service of the file.
+func (t protoText) service(w io.Writer, i int, s *descriptor.ServiceDescriptorProto) {
+	fmt.Fprintf(w, "\nservice %s {\n", s.GetName())
+	var comment strings.Builder
+	for j, m := range s.Method {
+		comment.Reset()
+		if t.comment(&comment, "\t", 6, int32(i), 2, int32(j)) {
+			io.WriteString(w, "\n")
 		}
-		typ = CamelCase(typ)
-		if _, ok := seen[typ]; !ok {
-			seen[typ] = struct{}{}
-			//lName := strings.ToLower(arg.Name)
-			subArgs := make([]Argument, 0, 16)
-			if arg.TableOf == nil {
-				for _, v := range arg.RecordOf {
-					subArgs = append(subArgs, *v.Argument)
-				}
-			} else {
-				if arg.TableOf.RecordOf == nil {
-					subArgs = append(subArgs, *arg.TableOf)
-				} else {
-					for _, v := range arg.TableOf.RecordOf {
-						subArgs = append(subArgs, *v.Argument)
-					}
-				}
+		var streamIn, streamOut string
+		if m.GetClientStreaming() {
+			streamIn = "stream "
+		}
+		if m.GetServerStreaming() {
+			streamOut = "stream "
+		}
+		fmt.Fprintf(w, "%s\trpc %s (%s%s) returns (%s%s) {}\n",
+			comment.String(), m.GetName(),
+			streamIn, t.typeName(m.GetInputType()),
+			streamOut, t.typeName(m.GetOutputType()),
+		)
+	}
+	io.WriteString(w, "}\n")
+}
+
+// fieldType returns the type of the field of m, with its repetition rule
+// - map<K, V> for the repeated field of a map entry.
+func (t protoText) fieldType(m *descriptor.DescriptorProto, f *descriptor.FieldDescriptorProto) string {
+	var typ string
+	if name := f.GetTypeName(); name != "" {
+		for _, e := range m.NestedType {
+			if e.GetOptions().GetMapEntry() && strings.HasSuffix(name, "."+m.GetName()+"."+e.GetName()) {
+				return "map<" + t.fieldType(e, e.Field[0]) + ", " + t.fieldType(e, e.Field[1]) + ">"
 			}
-			if err = protoWriteMessageTyp(buf, typ, seen, argDocs{Pre: D.Map[aName]}, subArgs...); err != nil {
-				logger.Error("protoWriteMessageTyp", "error", err)
-				return err
+		}
+		typ = t.typeName(name)
+	} else {
+		for k, v := range protoScalarTypes {
+			if v == f.GetType() {
+				typ = k
+				break
 			}
 		}
-		fmt.Fprintf(w, "\t%s%s %s = %d%s;\n", rule, typ, aName, i+1, optS)
 	}
-	io.WriteString(w, "}\n")
-	w.Write(buf.Bytes())
+	if f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		return "repeated " + typ
+	}
+	return typ
+}
 
-	return err
+// typeName returns the name of the fully qualified type, relative to the package of the file.
+func (t protoText) typeName(name string) string {
+	if pkg := t.fd.GetPackage(); pkg != "" && strings.HasPrefix(name, "."+pkg+".") {
+		return name[len(pkg)+2:]
+	}
+	return strings.TrimPrefix(name, ".")
+}
+
+// protoField returns the repetition rule ("map" for a map), the type (of the values of a map)
+// and the options of the argument's field.
+func protoField(arg Argument) (rule, typ string, opts protoOptions, err error) {
+	got, err := arg.goType(false)
+	if err != nil {
+		return "", "", nil, err
+	}
+	got = strings.TrimPrefix(got, "*")
+	if strings.HasPrefix(got, "[]") && got != "[]byte" { // []byte is bytes
+		rule = "repeated "
+		got = got[2:]
+	} else if strings.HasPrefix(got, "map[string]") {
+		rule = "map"
+		got = got[len("map[string]"):]
+	}
+	got = strings.TrimPrefix(got, "*")
+	if got == "" {
+		got = mkRecTypName(arg.Name)
+	}
+	typ, opts = protoType(got, arg.Name, arg.AbsType)
+	return rule, typ, opts, nil
+}
+
+// protoRecordArgs returns the fields of the record (or table of records) argument.
+func protoRecordArgs(arg Argument) []Argument {
+	subArgs := make([]Argument, 0, 16)
+	if arg.TableOf == nil {
+		for _, v := range arg.RecordOf {
+			subArgs = append(subArgs, *v.Argument)
+		}
+	} else if arg.TableOf.Flavor == FLAVOR_TABLE {
+		subArgs = append(subArgs, *arg.itemsArg().RecordOf[0].Argument)
+	} else if arg.TableOf.RecordOf == nil {
+		subArgs = append(subArgs, *arg.TableOf)
+	} else {
+		for _, v := range arg.TableOf.RecordOf {
+			subArgs = append(subArgs, *v.Argument)
+		}
+	}
+	return subArgs
 }
 
 func protoType(got, aName, absType string) (string, protoOptions) {
+	typ, opts := protoTypeGogo(got, aName, absType)
+	if ProtoAPIv2 {
+		// protojson names and formats the fields by itself
+		return typ, nil
+	}
+	return typ, opts
+}
+
+func protoTypeGogo(got, aName, absType string) (string, protoOptions) {
 	switch trimmed := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(got, "[]"), "*")); trimmed {
 	case "string":
 		return "string", nil
@@ -234,9 +397,14 @@ func protoType(got, aName, absType string) (string, protoOptions) {
 			"gogoproto.customtype": "github.com/godror/gen-o-call/custom.DateTime",
 			"gogoproto.moretags":   `xml:",omitempty"`,
 		}
+	case "time.duration":
+		return "google.protobuf.Duration", protoOptions{
+			"gogoproto.stdduration": true,
+			"gogoproto.nullable":    false,
+		}
 	case "n":
 		return "string", nil
-	case "raw":
+	case "raw", "byte":
 		return "bytes", nil
 	case "godror.lob", "ora.lob":
 		if absType == "CLOB" {
@@ -254,9 +422,15 @@ func (opts protoOptions) String() string {
 	if len(opts) == 0 {
 		return ""
 	}
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	var buf bytes.Buffer
 	buf.WriteByte('[')
-	for k, v := range opts {
+	for _, k := range keys {
+		v := opts[k]
 		if buf.Len() != 1 {
 			buf.WriteString(", ")
 		}
@@ -292,10 +466,6 @@ func CopyStruct(dest interface{}, src interface{}) error {
 }
 func mkRecTypName(name string) string { return strings.ToLower(name) + "_rek_typ" }
 
-func asComment(s, prefix string) string {
-	return "\n" + prefix + "// " + strings.Replace(s, "\n", "\n"+prefix+"// ", -1) + "\n"
-}
-
 type argDocs struct {
 	Pre, Post string
 	Docs      []string
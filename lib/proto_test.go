@@ -8,7 +8,11 @@ package genocall
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -109,3 +113,157 @@ func TestParseArgDocs(t *testing.T) {
 		}
 	}
 }
+
+// twoPackageFunctions returns the snapshot's function, and a copy of it (with the same name)
+// in another package, so both use the same record type.
+func twoPackageFunctions(t *testing.T) []Function {
+	functions, _, err := testSnapshot().Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := functions[0]
+	other.Package = "TST_OTHER"
+	// as ApplyAnnotations
+	return qualifyNames(append(functions, other))
+}
+
+func TestSaveProtobufCommon(t *testing.T) {
+	functions := twoPackageFunctions(t)
+	var buf strings.Builder
+	common, err := SaveProtobufCommon(&buf, functions, "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(buf.String())
+	if len(common) != 1 {
+		t.Fatalf("got %v, wanted one common record", common)
+	}
+	var rec string
+	for k := range common {
+		rec = k
+	}
+	if !strings.Contains(buf.String(), "message "+rec+" {") {
+		t.Errorf("common record %q is not in the common proto", rec)
+	}
+
+	for _, f := range functions {
+		buf.Reset()
+		if err := SaveProtobufService(&buf, []Function{f}, "snap", CamelCase(f.Package), "snap_common.proto", common); err != nil {
+			t.Fatal(err)
+		}
+		s := buf.String()
+		t.Log(s)
+		if strings.Contains(s, "message "+rec) {
+			t.Errorf("%s: common record %q is written", f.Package, rec)
+		}
+		if !strings.Contains(s, `import "snap_common.proto";`) {
+			t.Errorf("%s: common proto is not imported", f.Package)
+		}
+		if !strings.Contains(s, "service "+CamelCase(f.Package)+" {") {
+			t.Errorf("%s: service is not named after the package", f.Package)
+		}
+	}
+
+	// just one package: nothing is common
+	buf.Reset()
+	if common, err = SaveProtobufCommon(&buf, functions[:1], "snap"); err != nil {
+		t.Fatal(err)
+	} else if len(common) != 0 {
+		t.Errorf("got %v, wanted nothing common", common)
+	}
+}
+
+// nestedRecordFunctions returns a function in each of two packages, both with an OUTER_T record
+// which has an INNER_T record in it, and an OWN_T record (with a DATE) used only by the first package.
+func nestedRecordFunctions(t *testing.T) []Function {
+	numT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "NUM", Prec: sql.NullInt64{Int64: 9, Valid: true}}
+	vcT := &PlsType{TypeName: TypeName{Name: "VARCHAR2"}, Attr: "TEXT", Length: sql.NullInt64{Int64: 10, Valid: true}}
+	recT := func(name string, fields ...*PlsType) *PlsType {
+		return &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_TYPES", Name: name}, TypeCode: "PL/SQL RECORD", RecordOf: fields}
+	}
+	innerT := recT("INNER_T", numT)
+	innerT.Attr = "INNER"
+	dateT := &PlsType{TypeName: TypeName{Name: "DATE"}, Attr: "WHEN"}
+	outerT, ownT := recT("OUTER_T", innerT, vcT), recT("OWN_T", vcT, dateT)
+
+	var args []UserArgument
+	ua := func(pkg string, level uint8, name, dataType, typeSubname string) {
+		a := UserArgument{
+			PackageName: pkg, ObjectName: "REC_" + pkg[len(pkg)-1:], LastDDL: time.Date(2023, 8, 17, 10, 11, 12, 0, time.UTC),
+			DataLevel: level, ArgumentName: name, InOut: "IN", DataType: dataType,
+		}
+		if name == "" {
+			a.InOut = "OUT"
+		}
+		if typeSubname != "" {
+			a.TypeOwner, a.TypeName, a.TypeSubname = "OWNR", "TST_TYPES", typeSubname
+		}
+		args = append(args, a)
+	}
+	for _, pkg := range []string{"TST_A", "TST_B"} {
+		ua(pkg, 0, "", "NUMBER", "")
+		ua(pkg, 0, "P_OUTER", "PL/SQL RECORD", "OUTER_T")
+		ua(pkg, 1, "INNER", "PL/SQL RECORD", "INNER_T")
+		ua(pkg, 2, "NUM", "NUMBER", "")
+		ua(pkg, 1, "TEXT", "VARCHAR2", "")
+		if pkg == "TST_A" {
+			ua(pkg, 0, "P_OWN", "PL/SQL RECORD", "OWN_T")
+			ua(pkg, 1, "TEXT", "VARCHAR2", "")
+			ua(pkg, 1, "WHEN", "DATE", "")
+		}
+	}
+	snap := Snapshot{
+		Arguments: args,
+		Types: flattenTypes(map[TypeName]*PlsType{
+			numT.TypeName: numT, vcT.TypeName: vcT, dateT.TypeName: dateT,
+			innerT.TypeName: innerT, outerT.TypeName: outerT, ownT.TypeName: ownT,
+		}),
+	}
+	functions, _, err := snap.Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return functions
+}
+
+func TestSaveProtobufCommonNested(t *testing.T) {
+	functions := nestedRecordFunctions(t)
+	var buf strings.Builder
+	common, err := SaveProtobufCommon(&buf, functions, "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(buf.String())
+	const inner, outer, own = "TstTypes_InnerT_Ownr", "TstTypes_OuterT_Ownr", "TstTypes_OwnT_Ownr"
+	if len(common) != 2 {
+		t.Errorf("got %v, wanted %s and %s", common, inner, outer)
+	}
+	for _, rec := range []string{inner, outer} {
+		if _, ok := common[rec]; !ok {
+			t.Errorf("%s is not common", rec)
+		}
+		if !strings.Contains(buf.String(), "message "+rec+" {") {
+			t.Errorf("%s is not in the common proto", rec)
+		}
+	}
+	if strings.Contains(buf.String(), own) {
+		t.Errorf("%s is in the common proto", own)
+	}
+
+	for _, f := range functions {
+		buf.Reset()
+		if err := SaveProtobufService(&buf, []Function{f}, "snap", CamelCase(f.Package), "snap_common.proto", common); err != nil {
+			t.Fatal(err)
+		}
+		s := buf.String()
+		t.Log(s)
+		for _, rec := range []string{inner, outer} {
+			if strings.Contains(s, "message "+rec) {
+				t.Errorf("%s: common record %s is written", f.Package, rec)
+			}
+		}
+		if got, want := strings.Contains(s, "message "+own+" {"), f.Package == "TST_A"; got != want {
+			t.Errorf("%s: %s is written: %t, wanted %t", f.Package, own, got, want)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// ProtoDescriptor returns the descriptor of the proto file of the functions, named name,
+// with one service (named after pkg) for all of them. SaveProtobuf writes its text.
+func ProtoDescriptor(name string, functions []Function, pkg string) (*descriptor.FileDescriptorProto, error) {
+	return ProtoDescriptorService(name, functions, pkg, CamelCase(pkg), "", nil)
+}
+
+// ProtoDescriptorService returns the descriptor of the proto file of the functions, named name,
+// with the messages of the functions and a service for them. SaveProtobufService writes its text.
+//
+// The documentation of the functions and their arguments is in the leading comments of its SourceCodeInfo.
+//
+// The record messages in common are not declared, but imported from commonImport.
+func ProtoDescriptorService(name string, functions []Function, pkg, service, commonImport string, common map[string]struct{}) (*descriptor.FileDescriptorProto, error) {
+	d := newProtoDesc(name, pkg)
+	seen := make(map[string]struct{}, 16+len(common))
+	for k := range common {
+		seen[k] = struct{}{}
+	}
+	s := descriptor.ServiceDescriptorProto{Name: proto.String(service)}
+	for _, fun := range functions {
+		fName := strings.ToLower(fun.AliasedName())
+		messages, err := d.functionMessages(fun, seen)
+		if err != nil {
+			if SkipMissingTableOf && (errors.Is(err, ErrMissingTableOf) ||
+				errors.Is(err, UnknownSimpleType)) {
+				logger.Warn("SKIP function, missing TableOf info", "function", fName)
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", fun.Name, err)
+		}
+		d.fd.MessageType = append(d.fd.MessageType, messages...)
+		m := descriptor.MethodDescriptorProto{
+			Name:       proto.String(CamelCase(dot2D.Replace(fName))),
+			InputType:  proto.String(d.typeName(CamelCase(fun.getStructName(false, false)))),
+			OutputType: proto.String(d.typeName(CamelCase(fun.getStructName(true, false)))),
+		}
+		if fun.streamsInput() {
+			m.ClientStreaming = proto.Bool(true)
+		}
+		if fun.streamsOutput() {
+			m.ServerStreaming = proto.Bool(true)
+		}
+		d.comment(&m, fun.Documentation)
+		s.Method = append(s.Method, &m)
+	}
+	d.fd.Service = append(d.fd.Service, &s)
+	var imports []string
+	if commonImport != "" && len(common) != 0 {
+		imports = append(imports, commonImport)
+	}
+	d.finish(imports...)
+	return d.fd, nil
+}
+
+// ProtoDescriptorCommon returns the descriptor of the proto file of the record messages
+// used by the functions of more than one package, named name, and the names of its messages
+// - these should be skipped by ProtoDescriptorService. SaveProtobufCommon writes its text.
+func ProtoDescriptorCommon(name string, functions []Function, pkg string) (*descriptor.FileDescriptorProto, map[string]struct{}, error) {
+	records, common, names, err := protoCommonRecords(functions)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := newProtoDesc(name, pkg)
+	seen := make(map[string]struct{}, len(common))
+	for k := range common {
+		seen[k] = struct{}{}
+	}
+	for _, nm := range names {
+		rec := records[nm]
+		messages, err := d.messages(nm, seen, argDocs{Pre: rec.Doc}, nil, rec.Args...)
+		if err != nil {
+			return nil, common, fmt.Errorf("%s: %w", nm, err)
+		}
+		d.fd.MessageType = append(d.fd.MessageType, messages...)
+	}
+	d.finish()
+	return d.fd, common, nil
+}
+
+// protoDesc builds the descriptor of a proto file.
+type protoDesc struct {
+	fd                                *descriptor.FileDescriptorProto
+	comments                          map[interface{}]string
+	gogo, usesTimestamp, usesDuration bool
+}
+
+func newProtoDesc(name, pkg string) *protoDesc {
+	fd := descriptor.FileDescriptorProto{Name: proto.String(name), Syntax: proto.String("proto3")}
+	if pkg != "" {
+		fd.Package = proto.String(pkg)
+	}
+	return &protoDesc{fd: &fd, comments: make(map[interface{}]string)}
+}
+
+// comment records the comment of the declaration (a message, a field or a method).
+func (d *protoDesc) comment(decl interface{}, comment string) {
+	if comment != "" {
+		d.comments[decl] = comment
+	}
+}
+
+// typeName returns the fully qualified name of the message of this file.
+func (d *protoDesc) typeName(name string) string {
+	if pkg := d.fd.GetPackage(); pkg != "" {
+		return "." + pkg + "." + name
+	}
+	return "." + name
+}
+
+// finish sets the imports - the well-known ones after the given -, and the comments.
+func (d *protoDesc) finish(imports ...string) {
+	if d.gogo {
+		imports = append(imports, "gogo.proto")
+	}
+	if d.usesTimestamp {
+		imports = append(imports, "google/protobuf/timestamp.proto")
+	}
+	if d.usesDuration {
+		imports = append(imports, "google/protobuf/duration.proto")
+	}
+	d.fd.Dependency = imports
+	d.setSourceCodeInfo()
+}
+
+// setSourceCodeInfo sets the recorded comments as the leading comments of their declarations.
+//
+// The .proto text is written from the descriptor (see WriteProtoFile), so there is no span to point at.
+func (d *protoDesc) setSourceCodeInfo() {
+	var locs []*descriptor.SourceCodeInfo_Location
+	add := func(decl interface{}, path ...int32) {
+		comment, ok := d.comments[decl]
+		if !ok {
+			return
+		}
+		var buf strings.Builder
+		for _, line := range strings.Split(comment, "\n") {
+			buf.WriteString(" " + line + "\n")
+		}
+		locs = append(locs, &descriptor.SourceCodeInfo_Location{
+			Path: path, Span: []int32{0, 0, 0}, LeadingComments: proto.String(buf.String()),
+		})
+	}
+	for i, m := range d.fd.MessageType {
+		add(m, 4, int32(i))
+		for j, f := range m.Field {
+			add(f, 4, int32(i), 2, int32(j))
+		}
+	}
+	for i, s := range d.fd.Service {
+		for j, m := range s.Method {
+			add(m, 6, int32(i), 2, int32(j))
+		}
+	}
+	if len(locs) != 0 {
+		d.fd.SourceCodeInfo = &descriptor.SourceCodeInfo{Location: locs}
+	}
+}
+
+// functionMessages returns the input and output messages of the function,
+// and the record messages of their fields not seen yet.
+func (d *protoDesc) functionMessages(f Function, seen map[string]struct{}) ([]*descriptor.DescriptorProto, error) {
+	var messages []*descriptor.DescriptorProto
+	for _, out := range []bool{false, true} {
+		dirmap, dirname := DIR_IN, "input"
+		if out {
+			dirmap, dirname = DIR_OUT, "output"
+		}
+		args := make([]Argument, 0, len(f.Args)+1)
+		for _, arg := range f.Args {
+			if arg.Direction&dirmap > 0 {
+				args = append(args, arg)
+			}
+		}
+		if out && f.Returns != nil {
+			args = append(args, *f.Returns)
+		}
+		var tagged func(Argument) string
+		if out && f.tagsCursors() {
+			tagged = func(arg Argument) string {
+				if f.isTaggedCursor(arg) {
+					return f.cursorBatchName(arg)
+				}
+				return ""
+			}
+		}
+		nm := f.AliasedName()
+		msgs, err := d.messages(CamelCase(dot2D.Replace(strings.ToLower(nm))+"__"+dirname),
+			seen, getDirDoc(f.Documentation, dirmap), tagged, args...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dirname, err)
+		}
+		messages = append(messages, msgs...)
+	}
+	return messages, nil
+}
+
+// messages returns the message, followed by the record messages of its fields not seen yet.
+//
+// The arguments tagged returns a batch message name for (the tagged cursors, see TaggedCursors)
+// are in the cursor_rows oneof, as that batch message.
+func (d *protoDesc) messages(msgName string, seen map[string]struct{}, D argDocs, tagged func(Argument) string, args ...Argument) ([]*descriptor.DescriptorProto, error) {
+	for _, arg := range args {
+		if arg.Flavor == FLAVOR_TABLE && arg.TableOf == nil {
+			return nil, fmt.Errorf("no table of data for %s.%s (%v): %w", msgName, arg, arg, ErrMissingTableOf)
+		}
+	}
+	m := descriptor.DescriptorProto{Name: proto.String(msgName)}
+	d.comment(&m, strings.TrimRight(D.Pre+D.Post, " \n\t"))
+	messages := []*descriptor.DescriptorProto{&m}
+	var oneof []*descriptor.FieldDescriptorProto
+	for i, arg := range args {
+		if strings.HasSuffix(arg.Name, "#") {
+			arg.Name = replHidden(arg.Name)
+		}
+		var rule string
+		if arg.Flavor == FLAVOR_TABLE {
+			rule = "repeated "
+		}
+		fRule, typ, pOpts, err := protoField(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", msgName, err)
+		}
+		if fRule != "" {
+			rule = fRule
+		}
+		f := descriptor.FieldDescriptorProto{
+			Name: proto.String(arg.Name), JsonName: proto.String(protoJSONName(arg.Name)),
+			Number: proto.Int32(int32(i + 1)), Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if rule != "" {
+			f.Label = descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+		simple := arg.Flavor == FLAVOR_SIMPLE || arg.Flavor == FLAVOR_TABLE && arg.TableOf.Flavor == FLAVOR_SIMPLE
+		if simple {
+			if doc := D.Map[arg.Name]; doc != "" {
+				d.comment(&f, doc+"\n"+arg.AbsType)
+			} else {
+				d.comment(&f, arg.AbsType)
+			}
+		} else {
+			typ = CamelCase(typ)
+			if _, ok := seen[typ]; !ok {
+				seen[typ] = struct{}{}
+				msgs, err := d.messages(typ, seen, argDocs{Pre: D.Map[arg.Name]}, nil, protoRecordArgs(arg)...)
+				if err != nil {
+					return nil, err
+				}
+				messages = append(messages, msgs...)
+			}
+			if tagged != nil {
+				if batch := tagged(arg); batch != "" {
+					messages = append(messages, d.cursorBatch(batch, typ))
+					f.TypeName = proto.String(d.typeName(batch))
+					f.Type = descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+					f.Label = descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+					f.OneofIndex = proto.Int32(0)
+					d.comment(&f, D.Map[arg.Name])
+					oneof = append(oneof, &f)
+					continue
+				}
+			}
+		}
+		if rule == "map" {
+			// map<string, V> is a repeated field of the nested KEntry { string key = 1; V value = 2; } message
+			jn := f.GetJsonName()
+			entry := descriptor.DescriptorProto{
+				Name:    proto.String(strings.ToUpper(jn[:1]) + jn[1:] + "Entry"),
+				Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+			}
+			key := descriptor.FieldDescriptorProto{
+				Name: proto.String("key"), JsonName: proto.String("key"), Number: proto.Int32(1),
+				Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:  descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}
+			value := descriptor.FieldDescriptorProto{
+				Name: proto.String("value"), JsonName: proto.String("value"), Number: proto.Int32(2),
+				Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}
+			if err := d.setType(&value, typ, simple); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", msgName, arg.Name, err)
+			}
+			entry.Field = []*descriptor.FieldDescriptorProto{&key, &value}
+			m.NestedType = append(m.NestedType, &entry)
+			f.TypeName = proto.String(d.typeName(msgName + "." + entry.GetName()))
+			f.Type = descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		} else if err := d.setType(&f, typ, simple); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", msgName, arg.Name, err)
+		}
+		if len(pOpts) != 0 {
+			if f.Options, err = protoFieldOptions(pOpts); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", msgName, arg.Name, err)
+			}
+			d.gogo = true
+		}
+		m.Field = append(m.Field, &f)
+	}
+	if len(oneof) != 0 {
+		m.OneofDecl = []*descriptor.OneofDescriptorProto{{Name: proto.String(cursorOneof)}}
+		m.Field = append(m.Field, oneof...)
+	}
+	return messages, nil
+}
+
+// setType sets the type of the field - a scalar, a well-known or a record message of this file.
+func (d *protoDesc) setType(f *descriptor.FieldDescriptorProto, typ string, simple bool) error {
+	if t, ok := protoScalarTypes[typ]; ok {
+		f.Type = t.Enum()
+		return nil
+	}
+	switch typ {
+	case "google.protobuf.Timestamp":
+		d.usesTimestamp = true
+		f.TypeName = proto.String("." + typ)
+	case "google.protobuf.Duration":
+		d.usesDuration = true
+		f.TypeName = proto.String("." + typ)
+	default:
+		if simple {
+			return fmt.Errorf("unknown type %q", typ)
+		}
+		f.TypeName = proto.String(d.typeName(typ))
+	}
+	f.Type = descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	return nil
+}
+
+// cursorBatch returns the message of the batches of a tagged cursor, with rows of typ.
+func (d *protoDesc) cursorBatch(batchName, typ string) *descriptor.DescriptorProto {
+	m := descriptor.DescriptorProto{
+		Name: proto.String(batchName),
+		Field: []*descriptor.FieldDescriptorProto{
+			{
+				Name: proto.String("rows"), JsonName: proto.String("rows"), Number: proto.Int32(1),
+				Label:    descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(d.typeName(typ)),
+			},
+			{
+				Name: proto.String("last"), JsonName: proto.String("last"), Number: proto.Int32(2),
+				Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:  descriptor.FieldDescriptorProto_TYPE_BOOL.Enum(),
+			},
+		},
+	}
+	d.comment(m.Field[1], "the last batch of the cursor")
+	return &m
+}
+
+// protoFieldOptions returns the options as the registered (gogoproto) extensions of FieldOptions.
+func protoFieldOptions(opts protoOptions) (*descriptor.FieldOptions, error) {
+	var fo descriptor.FieldOptions
+	exts := proto.RegisteredExtensions(&fo)
+	for k, v := range opts {
+		var ext *proto.ExtensionDesc
+		for _, e := range exts {
+			if e.Name == k {
+				ext = e
+				break
+			}
+		}
+		if ext == nil {
+			return nil, fmt.Errorf("unknown option %q", k)
+		}
+		switch x := v.(type) {
+		case string:
+			v = proto.String(x)
+		case bool:
+			v = proto.Bool(x)
+		}
+		if err := proto.SetExtension(&fo, ext, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return &fo, nil
+}
+
+// protoOptionsOf returns the registered (gogoproto) extensions set in the field options.
+func protoOptionsOf(fo *descriptor.FieldOptions) protoOptions {
+	if fo == nil {
+		return nil
+	}
+	opts := make(protoOptions)
+	for _, ext := range proto.RegisteredExtensions(fo) {
+		if !proto.HasExtension(fo, ext) {
+			continue
+		}
+		v, err := proto.GetExtension(fo, ext)
+		if err != nil {
+			continue
+		}
+		switch x := v.(type) {
+		case *string:
+			opts[ext.Name] = *x
+		case *bool:
+			opts[ext.Name] = *x
+		}
+	}
+	return opts
+}
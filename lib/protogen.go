@@ -0,0 +1,224 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	_ "github.com/gogo/protobuf/types" // registers google/protobuf/timestamp.proto and duration.proto
+	"github.com/gogo/protobuf/vanity"
+
+	// the plugins of protoc-gen-gofast, registered by their init
+	_ "github.com/gogo/protobuf/plugin/compare"
+	_ "github.com/gogo/protobuf/plugin/defaultcheck"
+	_ "github.com/gogo/protobuf/plugin/description"
+	_ "github.com/gogo/protobuf/plugin/embedcheck"
+	_ "github.com/gogo/protobuf/plugin/enumstringer"
+	_ "github.com/gogo/protobuf/plugin/equal"
+	_ "github.com/gogo/protobuf/plugin/face"
+	_ "github.com/gogo/protobuf/plugin/gostring"
+	_ "github.com/gogo/protobuf/plugin/marshalto"
+	_ "github.com/gogo/protobuf/plugin/oneofcheck"
+	_ "github.com/gogo/protobuf/plugin/populate"
+	_ "github.com/gogo/protobuf/plugin/size"
+	_ "github.com/gogo/protobuf/plugin/stringer"
+	_ "github.com/gogo/protobuf/plugin/union"
+	_ "github.com/gogo/protobuf/plugin/unmarshal"
+	_ "github.com/gogo/protobuf/protoc-gen-gogo/grpc"
+)
+
+// ProtoGoParameter is the parameter of the Go code generator,
+// the same as protoc's --go_out=<parameter>:<dir>.
+var ProtoGoParameter = "Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types," +
+	"Mgoogle/protobuf/duration.proto=github.com/gogo/protobuf/types,plugins=grpc"
+
+// builtinProtos are the imports GenerateProtoGo knows without their descriptors,
+// with the Go package of each.
+var builtinProtos = map[string]string{
+	"google/protobuf/descriptor.proto": "github.com/gogo/protobuf/protoc-gen-gogo/descriptor",
+	"gogo.proto":                       "github.com/gogo/protobuf/gogoproto",
+	"google/protobuf/timestamp.proto":  "github.com/gogo/protobuf/types",
+	"google/protobuf/duration.proto":   "github.com/gogo/protobuf/types",
+}
+
+// gogoMu serializes the gogo generators, as their plugins are the package state of the generator package.
+var gogoMu sync.Mutex
+
+// GenerateProtoGo generates the Go code of the messages and the gRPC services
+// of the files, as protoc with protoc-gen-gofast would, but in-process.
+//
+// protos are the descriptors of the files (see ProtoDescriptorService and ProtoDescriptorCommon),
+// and of their imports, except the well-known ones.
+// The names of the returned generated files are relative to the names of the files.
+//
+// The gRPC plugin is always on, whatever "plugins=" the parameter has.
+func GenerateProtoGo(parameter string, protos []*descriptor.FileDescriptorProto, files ...string) (map[string][]byte, error) {
+	protos, err := protoRequestFiles(protos, files)
+	if err != nil {
+		return nil, err
+	}
+	for _, fd := range protos {
+		if _, ok := builtinProtos[fd.GetName()]; ok {
+			continue
+		}
+		// the same as protoc-gen-gofast
+		vanity.TurnOffGogoImport(fd)
+		vanity.TurnOnMarshalerAll(fd)
+		vanity.TurnOnSizerAll(fd)
+		vanity.TurnOnUnmarshalerAll(fd)
+	}
+
+	// This is vanity/command.Generate without the test generation, which would replace
+	// the registered plugins with its own for good. The registered plugins are filtered
+	// by the "plugins=" parameter for good, too, so it is always the gogo plugins and grpc
+	// - all the imported ones -, to keep them for the next call.
+	params := strings.Split(parameter, ",")
+	for i := len(params) - 1; i >= 0; i-- {
+		if params[i] == "" || strings.HasPrefix(params[i], "plugins=") {
+			params = append(params[:i], params[i+1:]...)
+		}
+	}
+	gogoMu.Lock()
+	defer gogoMu.Unlock()
+	g := generator.New()
+	g.Request = &plugin.CodeGeneratorRequest{
+		FileToGenerate: files,
+		Parameter:      proto.String(strings.Join(append(params, "plugins=grpc"), ",")),
+		ProtoFile:      protos,
+	}
+	g.CommandLineParameters(g.Request.GetParameter())
+	g.WrapTypes()
+	g.SetPackageNames()
+	g.BuildTypeNameMap()
+	g.GenerateAllFiles()
+	if g.Response.Error != nil {
+		return nil, fmt.Errorf("generate %q: %s", files, g.Response.GetError())
+	}
+	generated := make(map[string][]byte, len(g.Response.File))
+	for _, f := range g.Response.File {
+		b, err := format.Source([]byte(f.GetContent()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.GetName(), err)
+		}
+		generated[f.GetName()] = b
+	}
+	return generated, nil
+}
+
+// protoRequestFiles returns the copies of the descriptors of the files, and of their imports
+// (the well-known ones from the registry), the dependencies first.
+func protoRequestFiles(protos []*descriptor.FileDescriptorProto, files []string) ([]*descriptor.FileDescriptorProto, error) {
+	byName := make(map[string]*descriptor.FileDescriptorProto, len(protos))
+	for _, fd := range protos {
+		byName[fd.GetName()] = fd
+	}
+	var ordered []*descriptor.FileDescriptorProto
+	seen := make(map[string]bool)
+	var add func(name string) error
+	add = func(name string) error {
+		if done, ok := seen[name]; ok {
+			if !done {
+				return fmt.Errorf("%s: import cycle", name)
+			}
+			return nil
+		}
+		seen[name] = false
+		var fd *descriptor.FileDescriptorProto
+		if _, ok := builtinProtos[name]; ok {
+			var err error
+			if fd, err = builtinProto(name); err != nil {
+				return err
+			}
+		} else if fd = byName[name]; fd == nil {
+			return fmt.Errorf("%s: no descriptor", name)
+		} else {
+			fd = proto.Clone(fd).(*descriptor.FileDescriptorProto)
+		}
+		for _, dep := range fd.Dependency {
+			if err := add(dep); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		seen[name] = true
+		ordered = append(ordered, fd)
+		return nil
+	}
+	for _, name := range files {
+		if err := add(name); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// builtinProto returns the descriptor of the well-known proto file, as registered in the gogo registry.
+func builtinProto(name string) (*descriptor.FileDescriptorProto, error) {
+	registered := name
+	if name == "google/protobuf/descriptor.proto" {
+		registered = "descriptor.proto"
+	}
+	gz := proto.FileDescriptor(registered)
+	if gz == nil {
+		return nil, fmt.Errorf("%s is not registered", name)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	var fd descriptor.FileDescriptorProto
+	if err := proto.Unmarshal(b, &fd); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	fd.Name = proto.String(name)
+	if fd.Options == nil {
+		fd.Options = &descriptor.FileOptions{}
+	}
+	fd.Options.GoPackage = proto.String(builtinProtos[name])
+	return &fd, nil
+}
+
+var protoScalarTypes = map[string]descriptor.FieldDescriptorProto_Type{
+	"double": descriptor.FieldDescriptorProto_TYPE_DOUBLE, "float": descriptor.FieldDescriptorProto_TYPE_FLOAT,
+	"int64": descriptor.FieldDescriptorProto_TYPE_INT64, "uint64": descriptor.FieldDescriptorProto_TYPE_UINT64,
+	"int32": descriptor.FieldDescriptorProto_TYPE_INT32, "uint32": descriptor.FieldDescriptorProto_TYPE_UINT32,
+	"fixed64": descriptor.FieldDescriptorProto_TYPE_FIXED64, "fixed32": descriptor.FieldDescriptorProto_TYPE_FIXED32,
+	"sfixed64": descriptor.FieldDescriptorProto_TYPE_SFIXED64, "sfixed32": descriptor.FieldDescriptorProto_TYPE_SFIXED32,
+	"sint64": descriptor.FieldDescriptorProto_TYPE_SINT64, "sint32": descriptor.FieldDescriptorProto_TYPE_SINT32,
+	"bool": descriptor.FieldDescriptorProto_TYPE_BOOL, "string": descriptor.FieldDescriptorProto_TYPE_STRING,
+	"bytes": descriptor.FieldDescriptorProto_TYPE_BYTES,
+}
+
+// protoJSONName returns the lowerCamelCase JSON name of the field, as protoc does.
+func protoJSONName(name string) string {
+	var buf strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
@@ -0,0 +1,208 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// testProtos returns the common and the per-package proto files of the test snapshot's,
+// nestedRecordFunctions', mapFunctions', booleanFunctions', datetimeFunctions', lobFunctions', weakCursorFunctions' and implicitFunctions' functions, in the "pb" directory,
+// and their descriptors.
+func testProtos(t *testing.T) (fstest.MapFS, []*descriptor.FileDescriptorProto, []string) {
+	t.Helper()
+	functions, _, err := testSnapshot().Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions = append(functions, nestedRecordFunctions(t)...)
+	functions = append(functions, mapFunctions(t)...)
+	functions = append(functions, booleanFunctions(t)...)
+	functions = append(functions, datetimeFunctions(t)...)
+	functions = append(functions, lobFunctions(t)...)
+	functions = append(functions, weakCursorFunctions(t)...)
+	functions = append(functions, implicitFunctions(t)...)
+	const commonProto = "pb/snap_common.proto"
+	fsys := make(fstest.MapFS)
+	var buf strings.Builder
+	common, err := SaveProtobufCommon(&buf, functions, "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys[commonProto] = &fstest.MapFile{Data: []byte(buf.String())}
+	fd, _, err := ProtoDescriptorCommon(commonProto, functions, "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	protos := []*descriptor.FileDescriptorProto{fd}
+	files := []string{commonProto}
+	groups := make(map[string][]Function)
+	for _, f := range functions {
+		groups[f.Package] = append(groups[f.Package], f)
+	}
+	for pkg, funcs := range groups {
+		fn := "pb/" + strings.ToLower(pkg) + ".proto"
+		buf.Reset()
+		if err := SaveProtobufService(&buf, funcs, "snap", CamelCase(pkg), commonProto, common); err != nil {
+			t.Fatal(err)
+		}
+		fsys[fn] = &fstest.MapFile{Data: []byte(buf.String())}
+		fd, err := ProtoDescriptorService(fn, funcs, "snap", CamelCase(pkg), commonProto, common)
+		if err != nil {
+			t.Fatal(err)
+		}
+		protos = append(protos, fd)
+		files = append(files, fn)
+	}
+	sort.Strings(files)
+	return fsys, protos, files
+}
+
+// TestWriteProtoFile checks the text written from the descriptors: the maps, the options and the comments.
+func TestWriteProtoFile(t *testing.T) {
+	fsys, _, _ := testProtos(t)
+	for fn, want := range map[string][]string{
+		"pb/snap_common.proto": {"package snap;\n\nimport \"gogo.proto\";\n\nmessage TstTypes_InnerT_Ownr {\n"},
+		"pb/tst_map.proto":     {"\tmap<string, string> p_names = 1;\n", "\tmap<string, TstMap_RowT_Ownr> p_rows = 2;\n"},
+		"pb/tst_dt.proto": {
+			"import \"pb/snap_common.proto\";\nimport \"gogo.proto\";\nimport \"google/protobuf/timestamp.proto\";\nimport \"google/protobuf/duration.proto\";\n",
+			"\n\t// INTERVAL DAY TO SECOND\n\tgoogle.protobuf.Duration p_by = 2 [(gogoproto.nullable)=false, (gogoproto.stdduration)=true];\n",
+		},
+		"pb/tst_snap.proto": {
+			"\n// the record\nmessage TstSnap_RecT_Ownr {\n",
+			"\t//        - p_rec - the record\n",
+			"\trpc RecIn (RecIn_Input) returns (RecIn_Output) {}\n}\n",
+		},
+		"pb/tst_weak.proto": {"\trepeated ListPCurRow_TstWeak p_cur = 1;\n", "rpc List (List_Input) returns (stream List_Output) {}"},
+	} {
+		got := string(fsys[fn].Data)
+		for _, w := range want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: no %q in\n%s", fn, w, got)
+			}
+		}
+	}
+}
+
+// typeCheckGenerated parses the generated Go files, and type checks them (with the real dependencies)
+// unless testing.Short.
+func typeCheckGenerated(t *testing.T, generated map[string][]byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for fn, b := range generated {
+		f, err := parser.ParseFile(fset, path.Base(fn), b, 0)
+		if err != nil {
+			t.Fatalf("%s: %+v", fn, err)
+		}
+		parsed = append(parsed, f)
+	}
+	if testing.Short() {
+		return
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("pb", fset, parsed, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGenerateProtoGo(t *testing.T) {
+	_, protos, files := testProtos(t)
+	generated, err := GenerateProtoGo(ProtoGoParameter, protos, files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range files {
+		if gfn := strings.TrimSuffix(fn, ".proto") + ".pb.go"; generated[gfn] == nil {
+			t.Fatalf("%s is not generated (only %v)", gfn, generated)
+		}
+	}
+	for fn, want := range map[string][]string{
+		"pb/snap_common.pb.go": {"type TstTypes_OuterT_Ownr struct", "func (m *TstTypes_InnerT_Ownr) Marshal() ("},
+		"pb/tst_a.pb.go":       {"type TstTypes_OwnT_Ownr struct", "func RegisterTstAServer(", "func (m *RecA_Input) Unmarshal("},
+		"pb/tst_b.pb.go":       {"func RegisterTstBServer(", "type TstBClient interface"},
+		// the comments of the descriptor
+		"pb/tst_snap.pb.go": {"// the record\ntype TstSnap_RecT_Ownr struct"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(string(generated[fn]), w) {
+				t.Errorf("%s: no %q", fn, w)
+			}
+		}
+	}
+	typeCheckGenerated(t, generated)
+}
+
+// TestGenerateProtoGoAgain checks that the gogo plugins are the same for the next call.
+func TestGenerateProtoGoAgain(t *testing.T) {
+	_, protos, files := testProtos(t)
+	first, err := GenerateProtoGo(ProtoGoParameter, protos, files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// another parameter must not change the plugins of the next call, either
+	if _, err = GenerateProtoGo(strings.Replace(ProtoGoParameter, "plugins=grpc", "plugins=none", 1), protos, files[:1]...); err != nil {
+		t.Fatal(err)
+	}
+	second, err := GenerateProtoGo(ProtoGoParameter, protos, files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fn, b := range first {
+		if !bytes.Equal(second[fn], b) {
+			t.Errorf("%s: the second is different:\n%s", fn, second[fn])
+		}
+	}
+	typeCheckGenerated(t, second)
+}
+
+func TestGenerateProtoGoV2(t *testing.T) {
+	defer func(old bool) { ProtoAPIv2 = old }(ProtoAPIv2)
+	ProtoAPIv2 = true
+	fsys, protos, files := testProtos(t)
+	for _, fn := range files {
+		if s := string(fsys[fn].Data); strings.Contains(s, "gogo") {
+			t.Errorf("%s: gogo in APIv2 proto:\n%s", fn, s)
+		}
+	}
+
+	generated, err := GenerateProtoGoV2("example.com/pb;snap", protos, files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fn, want := range map[string][]string{
+		"example.com/pb/snap_common.pb.go":      {"type TstTypes_OuterT_Ownr struct", "protoimpl.MessageState"},
+		"example.com/pb/tst_a.pb.go":            {"When *timestamppb.Timestamp", `"google.golang.org/protobuf/types/known/timestamppb"`},
+		"example.com/pb/tst_a_grpc.pb.go":       {"func RegisterTstAServer(", "type TstAClient interface", "RecA(context.Context, *RecA_Input) (*RecA_Output, error)"},
+		"example.com/pb/snap_common_grpc.pb.go": nil,
+	} {
+		b, ok := generated[fn]
+		if want == nil {
+			if ok {
+				t.Errorf("%s is generated", fn)
+			}
+			continue
+		}
+		for _, w := range want {
+			if !strings.Contains(string(b), w) {
+				t.Errorf("%s: no %q", fn, w)
+			}
+		}
+	}
+	typeCheckGenerated(t, generated)
+}
@@ -0,0 +1,347 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	gogodescriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// ProtoAPIv2 makes SaveProtobuf*, the generated functions and GenerateProtoGo
+// target the google.golang.org/protobuf (APIv2) messages:
+// dates are google.protobuf.Timestamp fields (*timestamppb.Timestamp in Go),
+// day to second intervals are google.protobuf.Duration fields (*durationpb.Duration),
+// without any gogoproto option, and the gRPC services are generated
+// as protoc-gen-go-grpc would.
+var ProtoAPIv2 bool
+
+// v2GoPackages are the Go packages of the builtinProtos for APIv2.
+var v2GoPackages = map[string]string{
+	"google/protobuf/descriptor.proto": "google.golang.org/protobuf/types/descriptorpb",
+	"gogo.proto":                       "github.com/gogo/protobuf/gogoproto",
+	"google/protobuf/timestamp.proto":  "google.golang.org/protobuf/types/known/timestamppb",
+	"google/protobuf/duration.proto":   "google.golang.org/protobuf/types/known/durationpb",
+}
+
+// GenerateProtoGoV2 generates the APIv2 Go code of the messages (as protoc-gen-go would)
+// and the gRPC services (as protoc-gen-go-grpc would) of the .proto files, in-process.
+//
+// The files are in the goImportPath Go package (optionally followed by ";" and the package name).
+// Otherwise it works as GenerateProtoGo.
+func GenerateProtoGoV2(goImportPath string, protos []*gogodescriptor.FileDescriptorProto, files ...string) (map[string][]byte, error) {
+	protos, err := protoRequestFiles(protos, files)
+	if err != nil {
+		return nil, err
+	}
+	req := pluginpb.CodeGeneratorRequest{FileToGenerate: files}
+	for _, fd := range protos {
+		// from gogo's descriptor to APIv2's
+		b, err := gogoproto.Marshal(fd)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fd.GetName(), err)
+		}
+		var v2 descriptorpb.FileDescriptorProto
+		if err = proto.Unmarshal(b, &v2); err != nil {
+			return nil, fmt.Errorf("%s: %w", fd.GetName(), err)
+		}
+		if v2.Options == nil {
+			v2.Options = &descriptorpb.FileOptions{}
+		}
+		if goPkg, ok := v2GoPackages[v2.GetName()]; ok {
+			v2.Options.GoPackage = proto.String(goPkg)
+		} else if v2.Options.GoPackage == nil {
+			v2.Options.GoPackage = proto.String(goImportPath)
+		}
+		req.ProtoFile = append(req.ProtoFile, &v2)
+	}
+
+	gen, err := protogen.Options{}.New(&req)
+	if err != nil {
+		return nil, err
+	}
+	gen.SupportedFeatures = internal_gengo.SupportedFeatures
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		internal_gengo.GenerateFile(gen, f)
+		if err := generateGRPC(gen, f); err != nil {
+			return nil, err
+		}
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		return nil, fmt.Errorf("generate %q: %s", files, resp.GetError())
+	}
+	generated := make(map[string][]byte, len(resp.File))
+	for _, f := range resp.File {
+		generated[f.GetName()] = []byte(f.GetContent())
+	}
+	return generated, nil
+}
+
+var (
+	contextPackage = protogen.GoImportPath("context")
+	grpcPackage    = protogen.GoImportPath("google.golang.org/grpc")
+	codesPackage   = protogen.GoImportPath("google.golang.org/grpc/codes")
+	statusPackage  = protogen.GoImportPath("google.golang.org/grpc/status")
+)
+
+// generateGRPC writes the file's services into <name>_grpc.pb.go, the same as protoc-gen-go-grpc
+// with require_unimplemented_servers=false, for the grpc version this module uses.
+func generateGRPC(gen *protogen.Plugin, file *protogen.File) error {
+	if len(file.Services) == 0 {
+		return nil
+	}
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+"_grpc.pb.go", file.GoImportPath)
+	g.P("// Code generated by gen-o-call. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	g.P("// This is a compile-time assertion to ensure that this generated file")
+	g.P("// is compatible with the grpc package it is being compiled against.")
+	g.P("const _ = ", grpcPackage.Ident("SupportPackageIsVersion7"))
+	g.P()
+	for _, service := range file.Services {
+		grpcService(g, file, service)
+	}
+	return nil
+}
+
+func grpcService(g *protogen.GeneratedFile, file *protogen.File, service *protogen.Service) {
+	svc := service.GoName
+	clientName, serverName, descName := svc+"Client", svc+"Server", svc+"_ServiceDesc"
+	clientImpl := unexportName(clientName)
+	fullMethod := func(m *protogen.Method) string {
+		return strconv.Quote("/" + string(service.Desc.FullName()) + "/" + string(m.Desc.Name()))
+	}
+	ctx := g.QualifiedGoIdent(contextPackage.Ident("Context"))
+
+	// client
+	g.P("// ", clientName, " is the client API for ", svc, " service.")
+	g.P("type ", clientName, " interface {")
+	for _, m := range service.Methods {
+		g.P(m.Comments.Leading, grpcClientSignature(g, m))
+	}
+	g.P("}")
+	g.P()
+	g.P("type ", clientImpl, " struct {")
+	g.P("cc ", grpcPackage.Ident("ClientConnInterface"))
+	g.P("}")
+	g.P()
+	g.P("func New", clientName, "(cc ", grpcPackage.Ident("ClientConnInterface"), ") ", clientName, " {")
+	g.P("return &", clientImpl, "{cc}")
+	g.P("}")
+	g.P()
+	var streamIndex int
+	for _, m := range service.Methods {
+		g.P("func (c *", clientImpl, ") ", grpcClientSignature(g, m), " {")
+		if !isStreaming(m) {
+			g.P("out := new(", m.Output.GoIdent, ")")
+			g.P("err := c.cc.Invoke(ctx, ", fullMethod(m), ", in, out, opts...)")
+			g.P("if err != nil { return nil, err }")
+			g.P("return out, nil")
+			g.P("}")
+			g.P()
+			continue
+		}
+		streamType := unexportName(svc) + m.GoName + "Client"
+		g.P("stream, err := c.cc.NewStream(ctx, &", descName, ".Streams[", streamIndex, "], ", fullMethod(m), ", opts...)")
+		g.P("if err != nil { return nil, err }")
+		g.P("x := &", streamType, "{stream}")
+		if !m.Desc.IsStreamingClient() {
+			g.P("if err := x.ClientStream.SendMsg(in); err != nil { return nil, err }")
+			g.P("if err := x.ClientStream.CloseSend(); err != nil { return nil, err }")
+		}
+		g.P("return x, nil")
+		g.P("}")
+		g.P()
+		g.P("type ", svc, "_", m.GoName, "Client interface {")
+		if m.Desc.IsStreamingClient() {
+			g.P("Send(*", m.Input.GoIdent, ") error")
+		}
+		if m.Desc.IsStreamingServer() {
+			g.P("Recv() (*", m.Output.GoIdent, ", error)")
+		} else {
+			g.P("CloseAndRecv() (*", m.Output.GoIdent, ", error)")
+		}
+		g.P(grpcPackage.Ident("ClientStream"))
+		g.P("}")
+		g.P()
+		g.P("type ", streamType, " struct {")
+		g.P(grpcPackage.Ident("ClientStream"))
+		g.P("}")
+		g.P()
+		if m.Desc.IsStreamingClient() {
+			g.P("func (x *", streamType, ") Send(m *", m.Input.GoIdent, ") error {")
+			g.P("return x.ClientStream.SendMsg(m)")
+			g.P("}")
+			g.P()
+		}
+		if m.Desc.IsStreamingServer() {
+			g.P("func (x *", streamType, ") Recv() (*", m.Output.GoIdent, ", error) {")
+		} else {
+			g.P("func (x *", streamType, ") CloseAndRecv() (*", m.Output.GoIdent, ", error) {")
+			g.P("if err := x.ClientStream.CloseSend(); err != nil { return nil, err }")
+		}
+		g.P("m := new(", m.Output.GoIdent, ")")
+		g.P("if err := x.ClientStream.RecvMsg(m); err != nil { return nil, err }")
+		g.P("return m, nil")
+		g.P("}")
+		g.P()
+		streamIndex++
+	}
+
+	// server
+	g.P("// ", serverName, " is the server API for ", svc, " service.")
+	g.P("type ", serverName, " interface {")
+	for _, m := range service.Methods {
+		g.P(m.Comments.Leading, grpcServerSignature(g, m))
+	}
+	g.P("}")
+	g.P()
+	g.P("// Unimplemented", serverName, " can be embedded to have forward compatible implementations.")
+	g.P("type Unimplemented", serverName, " struct {}")
+	g.P()
+	for _, m := range service.Methods {
+		nilArg := "nil, "
+		if isStreaming(m) {
+			nilArg = ""
+		}
+		g.P("func (Unimplemented", serverName, ") ", grpcServerSignature(g, m), " {")
+		g.P("return ", nilArg, statusPackage.Ident("Errorf"), "(", codesPackage.Ident("Unimplemented"), `, "method `, m.GoName, ` not implemented")`)
+		g.P("}")
+	}
+	g.P()
+	g.P("func Register", serverName, "(s ", grpcPackage.Ident("ServiceRegistrar"), ", srv ", serverName, ") {")
+	g.P("s.RegisterService(&", descName, ", srv)")
+	g.P("}")
+	g.P()
+	for _, m := range service.Methods {
+		handler := "_" + svc + "_" + m.GoName + "_Handler"
+		if !isStreaming(m) {
+			g.P("func ", handler, "(srv interface{}, ctx ", ctx, ", dec func(interface{}) error, interceptor ", grpcPackage.Ident("UnaryServerInterceptor"), ") (interface{}, error) {")
+			g.P("in := new(", m.Input.GoIdent, ")")
+			g.P("if err := dec(in); err != nil { return nil, err }")
+			g.P("if interceptor == nil { return srv.(", serverName, ").", m.GoName, "(ctx, in) }")
+			g.P("info := &", grpcPackage.Ident("UnaryServerInfo"), "{")
+			g.P("Server: srv,")
+			g.P("FullMethod: ", fullMethod(m), ",")
+			g.P("}")
+			g.P("handler := func(ctx ", ctx, ", req interface{}) (interface{}, error) {")
+			g.P("return srv.(", serverName, ").", m.GoName, "(ctx, req.(*", m.Input.GoIdent, "))")
+			g.P("}")
+			g.P("return interceptor(ctx, in, info, handler)")
+			g.P("}")
+			g.P()
+			continue
+		}
+		streamType := unexportName(svc) + m.GoName + "Server"
+		g.P("func ", handler, "(srv interface{}, stream ", grpcPackage.Ident("ServerStream"), ") error {")
+		if m.Desc.IsStreamingClient() {
+			g.P("return srv.(", serverName, ").", m.GoName, "(&", streamType, "{stream})")
+		} else {
+			g.P("m := new(", m.Input.GoIdent, ")")
+			g.P("if err := stream.RecvMsg(m); err != nil { return err }")
+			g.P("return srv.(", serverName, ").", m.GoName, "(m, &", streamType, "{stream})")
+		}
+		g.P("}")
+		g.P()
+		g.P("type ", svc, "_", m.GoName, "Server interface {")
+		if m.Desc.IsStreamingServer() {
+			g.P("Send(*", m.Output.GoIdent, ") error")
+		} else {
+			g.P("SendAndClose(*", m.Output.GoIdent, ") error")
+		}
+		if m.Desc.IsStreamingClient() {
+			g.P("Recv() (*", m.Input.GoIdent, ", error)")
+		}
+		g.P(grpcPackage.Ident("ServerStream"))
+		g.P("}")
+		g.P()
+		g.P("type ", streamType, " struct {")
+		g.P(grpcPackage.Ident("ServerStream"))
+		g.P("}")
+		g.P()
+		if m.Desc.IsStreamingServer() {
+			g.P("func (x *", streamType, ") Send(m *", m.Output.GoIdent, ") error {")
+		} else {
+			g.P("func (x *", streamType, ") SendAndClose(m *", m.Output.GoIdent, ") error {")
+		}
+		g.P("return x.ServerStream.SendMsg(m)")
+		g.P("}")
+		g.P()
+		if m.Desc.IsStreamingClient() {
+			g.P("func (x *", streamType, ") Recv() (*", m.Input.GoIdent, ", error) {")
+			g.P("m := new(", m.Input.GoIdent, ")")
+			g.P("if err := x.ServerStream.RecvMsg(m); err != nil { return nil, err }")
+			g.P("return m, nil")
+			g.P("}")
+			g.P()
+		}
+	}
+
+	g.P("// ", descName, " is the grpc.ServiceDesc for ", svc, " service.")
+	g.P("var ", descName, " = ", grpcPackage.Ident("ServiceDesc"), "{")
+	g.P("ServiceName: ", strconv.Quote(string(service.Desc.FullName())), ",")
+	g.P("HandlerType: (*", serverName, ")(nil),")
+	g.P("Methods: []", grpcPackage.Ident("MethodDesc"), "{")
+	for _, m := range service.Methods {
+		if !isStreaming(m) {
+			g.P("{MethodName: ", strconv.Quote(string(m.Desc.Name())), ", Handler: _", svc, "_", m.GoName, "_Handler},")
+		}
+	}
+	g.P("},")
+	g.P("Streams: []", grpcPackage.Ident("StreamDesc"), "{")
+	for _, m := range service.Methods {
+		if isStreaming(m) {
+			g.P("{StreamName: ", strconv.Quote(string(m.Desc.Name())), ", Handler: _", svc, "_", m.GoName, "_Handler",
+				", ServerStreams: ", m.Desc.IsStreamingServer(), ", ClientStreams: ", m.Desc.IsStreamingClient(), "},")
+		}
+	}
+	g.P("},")
+	g.P("Metadata: ", strconv.Quote(file.Desc.Path()), ",")
+	g.P("}")
+}
+
+// isStreaming reports whether the method is a streaming one, of either direction.
+func isStreaming(m *protogen.Method) bool {
+	return m.Desc.IsStreamingClient() || m.Desc.IsStreamingServer()
+}
+
+func grpcClientSignature(g *protogen.GeneratedFile, m *protogen.Method) string {
+	s := m.GoName + "(ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	if !m.Desc.IsStreamingClient() {
+		s += ", in *" + g.QualifiedGoIdent(m.Input.GoIdent)
+	}
+	s += ", opts ..." + g.QualifiedGoIdent(grpcPackage.Ident("CallOption")) + ") ("
+	if isStreaming(m) {
+		return s + m.Parent.GoName + "_" + m.GoName + "Client, error)"
+	}
+	return s + "*" + g.QualifiedGoIdent(m.Output.GoIdent) + ", error)"
+}
+
+func grpcServerSignature(g *protogen.GeneratedFile, m *protogen.Method) string {
+	if m.Desc.IsStreamingClient() {
+		return m.GoName + "(" + m.Parent.GoName + "_" + m.GoName + "Server) error"
+	}
+	if m.Desc.IsStreamingServer() {
+		return m.GoName + "(*" + g.QualifiedGoIdent(m.Input.GoIdent) + ", " + m.Parent.GoName + "_" + m.GoName + "Server) error"
+	}
+	return m.GoName + "(" + g.QualifiedGoIdent(contextPackage.Ident("Context")) +
+		", *" + g.QualifiedGoIdent(m.Input.GoIdent) + ") (*" + g.QualifiedGoIdent(m.Output.GoIdent) + ", error)"
+}
+
+func unexportName(s string) string { return strings.ToLower(s[:1]) + s[1:] }
@@ -60,6 +60,9 @@ type UserArgument struct {
      FROM user_arguments
      ORDER BY object_id, subprogram_id, SEQUENCE;
 */
+//
+// Deprecated: such an export has the arguments only, without the resolved types,
+// annotations and documentation. Use a Snapshot (see ReadSnapshot and ReadSnapshotFile) instead.
 func ParseCsvFile(filename string, filter func(string) bool) (functions []Function, err error) {
 	fh, err := OpenCsv(filename)
 	if err != nil {
@@ -70,6 +73,8 @@ func ParseCsvFile(filename string, filter func(string) bool) (functions []Functi
 }
 
 // ParseCsv parses the csv
+//
+// Deprecated: use a Snapshot instead, see ParseCsvFile.
 func ParseCsv(r io.Reader, filter func(string) bool) (functions []Function, err error) {
 	userArgs, err := ReadCsv(r)
 	if err != nil {
@@ -134,6 +139,9 @@ func MustOpenCsv(filename string) *os.File {
 }
 
 // ReadCsv reads the csv from the Reader, and sends the arguments to the given channel.
+//
+// The returned arguments can be put into a Snapshot's Arguments,
+// to be completed with the package sources and types.
 func ReadCsv(r io.Reader) (userArgs []UserArgument, err error) {
 	br := bufio.NewReader(r)
 	csvr := csv.NewReader(br)
@@ -253,7 +261,7 @@ func ParseArguments(userArgs [][]UserArgument, filter func(string) bool, types m
 				ua.DataPrecision,
 				ua.DataScale,
 				ua.CharLength,
-				types[TypeName{Owner: ua.TypeOwner, Package: ua.TypeName, Name: ua.TypeSubname}],
+				types[argTypeName(ua.TypeOwner, ua.TypeName, ua.TypeSubname)],
 			)
 			log.Println(arg)
 			//Log("level", level, "arg", arg.Name, "type", ua.DataType, "last", lastArgs, "flavor", arg.Flavor)
@@ -285,6 +293,10 @@ func ParseArguments(userArgs [][]UserArgument, filter func(string) bool, types m
 		//Log("args", lastArgs[-1].RecordOf)
 		for i, na := range lastArgs[-1].RecordOf {
 			fun.Args[i] = *na.Argument
+			fun.Args[i].fillFromType()
+		}
+		if fun.Returns != nil {
+			fun.Returns.fillFromType()
 		}
 		//Log("args", fun.Args)
 		functions = append(functions, fun)
@@ -340,6 +352,9 @@ func (a Annotation) FullOther() string {
 	return a.Package + "." + a.Other
 }
 func (a Annotation) String() string {
+	if a.Type == "stateful" {
+		return a.Type + " " + a.Package
+	}
 	if a.Type == "" || a.Name == "" {
 		return ""
 	}
@@ -348,6 +363,10 @@ func (a Annotation) String() string {
 		return a.Type + " " + a.FullName()
 	case "max-table-size":
 		return fmt.Sprintf("%s.MaxTableSize=%d", a.FullName(), a.Size)
+	case "batch-size":
+		return fmt.Sprintf("%s.BatchSize=%d", a.FullName(), a.Size)
+	case "cursor-columns", "cursor-query", "implicit-results":
+		return a.Type + " " + a.FullName() + "=>" + a.Other
 	}
 	return a.Type + " " + a.FullName() + "=>" + a.FullOther()
 }
@@ -363,13 +382,30 @@ func ApplyAnnotations(functions []Function, annotations []Annotation) []Function
 		funcs[L(f.RealName())] = &f
 	}
 	for _, a := range annotations {
+		// the output of the package changes with any of its annotations
+		if s := a.String(); s != "" && a.Package != "" {
+			for _, f := range funcs {
+				if strings.EqualFold(f.Package, a.Package) {
+					f.annotations = append(f.annotations, s)
+				}
+			}
+		}
+		if a.Type == "stateful" {
+			// pin the calls of ALL functions in the package to a session
+			for _, f := range funcs {
+				if strings.EqualFold(f.Package, a.Package) {
+					f.stateful = true
+				}
+			}
+			continue
+		}
 		if a.Name == "" || a.Type == "" {
 			continue
 		}
-		if a.Other == "" && !(a.Type == "private" || a.Type == "handle" || a.Type == "max-table-size") {
+		if a.Other == "" && !(a.Type == "private" || a.Type == "handle" || a.Type == "max-table-size" || a.Type == "batch-size") {
 			continue
 		}
-		if a.Size <= 0 && a.Type == "max-table-size" {
+		if a.Size <= 0 && (a.Type == "max-table-size" || a.Type == "batch-size") {
 			continue
 		}
 		switch a.Type {
@@ -414,11 +450,44 @@ func ApplyAnnotations(functions []Function, annotations []Annotation) []Function
 			if f := funcs[nm]; f != nil && a.Size >= f.maxTableSize {
 				f.maxTableSize = a.Size
 			}
+
+		case "batch-size":
+			nm := L(a.FullName())
+			logger.Debug("batch-size", "name", nm, "size", a.Size)
+			if f := funcs[nm]; f != nil {
+				f.batchSize = a.Size
+			}
+
+		case "cursor-columns":
+			fn, argName, _ := strings.Cut(L(a.Name), ".")
+			if f := funcs[L(a.Package)+"."+fn]; f != nil {
+				if err := f.setCursorColumns(argName, a.Other); err != nil {
+					logger.Warn("cursor-columns", "name", a.FullName(), "error", err)
+				}
+			}
 		}
 	}
 	functions = functions[:0]
 	for _, f := range funcs {
 		functions = append(functions, *f)
 	}
+	return qualifyNames(functions)
+}
+
+// qualifyNames prefixes the (aliased) names of the functions with their package,
+// if the same name is in more than one package: all packages share the messages
+// and the server, so their names must not collide.
+func qualifyNames(functions []Function) []Function {
+	pkgs := make(map[string]map[string]struct{}, len(functions))
+	for _, f := range functions {
+		nm := strings.ToLower(f.shortName())
+		if pkgs[nm] == nil {
+			pkgs[nm] = make(map[string]struct{}, 1)
+		}
+		pkgs[nm][strings.ToLower(f.Package)] = struct{}{}
+	}
+	for i, f := range functions {
+		functions[i].qualified = len(pkgs[strings.ToLower(f.shortName())]) > 1
+	}
 	return functions
 }
@@ -44,28 +44,39 @@ func (t dbType) String() string {
 }
 
 func ReadDB(ctx context.Context, db querier, pattern string, filter func(string) bool) (functions []Function, annotations []Annotation, err error) {
+	snap, err := ReadSnapshot(ctx, db, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snap.Functions(ctx, filter)
+}
+
+// ReadSnapshot reads the arguments of the functions matching pattern,
+// with their types and their packages' sources.
+func ReadSnapshot(ctx context.Context, db querier, pattern string) (*Snapshot, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	const objTimeQry = `SELECT last_ddl_time FROM all_objects WHERE object_name = :1 AND object_type <> 'PACKAGE BODY'`
+	const objTimeQry = `SELECT last_ddl_time, owner FROM all_objects WHERE object_name = :1 AND object_type <> 'PACKAGE BODY'`
 
 	objTimeStmt, err := db.PrepareContext(ctx, objTimeQry)
 	if err != nil {
-		return nil, nil, errors.Errorf("%s: %w", objTimeQry, err)
+		return nil, errors.Errorf("%s: %w", objTimeQry, err)
 	}
 	defer objTimeStmt.Close()
 
-	getObjTime := func(name string) (time.Time, error) {
+	getObjTime := func(name string) (time.Time, string, error) {
 		var t time.Time
-		if err := objTimeStmt.QueryRowContext(ctx, name).Scan(&t); err != nil {
-			return t, errors.Errorf("%s [%q]: %w", objTimeQry, name, err)
+		var owner string
+		if err := objTimeStmt.QueryRowContext(ctx, name).Scan(&t, &owner); err != nil {
+			return t, owner, errors.Errorf("%s [%q]: %w", objTimeQry, name, err)
 		}
-		return t, nil
+		return t, owner, nil
 	}
 
 	tr, err := newTypeResolver(ctx, db)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	defer tr.Close()
 
@@ -78,14 +89,21 @@ func ReadDB(ctx context.Context, db querier, pattern string, filter func(string)
       ORDER BY 1, 2, 3, sequence`
 	rows, err := db.QueryContext(ctx, argumentsQry, sql.Named("pat", pattern))
 	if err != nil {
-		return nil, nil, errors.Errorf("%s: %w", argumentsQry, err)
+		return nil, errors.Errorf("%s: %w", argumentsQry, err)
 	}
 	defer rows.Close()
 
 	grp, grpCtx := errgroup.WithContext(ctx)
-	var annotPromises []<-chan []Annotation
-	var docPromises []<-chan map[string]string
-	userArgs := make([]UserArgument, 0, 1024)
+	snap := Snapshot{
+		Format: SnapshotFormat, Version: SnapshotVersion,
+		Pattern: pattern, Created: time.Now(),
+		Arguments: make([]UserArgument, 0, 1024),
+	}
+	const versionQry = `SELECT version FROM product_component_version WHERE product LIKE 'Oracle%' AND ROWNUM = 1`
+	if err = db.QueryRowContext(ctx, versionQry).Scan(&snap.ServerVersion); err != nil {
+		return nil, errors.Errorf("%s: %w", versionQry, err)
+	}
+	var packages []*SnapshotPackage
 	var prevPackage string
 	var pkgTime time.Time
 	for rows.Next() {
@@ -95,7 +113,7 @@ func ReadDB(ctx context.Context, db querier, pattern string, filter func(string)
 			&row.Data, &row.Prec, &row.Scale, &row.Charset,
 			&row.PLS, &row.Length, &row.Owner, &row.Name, &row.Subname, &row.Link,
 		); err != nil {
-			return nil, nil, errors.Errorf("reading row=%v: %w", rows, err)
+			return nil, errors.Errorf("reading row=%v: %w", rows, err)
 		}
 		var ua UserArgument
 		ua.DataType = row.Data
@@ -105,44 +123,31 @@ func ReadDB(ctx context.Context, db querier, pattern string, filter func(string)
 		}
 
 		switch row.Data {
-		case "OBJECT", "PL/SQL TABLE", "PL/SQL RECORD", "REF CURSOR", "TABLE":
+		case "OBJECT", "PL/SQL TABLE", "PL/SQL RECORD", "REF CURSOR", "TABLE", "VARRAY":
 			grp.Go(func() error {
-				return tr.Resolve(grpCtx, row.Data, TypeName{Owner: row.Owner, Package: row.Name, Name: row.Subname})
+				return tr.Resolve(grpCtx, row.Data, argTypeName(row.Owner, row.Name, row.Subname))
 			})
 		}
 
 		ua.PackageName = row.Package
 		if ua.PackageName != prevPackage {
 			prevPackage = ua.PackageName
-			if pkgTime, err = getObjTime(ua.PackageName); err != nil {
-				return nil, nil, err
+			var owner string
+			if pkgTime, owner, err = getObjTime(ua.PackageName); err != nil {
+				return nil, err
 			}
-			aCh := make(chan []Annotation, 1)
-			annotPromises = append(annotPromises, aCh)
-			dCh := make(chan map[string]string, 1)
-			docPromises = append(docPromises, dCh)
+			pkg := &SnapshotPackage{Name: ua.PackageName, Owner: owner}
+			packages = append(packages, pkg)
 
-			// read source and parse for annotations and documentation
+			// read source, to be parsed for annotations and documentation
 			grp.Go(func() error {
 				buf := Buffers.Get()
+				defer Buffers.Put(buf)
 				buf.Reset()
-				defer func() {
-					buf.Reset()
-					Buffers.Put(buf)
-					close(aCh)
-					close(dCh)
-				}()
-
-				if err := getSource(grpCtx, buf, db, ua.PackageName); err != nil {
-					return err
-				}
-
-				annotations, docs, err := ParseAnnotationsAndDocs(grpCtx, ua.PackageName, buf.String())
-				aCh <- annotations
-				dCh <- docs
-				if err != nil {
+				if err := getSource(grpCtx, buf, db, pkg.Name); err != nil {
 					return err
 				}
+				pkg.Source = buf.String()
 				return nil
 			})
 		}
@@ -187,7 +192,12 @@ func ReadDB(ctx context.Context, db querier, pattern string, filter func(string)
 			ua.CharLength = uint(row.Length.Int64)
 		}
 
-		userArgs = append(userArgs, ua)
+		snap.Arguments = append(snap.Arguments, ua)
+	}
+	if err == nil {
+		if err = rows.Err(); err != nil {
+			err = errors.Errorf("%s: %w", argumentsQry, err)
+		}
 	}
 	if grpErr := grp.Wait(); grpErr != nil {
 		if err == nil {
@@ -195,66 +205,42 @@ func ReadDB(ctx context.Context, db querier, pattern string, filter func(string)
 		}
 	}
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	for _, aCh := range annotPromises {
-		select {
-		case <-ctx.Done():
-			return functions, annotations, ctx.Err()
-		case annot := <-aCh:
-			annotations = append(annotations, annot...)
+	snap.Packages = make([]SnapshotPackage, len(packages))
+	for i, p := range packages {
+		snap.Packages[i] = *p
+		// the record types of the implicit results are not in the arguments
+		annotations, _, err := ParseAnnotationsAndDocs(ctx, p.Name, p.Source)
+		if err != nil {
+			return nil, errors.Errorf("%s: %w", p.Name, err)
 		}
-	}
-	filteredArgs, err := FilterAndGroup(userArgs, filter)
-	if err != nil {
-		return functions, annotations, err
-	}
-	if functions, err = ParseArguments(filteredArgs, filter, tr.Types()); err != nil {
-		return functions, annotations, err
-	}
-	funcs := make(map[string]int, len(functions))
-	for i, f := range functions {
-		funcs[f.FullName()] = i
-	}
-	for _, dCh := range docPromises {
-		select {
-		case <-ctx.Done():
-			return functions, annotations, ctx.Err()
-		case doc := <-dCh:
-			for k, s := range doc {
-				functions[funcs[k]].Documentation = s
+		for _, a := range annotations {
+			if a.Type != "implicit-results" {
+				continue
+			}
+			for _, nm := range strings.Split(a.Other, ",") {
+				if err = tr.Resolve(ctx, "PL/SQL RECORD", implicitTypeName(p.Owner, p.Name, nm)); err != nil {
+					return nil, errors.Errorf("%s: %w", a, err)
+				}
 			}
 		}
 	}
-	return functions, annotations, nil
+	snap.Types = flattenTypes(tr.Types())
+	return &snap, nil
 }
 
 func ParseAnnotationsAndDocs(ctx context.Context, packageName, src string) ([]Annotation, map[string]string, error) {
 	var annotations []Annotation
 	docs := make(map[string]string)
 	for _, b := range rAnnotation.FindAllString(src, -1) {
-		// FIXME(tgulacsi): --oracall:
-		b = strings.TrimSpace(b[strings.IndexByte(b, ':')+1:])
-		a := Annotation{Package: packageName}
-		if i := strings.IndexByte(b, ' '); i < 0 {
-			continue
-		} else {
-			a.Type, b = b[:i], b[i+1:]
+		a, err := parseAnnotation(packageName, b)
+		if err != nil {
+			return annotations, docs, err
 		}
-		if i := strings.Index(b, "=>"); i < 0 {
-			if i = strings.IndexByte(b, '='); i < 0 {
-				a.Name = strings.TrimSpace(b)
-			} else {
-				a.Name = strings.TrimSpace(b[:i])
-				var err error
-				if a.Size, err = strconv.Atoi(strings.TrimSpace(b[i+1:])); err != nil {
-					return annotations, docs, err
-				}
-			}
-		} else {
-			a.Name, a.Other = strings.TrimSpace(b[:i]), strings.TrimSpace(b[i+2:])
+		if a.Type != "" {
+			annotations = append(annotations, a)
 		}
-		annotations = append(annotations, a)
 	}
 	if len(annotations) != 0 {
 		src = rAnnotation.ReplaceAllString(src, "")
@@ -268,6 +254,37 @@ func ParseAnnotationsAndDocs(ctx context.Context, packageName, src string) ([]An
 	return annotations, docs, err
 }
 
+// parseAnnotation parses the annotation (as matched by rAnnotation) of the package.
+// The Type of the returned annotation is empty if b is not a valid annotation.
+func parseAnnotation(packageName, b string) (Annotation, error) {
+	// FIXME(tgulacsi): --oracall:
+	b = strings.TrimSpace(b[strings.IndexByte(b, ':')+1:])
+	a := Annotation{Package: packageName}
+	if b == "stateful" {
+		a.Type = b
+		return a, nil
+	}
+	if i := strings.IndexByte(b, ' '); i < 0 {
+		return Annotation{}, nil
+	} else {
+		a.Type, b = b[:i], b[i+1:]
+	}
+	if i := strings.Index(b, "=>"); i < 0 {
+		if i = strings.IndexByte(b, '='); i < 0 {
+			a.Name = strings.TrimSpace(b)
+		} else {
+			a.Name = strings.TrimSpace(b[:i])
+			var err error
+			if a.Size, err = strconv.Atoi(strings.TrimSpace(b[i+1:])); err != nil {
+				return a, err
+			}
+		}
+	} else {
+		a.Name, a.Other = strings.TrimSpace(b[:i]), strings.TrimSpace(b[i+2:])
+	}
+	return a, nil
+}
+
 func getSource(ctx context.Context, w io.Writer, cx querier, packageName string) error {
 	qry := "SELECT text FROM user_source WHERE name = UPPER(:1) AND type = 'PACKAGE' ORDER BY line"
 	rows, err := cx.QueryContext(ctx, qry, packageName)
@@ -290,7 +307,7 @@ func getSource(ctx context.Context, w io.Writer, cx querier, packageName string)
 	return nil
 }
 
-var rAnnotation = regexp.MustCompile(`--(oracall|gen-?o-?call):(?:(replace(_json)?|rename)\s+[a-zA-Z0-9_#]+\s*=>\s*[a-zA-Z0-9_#]+|(handle|private)\s+[a-zA-Z0-9_#]+|max-table-size\s+[a-zA-Z0-9_$]+\s*=\s*[0-9]+)`)
+var rAnnotation = regexp.MustCompile(`--(oracall|gen-?o-?call):(?:(replace(_json)?|rename)\s+[a-zA-Z0-9_#]+\s*=>\s*[a-zA-Z0-9_#]+|(handle|private)\s+[a-zA-Z0-9_#]+|stateful\b|(?:max-table|batch)-size\s+[a-zA-Z0-9_$]+\s*=\s*[0-9]+|implicit-results\s+[a-zA-Z0-9_#$]+\s*=>\s*[a-zA-Z0-9_#$.]+(?:\s*,\s*[a-zA-Z0-9_#$.]+)*|cursor-(?:columns|query)\s+[a-zA-Z0-9_#$]+\.[a-zA-Z0-9_#$]+\s*=>[^\n]+)`)
 
 type typeResolver struct {
 	db    querier
@@ -298,19 +315,48 @@ type typeResolver struct {
 	types map[TypeName]*PlsType
 }
 
+// Types returns the resolved types, by their name.
+//
+// The records' and objects' attributes of complex types are linked here
+// to the structure of their (by now resolved) type.
 func (tr *typeResolver) Types() map[TypeName]*PlsType {
 	tr.mu.Lock()
 	defer tr.mu.Unlock()
+	seen := make(map[*PlsType]struct{}, len(tr.types))
+	var link func(t *PlsType)
+	link = func(t *PlsType) {
+		if _, ok := seen[t]; ok {
+			return
+		}
+		seen[t] = struct{}{}
+		if t.CollectionOf == nil && len(t.RecordOf) == 0 && complexType(t.TypeCode) != "" {
+			if r := tr.types[t.TypeName]; r != nil && r != t {
+				t.TypeCode, t.CollectionOf, t.RecordOf = r.TypeCode, r.CollectionOf, r.RecordOf
+			}
+		}
+		if t.CollectionOf != nil {
+			link(t.CollectionOf)
+		}
+		for _, r := range t.RecordOf {
+			link(r)
+		}
+	}
+	for _, t := range tr.types {
+		link(t)
+	}
 	return tr.types
 }
 
 var trQueries = map[string]string{
 	"coll": `SELECT coll_type, elem_type_owner, elem_type_name, elem_type_package,
 			   length, precision, scale, character_set_name, index_by,
-			   (SELECT MIN(typecode) FROM all_plsql_types B
+			   NVL((SELECT MIN(typecode) FROM all_plsql_types B
 				  WHERE B.owner = A.elem_type_owner AND
 						B.type_name = A.elem_type_name AND
-						B.package_name = A.elem_type_package) typecode
+						B.package_name = A.elem_type_package),
+				 (SELECT MIN(typecode) FROM all_types B
+				  WHERE B.owner = A.elem_type_owner AND
+						B.type_name = A.elem_type_name)) typecode
 		  FROM all_plsql_coll_types A
 		  WHERE owner = :owner AND package_name = :pkg AND type_name = :sub
 		UNION
@@ -329,16 +375,21 @@ var trQueries = map[string]string{
 
 	"plsTyp": `SELECT attr_name, attr_type_owner, attr_type_name, attr_type_package,
 		  length, precision, scale, character_set_name,
-		  (SELECT MIN(typecode) FROM all_plsql_types B
-			 WHERE B.owner = A.attr_type_owner AND B.type_name = A.attr_type_name AND B.package_name = A.attr_type_package) typecode
+		  NVL((SELECT MIN(typecode) FROM all_plsql_types B
+			 WHERE B.owner = A.attr_type_owner AND B.type_name = A.attr_type_name AND B.package_name = A.attr_type_package),
+			(SELECT MIN(typecode) FROM all_types B
+			 WHERE B.owner = A.attr_type_owner AND B.type_name = A.attr_type_name)) typecode
 	 FROM all_plsql_type_attrs A
 	 WHERE owner = :owner AND package_name = :pkg AND type_name = :sub
 	 ORDER BY attr_no`,
 
-	"objTyp": `SELECT B.attr_name, B.ATTR_TYPE_NAME, B.PRECISION, B.scale, B.character_set_name,
-            NVL2(B.ATTR_TYPE_OWNER, B.attr_type_owner||'.', '')||B.attr_type_name, B.length
-       FROM all_type_attrs B
-	   WHERE B.owner = :owner AND B.type_name = :sub`,
+	"objTyp": `SELECT attr_name, attr_type_owner, attr_type_name, NULL attr_type_package,
+		  length, precision, scale, character_set_name,
+		  (SELECT MIN(typecode) FROM all_types B
+			 WHERE B.owner = A.attr_type_owner AND B.type_name = A.attr_type_name) typecode
+	 FROM all_type_attrs A
+	 WHERE owner = :owner AND type_name = :pkg
+	 ORDER BY attr_no`,
 }
 
 func newTypeResolver(ctx context.Context, db querier) (*typeResolver, error) {
@@ -350,6 +401,27 @@ func (tr *typeResolver) Close() error {
 	return nil
 }
 
+// argTypeName returns the name of an argument's type: the TYPE_NAME is the package
+// of a PL/SQL type (named TYPE_SUBNAME), but the name of an SQL type.
+func argTypeName(owner, name, subname string) TypeName {
+	if subname == "" {
+		return TypeName{Owner: owner, Name: name}
+	}
+	return TypeName{Owner: owner, Package: name, Name: subname}
+}
+
+// complexType returns the data type to Resolve the type of the typecode as,
+// or "" for a simple type.
+func complexType(typeCode string) string {
+	switch typeCode {
+	case "OBJECT", "PL/SQL RECORD":
+		return typeCode
+	case "COLLECTION", "TABLE", "VARYING ARRAY", "PL/SQL INDEX TABLE":
+		return "TABLE"
+	}
+	return ""
+}
+
 func (tr *typeResolver) Resolve(ctx context.Context, data string, tn TypeName) error {
 	tr.mu.Lock()
 	if old := tr.types[tn]; old != nil {
@@ -360,22 +432,35 @@ func (tr *typeResolver) Resolve(ctx context.Context, data string, tn TypeName) e
 	tr.types[typ.TypeName] = &typ
 	tr.mu.Unlock()
 
-	var rows *sql.Rows
+	// an SQL type is queried with its name as the package
+	pkg, sub := tn.Package, tn.Name
+	if pkg == "" {
+		pkg, sub = tn.Name, ""
+	}
 	var err error
 	switch data {
-	case "PL/SQL TABLE", "PL/SQL INDEX TABLE", "TABLE":
+	case "PL/SQL TABLE", "PL/SQL INDEX TABLE", "TABLE", "VARRAY":
 		var elem PlsType
+		var owner, name, elemPkg, charset, indexBy, typeCode sql.NullString
 		if err = tr.db.QueryRowContext(ctx, trQueries["coll"],
-			sql.Named("owner", tn.Owner), sql.Named("pkg", tn.Package), sql.Named("sub", tn.Name),
+			sql.Named("owner", tn.Owner), sql.Named("pkg", pkg), sql.Named("sub", sub),
 		).Scan(
 			&typ.TypeCode,
-			&elem.Owner, &elem.Name, &elem.Package,
+			&owner, &name, &elemPkg,
 			&elem.Length, &elem.Prec, &elem.Scale,
-			&elem.Charset, &elem.IndexBy,
-			&elem.TypeCode,
+			&charset, &indexBy,
+			&typeCode,
 		); err != nil {
 			return errors.Errorf("%s, %s: %w", trQueries["coll"], tn, err)
 		}
+		elem.TypeName = TypeName{Owner: owner.String, Package: elemPkg.String, Name: name.String}
+		elem.Charset, elem.TypeCode = charset.String, typeCode.String
+		typ.IndexBy = indexBy.String
+		if kind := complexType(elem.TypeCode); kind != "" {
+			if err = tr.Resolve(ctx, kind, elem.TypeName); err != nil {
+				return err
+			}
+		}
 		tr.mu.Lock()
 		if old := tr.types[elem.TypeName]; old != nil {
 			typ.CollectionOf = old
@@ -385,35 +470,30 @@ func (tr *typeResolver) Resolve(ctx context.Context, data string, tn TypeName) e
 		}
 		tr.mu.Unlock()
 
-	case "PL/SQL RECORD":
-		/*
-				"plsTyp": `SELECT attr_name, attr_type_owner, attr_type_name, attr_type_package,
-				  length, precision, scale, character_set_name,
-				  (SELECT MIN(typecode) FROM all_plsql_types B
-					 WHERE B.owner = A.attr_type_owner AND B.type_name = A.attr_type_name AND B.package_name = A.attr_type_package) typecode
-			 FROM all_plsql_type_attrs A
-			 WHERE owner = :owner AND package_name = :pkg AND type_name = :sub
-			 ORDER BY attr_no`,
-		*/
-		if rows, err = tr.db.QueryContext(ctx, trQueries["plsTyp"],
-			sql.Named("owner", tn.Owner), sql.Named("pkg", tn.Package), sql.Named("sub", tn.Name),
-		); err != nil {
-			return errors.Errorf("%s: %w", trQueries["plsTyp"], err)
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var t PlsType
-			if err = rows.Scan(&t.Attr, &t.Owner, &t.Name, &t.Package,
-				&t.Length, &t.Prec, &t.Scale, &t.Charset, &t.TypeCode,
-			); err != nil {
-				return err
+	case "PL/SQL RECORD", "OBJECT":
+		qry, params := trQueries["plsTyp"], []interface{}{sql.Named("owner", tn.Owner), sql.Named("pkg", pkg), sql.Named("sub", sub)}
+		if data == "OBJECT" {
+			qry, params = trQueries["objTyp"], params[:2]
+		}
+		var attrs []*PlsType
+		if attrs, err = tr.attrs(ctx, qry, params...); err != nil {
+			return err
+		}
+		for _, t := range attrs {
+			if kind := complexType(t.TypeCode); kind != "" {
+				// linked to the resolved type in Types, as the attribute has its own name
+				if err = tr.Resolve(ctx, kind, t.TypeName); err != nil {
+					return err
+				}
+				typ.RecordOf = append(typ.RecordOf, t)
+				continue
 			}
 			tr.mu.Lock()
 			if old := tr.types[t.TypeName]; old != nil {
 				typ.RecordOf = append(typ.RecordOf, old)
 			} else {
-				tr.types[t.TypeName] = &t
-				typ.RecordOf = append(typ.RecordOf, &t)
+				tr.types[t.TypeName] = t
+				typ.RecordOf = append(typ.RecordOf, t)
 			}
 			tr.mu.Unlock()
 		}
@@ -433,12 +513,33 @@ func (tr *typeResolver) Resolve(ctx context.Context, data string, tn TypeName) e
 	default:
 		return errors.Errorf("%v: %w", typ, errors.New("unknown type"))
 	}
-	if rows != nil {
-		if rowsErr := rows.Err(); rowsErr != nil && err == nil {
-			err = rowsErr
+	return err
+}
+
+// attrs returns the attributes of a record or object, read with the "plsTyp" or "objTyp" query.
+func (tr *typeResolver) attrs(ctx context.Context, qry string, params ...interface{}) ([]*PlsType, error) {
+	rows, err := tr.db.QueryContext(ctx, qry, params...)
+	if err != nil {
+		return nil, errors.Errorf("%s: %w", qry, err)
+	}
+	defer rows.Close()
+	var attrs []*PlsType
+	for rows.Next() {
+		var t PlsType
+		var owner, name, pkg, charset, typeCode sql.NullString
+		if err = rows.Scan(&t.Attr, &owner, &name, &pkg,
+			&t.Length, &t.Prec, &t.Scale, &charset, &typeCode,
+		); err != nil {
+			return nil, errors.Errorf("%s: %w", qry, err)
 		}
+		t.TypeName = TypeName{Owner: owner.String, Package: pkg.String, Name: name.String}
+		t.Charset, t.TypeCode = charset.String, typeCode.String
+		attrs = append(attrs, &t)
 	}
-	return err
+	if err = rows.Err(); err != nil {
+		return nil, errors.Errorf("%s: %w", qry, err)
+	}
+	return attrs, nil
 }
 
 type querier interface {
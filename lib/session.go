@@ -0,0 +1,202 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// The functions of the packages annotated with
+//
+//	--genocall:stateful
+//
+// keep their globals between the calls, so the calls of a client (see SessionKey in the
+// generated server) are pinned to a dedicated session, released after SessionIdleTimeout.
+
+// resetPackageStateQry reinitializes the state of the packages of the session.
+const resetPackageStateQry = "BEGIN DBMS_SESSION.MODIFY_PACKAGE_STATE(DBMS_SESSION.REINITIALIZE); END;"
+
+// ReleaseSession releases the connection of a dropped session of a client into the pool:
+// its package state is reinitialized before, so the next user of the connection does not
+// inherit the client's globals - or, if that fails, the connection is closed for good.
+func ReleaseSession(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, resetPackageStateQry); err != nil {
+		// the pool closes the connection returning driver.ErrBadConn
+		_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		return err
+	}
+	return conn.Close()
+}
+
+// sessionImports returns the imports of sessionCommon.
+func sessionImports() []string {
+	if SQLOnly {
+		return []string{"sync"}
+	}
+	return []string{"sync", "google.golang.org/grpc/metadata"}
+}
+
+// sessionCommon returns the session pool of the generated server.
+func sessionCommon() string {
+	var fromMetadata string
+	if !SQLOnly {
+		fromMetadata = `
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(SessionMetadataKey); len(v) != 0 {
+			return v[0]
+		}
+	}`
+	}
+	return `
+// SessionMetadataKey is the gRPC metadata key identifying the client,
+// whose calls of the stateful packages' functions are pinned to a session.
+const SessionMetadataKey = "genocall-session"
+
+// SessionIdleTimeout is the time after the session of an idle client is released.
+var SessionIdleTimeout = 5 * time.Minute
+
+type sessionKeyCtx struct{}
+
+// WithSessionKey returns a context identifying the client by key,
+// for pinning it to a session for the functions of the stateful packages.
+func WithSessionKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKeyCtx{}, key)
+}
+
+// sessionKey is the default SessionKey: the key of WithSessionKey,
+// or the SessionMetadataKey of the incoming gRPC metadata.
+func sessionKey(ctx context.Context) string {
+	if key, ok := ctx.Value(sessionKeyCtx{}).(string); ok {
+		return key
+	}` + fromMetadata + `
+	return ""
+}
+
+type sessionPool struct {
+	mu sync.Mutex
+	m  map[string]*session
+}
+
+// session is a client's dedicated connection, used by one call at a time.
+type session struct {
+	mu     sync.Mutex
+	pool   *sessionPool
+	key    string
+	conn   *sql.Conn
+	last   time.Time
+	timer  *time.Timer
+	closed bool
+}
+
+// session returns the locked session of the client of ctx (see SessionKey),
+// or nil if the client is not identified, or the call is already in a transaction or session.
+func (s *genocallServer) session(ctx context.Context) (*session, error) {
+	if s.conn != Preparer(s.db) || s.sessions == nil || s.SessionKey == nil {
+		return nil, nil
+	}
+	key := s.SessionKey(ctx)
+	if key == "" {
+		return nil, nil
+	}
+	p := s.sessions
+	for {
+		p.mu.Lock()
+		sess := p.m[key]
+		if sess == nil {
+			sess = &session{pool: p, key: key}
+			p.m[key] = sess
+		}
+		p.mu.Unlock()
+
+		sess.mu.Lock()
+		if sess.closed {
+			sess.mu.Unlock()
+			continue
+		}
+		if sess.conn == nil {
+			conn, err := s.db.Conn(ctx)
+			if err != nil {
+				sess.drop()
+				sess.mu.Unlock()
+				return nil, err
+			}
+			sess.conn = conn
+		}
+		return sess, nil
+	}
+}
+
+// release unlocks the session, and closes it after SessionIdleTimeout if it is not used.
+// A session whose connection is broken (err is driver.ErrBadConn) is closed at once.
+func (sess *session) release(err error) {
+	defer sess.mu.Unlock()
+	if errors.Is(err, driver.ErrBadConn) {
+		sess.drop()
+		return
+	}
+	sess.last = time.Now()
+	if sess.timer == nil {
+		sess.timer = time.AfterFunc(SessionIdleTimeout, sess.expire)
+	} else {
+		sess.timer.Reset(SessionIdleTimeout)
+	}
+}
+
+func (sess *session) expire() {
+	if !sess.mu.TryLock() {
+		return // in use, release rearms the timer
+	}
+	defer sess.mu.Unlock()
+	if d := time.Since(sess.last); d < SessionIdleTimeout {
+		sess.timer.Reset(SessionIdleTimeout - d)
+		return
+	}
+	sess.drop()
+}
+
+// drop removes the (locked) session from the pool, and releases its connection
+// without the client's package state.
+func (sess *session) drop() {
+	sess.pool.mu.Lock()
+	if sess.pool.m[sess.key] == sess {
+		delete(sess.pool.m, sess.key)
+	}
+	sess.pool.mu.Unlock()
+	sess.closed = true
+	if sess.timer != nil {
+		sess.timer.Stop()
+	}
+	if sess.conn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := genocall.ReleaseSession(ctx, sess.conn); err != nil {
+			Log("msg", "reset session", "key", sess.key, "error", err)
+		}
+	}
+}
+
+// CloseSessions closes the sessions of the clients.
+func (s *genocallServer) CloseSessions() {
+	if s.sessions == nil {
+		return
+	}
+	s.sessions.mu.Lock()
+	sessions := make([]*session, 0, len(s.sessions.m))
+	for _, sess := range s.sessions.m {
+		sessions = append(sessions, sess)
+	}
+	s.sessions.mu.Unlock()
+	for _, sess := range sessions {
+		sess.mu.Lock()
+		if !sess.closed {
+			sess.drop()
+		}
+		sess.mu.Unlock()
+	}
+}
+`
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// statefulDriver's connections keep the package state set by "SET x",
+// reinitialized by resetPackageStateQry (unless failReset), and read by "GET".
+type statefulDriver struct {
+	mu        sync.Mutex
+	opened    int
+	failReset bool
+}
+
+func (d *statefulDriver) Open(string) (driver.Conn, error) {
+	d.mu.Lock()
+	d.opened++
+	d.mu.Unlock()
+	return &statefulConn{d: d}, nil
+}
+
+type statefulConn struct {
+	d     *statefulDriver
+	state string
+}
+
+func (c *statefulConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *statefulConn) Close() error                        { return nil }
+func (c *statefulConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c *statefulConn) ExecContext(ctx context.Context, qry string, args []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case qry == resetPackageStateQry:
+		if c.d.failReset {
+			return nil, errors.New("ORA-03113: end-of-file on communication channel")
+		}
+		c.state = ""
+	case strings.HasPrefix(qry, "SET "):
+		c.state = strings.TrimPrefix(qry, "SET ")
+	default:
+		return nil, driver.ErrSkip
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *statefulConn) QueryContext(ctx context.Context, qry string, args []driver.NamedValue) (driver.Rows, error) {
+	if qry != "GET" {
+		return nil, driver.ErrSkip
+	}
+	return &stateRows{state: c.state}, nil
+}
+
+type stateRows struct {
+	state string
+	done  bool
+}
+
+func (r *stateRows) Columns() []string { return []string{"STATE"} }
+func (r *stateRows) Close() error      { return nil }
+func (r *stateRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done, dest[0] = true, r.state
+	return nil
+}
+
+func TestReleaseSession(t *testing.T) {
+	for _, failReset := range []bool{false, true} {
+		d := &statefulDriver{failReset: failReset}
+		db := sql.OpenDB(connector{d})
+		// the next caller gets the same connection - if it is not closed
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		ctx := context.Background()
+
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = conn.ExecContext(ctx, "SET client A"); err != nil {
+			t.Fatal(err)
+		}
+		if err = ReleaseSession(ctx, conn); (err != nil) != failReset {
+			t.Errorf("failReset=%t: %+v", failReset, err)
+		}
+
+		var state string
+		if err = db.QueryRowContext(ctx, "GET").Scan(&state); err != nil {
+			t.Fatal(err)
+		}
+		if state != "" {
+			t.Errorf("failReset=%t: the next caller sees the state %q of the dropped session", failReset, state)
+		}
+		if want := map[bool]int{false: 1, true: 2}[failReset]; d.opened != want {
+			t.Errorf("failReset=%t: %d connections opened, wanted %d", failReset, d.opened, want)
+		}
+		db.Close()
+	}
+}
+
+type connector struct{ d driver.Driver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.d }
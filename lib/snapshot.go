@@ -0,0 +1,253 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// SnapshotFormat identifies a snapshot file.
+	SnapshotFormat = "gen-o-call-snapshot"
+	// SnapshotVersion is the version of the snapshot format written by this package.
+	SnapshotVersion = 1
+)
+
+// Snapshot is an offline copy of everything ReadDB reads from the database:
+// the raw argument rows, the resolved types and the package sources
+// (for the annotations and documentation).
+//
+// Functions can be generated from a Snapshot without a database connection.
+// ServerVersion is the version of the database (as "23.0.0.0.0"), see SupportsNativeBoolean.
+type Snapshot struct {
+	Format        string
+	Version       int
+	Pattern       string    `json:",omitempty"`
+	Created       time.Time `json:",omitempty"`
+	ServerVersion string    `json:",omitempty"`
+	Packages      []SnapshotPackage
+	Arguments     []UserArgument
+	Types         []SnapshotType `json:",omitempty"`
+}
+
+// SnapshotPackage is a stored package's header source, and its owner.
+// (The last DDL time is in each of its Arguments.)
+type SnapshotPackage struct {
+	Name   string
+	Owner  string `json:",omitempty"`
+	Source string `json:",omitempty"`
+}
+
+// SnapshotType is a resolved PlsType, with the referenced types
+// as indexes into Snapshot.Types - as they may be shared, or even cyclic.
+type SnapshotType struct {
+	TypeName
+	Attr                       string `json:",omitempty"`
+	Charset, IndexBy, TypeCode string `json:",omitempty"`
+	Length, Prec, Scale        sql.NullInt64
+	CollectionOf               *int  `json:",omitempty"`
+	RecordOf                   []int `json:",omitempty"`
+}
+
+func flattenTypes(types map[TypeName]*PlsType) []SnapshotType {
+	keys := make([]TypeName, 0, len(types))
+	for k := range types {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	idx := make(map[*PlsType]int, len(keys))
+	flat := make([]SnapshotType, 0, len(keys))
+	var add func(t *PlsType) int
+	add = func(t *PlsType) int {
+		if i, ok := idx[t]; ok {
+			return i
+		}
+		i := len(flat)
+		idx[t] = i
+		flat = append(flat, SnapshotType{
+			TypeName: t.TypeName, Attr: t.Attr,
+			Charset: t.Charset, IndexBy: t.IndexBy, TypeCode: t.TypeCode,
+			Length: t.Length, Prec: t.Prec, Scale: t.Scale,
+		})
+		if t.CollectionOf != nil {
+			j := add(t.CollectionOf)
+			flat[i].CollectionOf = &j
+		}
+		for _, r := range t.RecordOf {
+			flat[i].RecordOf = append(flat[i].RecordOf, add(r))
+		}
+		return i
+	}
+	for _, k := range keys {
+		add(types[k])
+	}
+	return flat
+}
+
+func (s *Snapshot) typeMap() map[TypeName]*PlsType {
+	nodes := make([]PlsType, len(s.Types))
+	for i, t := range s.Types {
+		nodes[i] = PlsType{
+			TypeName: t.TypeName, Attr: t.Attr,
+			Charset: t.Charset, IndexBy: t.IndexBy, TypeCode: t.TypeCode,
+			Length: t.Length, Prec: t.Prec, Scale: t.Scale,
+		}
+	}
+	types := make(map[TypeName]*PlsType, len(nodes))
+	for i, t := range s.Types {
+		if t.CollectionOf != nil {
+			nodes[i].CollectionOf = &nodes[*t.CollectionOf]
+		}
+		for _, j := range t.RecordOf {
+			nodes[i].RecordOf = append(nodes[i].RecordOf, &nodes[j])
+		}
+		if _, ok := types[t.TypeName]; !ok {
+			types[t.TypeName] = &nodes[i]
+		}
+	}
+	return types
+}
+
+// Functions parses the snapshot's arguments into functions, and returns the
+// annotations found in the package sources.
+func (s *Snapshot) Functions(ctx context.Context, filter func(string) bool) (functions []Function, annotations []Annotation, err error) {
+	types := s.typeMap()
+	filteredArgs, err := FilterAndGroup(s.Arguments, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if functions, err = ParseArguments(filteredArgs, filter, types); err != nil {
+		return functions, nil, err
+	}
+	funcs := make(map[string]int, len(functions))
+	for i, f := range functions {
+		funcs[f.FullName()] = i
+	}
+	for _, p := range s.Packages {
+		annots, docs, err := ParseAnnotationsAndDocs(ctx, p.Name, p.Source)
+		if err != nil {
+			return functions, annotations, fmt.Errorf("%s: %w", p.Name, err)
+		}
+		annotations = append(annotations, annots...)
+		for _, a := range annots {
+			if a.Type != "implicit-results" {
+				continue
+			}
+			// the record types are resolved here, as ApplyAnnotations has no types
+			if i, ok := funcs[UnoCap(p.Name)+"."+strings.ToLower(a.Name)]; ok {
+				if err = functions[i].setImplicitResults(types, p.Owner, p.Name, a.Other); err != nil {
+					return functions, annotations, fmt.Errorf("%s: %w", a, err)
+				}
+			}
+		}
+		for k, doc := range docs {
+			if i, ok := funcs[k]; ok {
+				functions[i].Documentation = doc
+			}
+		}
+	}
+	return functions, annotations, nil
+}
+
+// Select returns a copy of the snapshot with only the functions (and their packages)
+// whose "PACKAGE.OBJECT" name matches the SQL LIKE pattern, as ReadSnapshot would have read them.
+func (s *Snapshot) Select(pattern string) *Snapshot {
+	var buf strings.Builder
+	buf.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			buf.WriteString(".*")
+		case '_':
+			buf.WriteByte('.')
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	buf.WriteByte('$')
+	rx := regexp.MustCompile(buf.String())
+
+	t := *s
+	t.Pattern = pattern
+	t.Arguments = make([]UserArgument, 0, len(s.Arguments))
+	pkgs := make(map[string]struct{})
+	for _, ua := range s.Arguments {
+		if rx.MatchString(ua.PackageName + "." + ua.ObjectName) {
+			t.Arguments = append(t.Arguments, ua)
+			pkgs[ua.PackageName] = struct{}{}
+		}
+	}
+	t.Packages = make([]SnapshotPackage, 0, len(pkgs))
+	for _, p := range s.Packages {
+		if _, ok := pkgs[p.Name]; ok {
+			t.Packages = append(t.Packages, p)
+		}
+	}
+	return &t
+}
+
+// Encode the snapshot as indented JSON.
+func (s *Snapshot) Encode(w io.Writer) error {
+	if s.Format == "" {
+		s.Format = SnapshotFormat
+	}
+	if s.Version == 0 {
+		s.Version = SnapshotVersion
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// DecodeSnapshot reads a snapshot written by Snapshot.Encode.
+func DecodeSnapshot(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if s.Format != SnapshotFormat {
+		return nil, fmt.Errorf("not a snapshot (format=%q)", s.Format)
+	}
+	if s.Version > SnapshotVersion {
+		return nil, fmt.Errorf("snapshot version %d is newer than the supported %d", s.Version, SnapshotVersion)
+	}
+	return &s, nil
+}
+
+// ReadSnapshotFile reads the snapshot from the named file.
+func ReadSnapshotFile(fileName string) (*Snapshot, error) {
+	fh, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	s, err := DecodeSnapshot(fh)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return s, nil
+}
+
+// WriteSnapshotFile writes the snapshot into the named file.
+func WriteSnapshotFile(fileName string, s *Snapshot) error {
+	fh, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	err = s.Encode(fh)
+	if closeErr := fh.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
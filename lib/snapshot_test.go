@@ -0,0 +1,163 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSnapshot() *Snapshot {
+	lastDDL := time.Date(2023, 8, 17, 10, 11, 12, 0, time.UTC)
+	recT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_SNAP", Name: "REC_T"}, TypeCode: "PL/SQL RECORD"}
+	numT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "NUM", Prec: sql.NullInt64{Int64: 9, Valid: true}}
+	vcT := &PlsType{TypeName: TypeName{Name: "VARCHAR2"}, Attr: "TEXT", Length: sql.NullInt64{Int64: 10, Valid: true}}
+	recT.RecordOf = []*PlsType{numT, vcT}
+	curT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_SNAP", Name: "CUR_T"}, TypeCode: "REF CURSOR"}
+	curT.CollectionOf = curT
+
+	ua := func(level uint8, name, inOut, dataType, typeSubname string) UserArgument {
+		a := UserArgument{
+			PackageName: "TST_SNAP", ObjectName: "REC_IN", LastDDL: lastDDL,
+			DataLevel: level, ArgumentName: name, InOut: inOut, DataType: dataType,
+		}
+		if typeSubname != "" {
+			a.TypeOwner, a.TypeName, a.TypeSubname = "OWNR", "TST_SNAP", typeSubname
+		}
+		return a
+	}
+	return &Snapshot{
+		Packages: []SnapshotPackage{{Name: "TST_SNAP",
+			Source: `CREATE OR REPLACE PACKAGE TST_SNAP IS
+  --genocall:handle no_data_found
+  --genocall:max-table-size rec_in=1000
+  TYPE rec_t IS RECORD (num NUMBER(9), text VARCHAR2(10));
+
+  /* rec_in
+     input:
+       - p_rec - the record
+  */
+  FUNCTION rec_in(p_rec IN rec_t) RETURN NUMBER;
+END TST_SNAP;
+`}},
+		Arguments: []UserArgument{
+			ua(0, "", "OUT", "NUMBER", ""),
+			ua(0, "P_REC", "IN", "PL/SQL RECORD", "REC_T"),
+			ua(1, "NUM", "IN", "NUMBER", ""),
+			ua(1, "TEXT", "IN", "VARCHAR2", ""),
+		},
+		Types: flattenTypes(map[TypeName]*PlsType{
+			recT.TypeName: recT, numT.TypeName: numT, vcT.TypeName: vcT, curT.TypeName: curT,
+		}),
+	}
+}
+
+func TestSnapshotSelect(t *testing.T) {
+	snap := testSnapshot()
+	for pattern, want := range map[string]int{
+		"%": 4, "tst_snap.%": 4, "TST_SNAP.REC_IN": 4, "TST_SNAP.REC_I_": 4,
+		"TST_SNAP.REC": 0, "OTHER.%": 0,
+	} {
+		sel := snap.Select(pattern)
+		if got := len(sel.Arguments); got != want {
+			t.Errorf("%q: got %d arguments, wanted %d", pattern, got, want)
+		}
+		if wantPkgs := min(want, 1); len(sel.Packages) != wantPkgs {
+			t.Errorf("%q: got %d packages, wanted %d", pattern, len(sel.Packages), wantPkgs)
+		}
+	}
+	if len(snap.Arguments) != 4 {
+		t.Error("Select modified the original")
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	want := testSnapshot()
+	var buf bytes.Buffer
+	if err := want.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(buf.String())
+	got, err := DecodeSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := got.typeMap()
+	if cur := types[TypeName{Owner: "OWNR", Package: "TST_SNAP", Name: "CUR_T"}]; cur == nil || cur.CollectionOf != cur {
+		t.Errorf("cyclic type is not restored: %#v", cur)
+	}
+
+	generate := func(s *Snapshot) (Function, string) {
+		functions, annotations, err := s.Functions(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		functions = ApplyAnnotations(functions, annotations)
+		if len(functions) != 1 {
+			t.Fatalf("got %d functions, wanted 1", len(functions))
+		}
+		var buf strings.Builder
+		if err := SaveProtobuf(&buf, functions, "snap"); err != nil {
+			t.Fatal(err)
+		}
+		return functions[0], buf.String()
+	}
+	wantF, wantProto := generate(want)
+	gotF, gotProto := generate(got)
+	if gotProto != wantProto {
+		t.Errorf("proto mismatch: got\n%s\nwanted\n%s", gotProto, wantProto)
+	}
+	if gotF.maxTableSize != 1000 || wantF.maxTableSize != 1000 {
+		t.Errorf("maxTableSize: got %d, wanted 1000", gotF.maxTableSize)
+	}
+	if len(gotF.handle) != 1 || gotF.handle[0] != "NO_DATA_FOUND" {
+		t.Errorf("handle: got %q", gotF.handle)
+	}
+	if !strings.Contains(gotF.Documentation, "the record") {
+		t.Errorf("documentation is missing: %q", gotF.Documentation)
+	}
+	if !gotF.LastDDL.Equal(wantF.LastDDL) {
+		t.Errorf("LastDDL: got %v, wanted %v", gotF.LastDDL, wantF.LastDDL)
+	}
+	if len(gotF.Args) != 1 || len(gotF.Args[0].RecordOf) != 2 {
+		t.Errorf("args: got %v", gotF.Args)
+	}
+}
+
+func TestStatefulAnnotation(t *testing.T) {
+	snap := testSnapshot()
+	snap.Packages[0].Source = strings.Replace(snap.Packages[0].Source,
+		"--genocall:handle no_data_found", "--genocall:handle no_data_found\n  --genocall:stateful", 1)
+	functions, annotations, err := snap.Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions = ApplyAnnotations(functions, annotations)
+	if len(functions) != 1 || !functions[0].stateful {
+		t.Fatalf("got %+v from %v, wanted a stateful function", functions, annotations)
+	}
+	if len(functions[0].handle) != 1 {
+		t.Errorf("handle: got %q", functions[0].handle)
+	}
+	if _, callFun := functions[0].PlsqlBlock(""); !strings.Contains(callFun, "s.session(ctx)") {
+		t.Errorf("the call is not pinned to a session:\n%s", callFun)
+	}
+
+	functions, _, err = testSnapshot().Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, callFun := functions[0].PlsqlBlock(""); strings.Contains(callFun, "s.session(ctx)") {
+		t.Errorf("the call of a not stateful package is pinned to a session:\n%s", callFun)
+	}
+}
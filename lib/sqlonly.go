@@ -0,0 +1,292 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/gogoproto"
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// SQLOnly makes the generated functions use plain Go structs instead of the
+// Protocol Buffers messages, and adds a Client calling them through database/sql only,
+// without any gRPC - see SaveStructsCommon and SaveStructsPackage.
+//
+// The structs are the same as the messages would be, with time.Time for the dates.
+var SQLOnly bool
+
+// SaveStructsCommon writes the structs of the records written by SaveProtobufCommon,
+// and the Client with its Iterator, and returns the records' names - these should be
+// skipped by SaveStructsPackage.
+func SaveStructsCommon(dst io.Writer, functions []Function) (map[string]struct{}, error) {
+	fd, common, err := ProtoDescriptorCommon("common.proto", functions, "")
+	if err != nil {
+		return common, err
+	}
+	if err = writeStructs(dst, fd); err != nil {
+		return common, err
+	}
+	_, err = io.WriteString(dst, clientCommon)
+	return common, err
+}
+
+// SaveStructsPackage writes the structs of the messages written by SaveProtobufService
+// (except the records in common), the stream interfaces of the functions with REF CURSOR
+// outputs, and the Client methods calling the functions.
+func SaveStructsPackage(dst io.Writer, functions []Function, common map[string]struct{}) error {
+	fd, err := ProtoDescriptorService("client.proto", functions, "", "Client", "", common)
+	if err != nil {
+		return err
+	}
+	if err = writeStructs(dst, fd); err != nil {
+		return err
+	}
+
+	// the methods are the rpcs of ProtoDescriptorService, which skipped the functions it could not save
+	byName := make(map[string]Function, len(functions))
+	for _, fun := range functions {
+		byName[CamelCase(dot2D.Replace(strings.ToLower(fun.AliasedName())))] = fun
+	}
+	var buf bytes.Buffer
+	for _, m := range fd.Service[0].Method {
+		fun := byName[m.GetName()]
+		pkg, in, out := CamelCase(fun.Package), strings.TrimPrefix(m.GetInputType(), "."), strings.TrimPrefix(m.GetOutputType(), ".")
+		// the method is qualified by the package, the name of the rpc maybe, too (see qualifyNames)
+		method := pkg + CamelCase(dot2D.Replace(strings.ToLower(fun.shortName())))
+		if !m.GetServerStreaming() {
+			fmt.Fprintf(&buf, `
+// %s calls %s.
+func (c *Client) %s(ctx context.Context, input %s) (%s, error) {
+	output, err := c.s.%s(ctx, &input)
+	if output == nil {
+		return %s{}, err
+	}
+	return *output, err
+}
+`,
+				method, fun.FullName(),
+				method, in, out,
+				m.GetName(),
+				out,
+			)
+			continue
+		}
+		fmt.Fprintf(&buf, `
+// %s_%sServer is the stream the outputs of %s are sent to, one for each batch of rows.
+type %s_%sServer interface {
+	Context() context.Context
+	Send(*%s) error
+}
+
+// %s calls %s, and returns an Iterator over its outputs, one for each batch of rows.
+func (c *Client) %s(ctx context.Context, input %s) *Iterator[%s] {
+	return newIterator(ctx, func(stream *iteratorStream[%s]) error {
+		return c.s.%s(&input, stream)
+	})
+}
+`,
+			pkg, m.GetName(), fun.FullName(),
+			pkg, m.GetName(),
+			out,
+			method, fun.FullName(),
+			method, in, out,
+			out,
+			m.GetName(),
+		)
+	}
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("save client methods: %w\n%s", err, buf.String())
+	}
+	_, err = dst.Write(b)
+	return err
+}
+
+// writeStructs writes a struct for each message, with the fields as the Protocol Buffers
+// Go generator would name them, and the same JSON names.
+func writeStructs(dst io.Writer, fd *descriptor.FileDescriptorProto) error {
+	var buf bytes.Buffer
+	for _, m := range fd.MessageType {
+		fmt.Fprintf(&buf, "\ntype %s struct {\n", m.GetName())
+		for _, f := range m.Field {
+			typ, err := goStructFieldType(m, f)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", m.GetName(), f.GetName(), err)
+			}
+			tag := f.GetName() + ",omitempty"
+			if jt := gogoproto.GetJsonTag(f); jt != nil {
+				tag = *jt
+			}
+			fmt.Fprintf(&buf, "\t%s %s `json:%q`\n", CamelCase(f.GetName()), typ, tag)
+		}
+		buf.WriteString("}\n")
+	}
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("save structs: %w\n%s", err, buf.String())
+	}
+	_, err = dst.Write(b)
+	return err
+}
+
+var goStructScalarTypes = map[descriptor.FieldDescriptorProto_Type]string{
+	descriptor.FieldDescriptorProto_TYPE_DOUBLE: "float64", descriptor.FieldDescriptorProto_TYPE_FLOAT: "float32",
+	descriptor.FieldDescriptorProto_TYPE_INT64: "int64", descriptor.FieldDescriptorProto_TYPE_UINT64: "uint64",
+	descriptor.FieldDescriptorProto_TYPE_INT32: "int32", descriptor.FieldDescriptorProto_TYPE_UINT32: "uint32",
+	descriptor.FieldDescriptorProto_TYPE_FIXED64: "uint64", descriptor.FieldDescriptorProto_TYPE_FIXED32: "uint32",
+	descriptor.FieldDescriptorProto_TYPE_SFIXED64: "int64", descriptor.FieldDescriptorProto_TYPE_SFIXED32: "int32",
+	descriptor.FieldDescriptorProto_TYPE_SINT64: "int64", descriptor.FieldDescriptorProto_TYPE_SINT32: "int32",
+	descriptor.FieldDescriptorProto_TYPE_BOOL: "bool", descriptor.FieldDescriptorProto_TYPE_STRING: "string",
+	descriptor.FieldDescriptorProto_TYPE_BYTES: "[]byte",
+}
+
+// goStructFieldType returns the Go type of the field f of the message m.
+func goStructFieldType(m *descriptor.DescriptorProto, f *descriptor.FieldDescriptorProto) (string, error) {
+	for _, n := range m.NestedType {
+		if !n.GetOptions().GetMapEntry() || f.GetTypeName() != "."+m.GetName()+"."+n.GetName() {
+			continue
+		}
+		key, err := goStructFieldType(n, n.Field[0])
+		if err != nil {
+			return "", err
+		}
+		value, err := goStructFieldType(n, n.Field[1])
+		return "map[" + key + "]" + value, err
+	}
+	var typ string
+	if f.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+		if typ = goStructScalarTypes[f.GetType()]; typ == "" {
+			return "", fmt.Errorf("unsupported type %s", f.GetType())
+		}
+	} else if f.GetTypeName() == ".google.protobuf.Timestamp" {
+		typ = "time.Time"
+	} else if f.GetTypeName() == ".google.protobuf.Duration" {
+		typ = "time.Duration"
+	} else {
+		typ = "*" + strings.TrimPrefix(f.GetTypeName(), ".")
+	}
+	if f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		typ = "[]" + typ
+	}
+	return typ, nil
+}
+
+// sortedKeys returns the keys of m, sorted.
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const clientCommon = `
+// Client calls the functions through database/sql only.
+type Client struct {
+	s *genocallServer
+}
+
+// NewClient returns a Client calling the functions in db.
+func NewClient(db *sql.DB) *Client { return &Client{s: NewServer(db, nil)} }
+
+// WithTx returns a Client calling the functions in tx.
+func (c *Client) WithTx(tx *sql.Tx) *Client { return &Client{s: c.s.WithTx(tx)} }
+
+// WithConn returns a Client calling the functions on conn.
+func (c *Client) WithConn(conn *sql.Conn) *Client { return &Client{s: c.s.WithConn(conn)} }
+
+// Iterator iterates over the outputs of a function with REF CURSOR outputs,
+// one for each batch of rows:
+//
+//	it := client.MyPkgMyFunc(ctx, input)
+//	defer it.Close()
+//	for it.Next() {
+//		use(it.Output())
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type Iterator[T any] struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	outputs chan *T
+	next    chan struct{}
+	output  *T
+	err     error
+}
+
+func newIterator[T any](ctx context.Context, call func(*iteratorStream[T]) error) *Iterator[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &Iterator[T]{ctx: ctx, cancel: cancel, outputs: make(chan *T), next: make(chan struct{})}
+	go func() {
+		defer close(it.outputs)
+		it.err = call(&iteratorStream[T]{ctx: ctx, outputs: it.outputs, next: it.next})
+	}()
+	return it
+}
+
+// Next advances to the next output, and reports whether there is one.
+func (it *Iterator[T]) Next() bool {
+	if it.output != nil {
+		// the function reuses the output, so it must wait until it is not used
+		it.output = nil
+		select {
+		case it.next <- struct{}{}:
+		case <-it.ctx.Done():
+		}
+	}
+	output, ok := <-it.outputs
+	it.output = output
+	return ok
+}
+
+// Output returns the current output, which is valid until the next call of Next.
+func (it *Iterator[T]) Output() *T { return it.output }
+
+// Err returns the error of the function, after Next returned false.
+func (it *Iterator[T]) Err() error { return it.err }
+
+// Close stops the function, and returns its error - except the cancelation caused by Close.
+func (it *Iterator[T]) Close() error {
+	it.cancel()
+	it.output = nil
+	for range it.outputs {
+	}
+	if errors.Is(it.err, context.Canceled) {
+		return nil
+	}
+	return it.err
+}
+
+// iteratorStream is the stream of the function, sending the outputs to the Iterator.
+type iteratorStream[T any] struct {
+	ctx     context.Context
+	outputs chan<- *T
+	next    <-chan struct{}
+}
+
+func (s *iteratorStream[T]) Context() context.Context { return s.ctx }
+
+func (s *iteratorStream[T]) Send(output *T) error {
+	select {
+	case s.outputs <- output:
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+	select {
+	case <-s.next:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+`
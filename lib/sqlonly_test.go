@@ -0,0 +1,137 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"bytes"
+	"context"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cursorFunctions returns a function of TST_CUR returning a REF CURSOR of records with a DATE,
+// with a NUMBER(9,2) and a VARCHAR2(20) input.
+func cursorFunctions(t *testing.T) []Function {
+	numT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "NUM"}
+	dateT := &PlsType{TypeName: TypeName{Name: "DATE"}, Attr: "WHEN"}
+	rowT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_CUR", Name: "ROW_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{numT, dateT}}
+	curT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_CUR", Name: "CUR_T"}, TypeCode: "REF CURSOR", CollectionOf: rowT}
+
+	var args []UserArgument
+	ua := func(level uint8, name, inOut, dataType, typeSubname string) {
+		a := UserArgument{
+			PackageName: "TST_CUR", ObjectName: "LIST_ROWS", LastDDL: time.Date(2023, 8, 17, 10, 11, 12, 0, time.UTC),
+			DataLevel: level, ArgumentName: name, InOut: inOut, DataType: dataType,
+		}
+		if typeSubname != "" {
+			a.TypeOwner, a.TypeName, a.TypeSubname = "OWNR", "TST_CUR", typeSubname
+		}
+		args = append(args, a)
+	}
+	ua(0, "", "OUT", "REF CURSOR", "CUR_T")
+	ua(1, "", "OUT", "PL/SQL RECORD", "ROW_T")
+	ua(2, "NUM", "OUT", "NUMBER", "")
+	ua(2, "WHEN", "OUT", "DATE", "")
+	ua(0, "P_SINCE", "IN", "DATE", "")
+	ua(0, "P_AMOUNT", "IN", "NUMBER", "")
+	args[len(args)-1].DataPrecision, args[len(args)-1].DataScale = 9, 2
+	ua(0, "P_NAME", "IN", "VARCHAR2", "")
+	args[len(args)-1].CharLength = 20
+	snap := Snapshot{
+		Arguments: args,
+		Types: flattenTypes(map[TypeName]*PlsType{
+			numT.TypeName: numT, dateT.TypeName: dateT, rowT.TypeName: rowT, curT.TypeName: curT,
+		}),
+	}
+	functions, _, err := snap.Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return functions
+}
+
+func TestSavePackagesSQLOnly(t *testing.T) {
+	defer func(old bool) { SQLOnly = old }(SQLOnly)
+	SQLOnly = true
+	functions := append(nestedRecordFunctions(t), tableFunctions(t)...)
+	functions = append(functions, objectFunctions(t)...)
+	functions = append(functions, mapFunctions(t)...)
+	functions = append(functions, outTableFunctions(t)...)
+	functions = append(functions, booleanFunctions(t)...)
+	functions = append(functions, datetimeFunctions(t)...)
+	functions = append(functions, lobFunctions(t)...)
+	functions = append(functions, weakCursorFunctions(t)...)
+	functions = append(functions, implicitFunctions(t)...)
+	functions = append(functions, twoPackageFunctions(t)...)
+	for _, f := range cursorFunctions(t) {
+		f.stateful = true
+		functions = append(functions, f)
+	}
+	dir := t.TempDir()
+	if _, _, err := SavePackages(dir, functions, "snap", "", "genocall", "fp", false); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := make(map[string][]byte, len(files))
+	for _, fn := range files {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(fn, "_test.go") {
+			if f, err := format.Source(b); err != nil {
+				t.Errorf("%s: %+v", fn, err)
+			} else if !bytes.Equal(f, b) {
+				t.Errorf("%s is not gofmt'd:\n%s", fn, b)
+			}
+		}
+		generated[filepath.Base(fn)] = b
+	}
+	common, tstCur := string(generated["genocall.go"]), string(generated["tst_cur_genocall.go"])
+	for _, want := range []string{"type TstTypes_OuterT_Ownr struct", "type Client struct", "func (c *Client) WithTx(tx *sql.Tx) *Client", "type Iterator[T any] struct"} {
+		if !strings.Contains(common, want) {
+			t.Errorf("%q is not in the common file:\n%s", want, common)
+		}
+	}
+	for _, want := range []string{
+		"type TstCur_ListRowsServer interface",
+		"func (c *Client) TstCurListRows(ctx context.Context, input ListRows_Input) *Iterator[ListRows_Output]",
+		"sess, sessErr := s.session(ctx)",
+		`httpRoutes["/tst_cur/list_rows"] = httpStreaming[ListRows_Input, ListRows_Output]((*genocallServer).ListRows)`,
+		"When time.Time `json:\"when,omitempty\"`",
+	} {
+		if !strings.Contains(tstCur, want) {
+			t.Errorf("%q is not in tst_cur_genocall.go:\n%s", want, tstCur)
+		}
+	}
+	if s := string(generated["tst_a_genocall.go"]); !strings.Contains(s, "func (c *Client) TstARecA(ctx context.Context, input RecA_Input) (RecA_Output, error)") {
+		t.Errorf("TstARecA is missing:\n%s", s)
+	}
+	// the same name in two packages
+	if s := string(generated["tst_other_genocall.go"]); !strings.Contains(s, "func (c *Client) TstOtherRecIn(ctx context.Context, input TstOther_RecIn_Input) (TstOther_RecIn_Output, error)") {
+		t.Errorf("TstOtherRecIn is missing:\n%s", s)
+	}
+	if testing.Short() {
+		return
+	}
+
+	// everything is generated, so the whole package is type checked
+	fset := token.NewFileSet()
+	imp := &stubImporter{fset: fset, std: importer.ForCompiler(fset, "source", nil), stubs: map[string]string{
+		"github.com/davecgh/go-spew/spew": "package spew\nfunc Sdump(...interface{}) string { return \"\" }",
+	}}
+	if _, err := imp.check("snap", generated); err != nil {
+		t.Error(err)
+	}
+}
@@ -34,6 +34,12 @@ type Function struct {
 	LastDDL              time.Time  `json:",omitempty"`
 	handle               []string
 	maxTableSize         int
+	batchSize            int
+	stateful             bool
+	// qualified by the package, as the name is in other packages, too (see qualifyNames)
+	qualified bool
+	// the annotations of the package, for its fingerprint (see ApplyAnnotations)
+	annotations []string
 }
 
 func (f Function) FullName() string {
@@ -57,6 +63,14 @@ func (f Function) RealName() string {
 	return UnoCap(f.Package) + "." + nm
 }
 func (f Function) AliasedName() string {
+	if f.qualified {
+		return strings.ToLower(f.Package) + "__" + f.shortName()
+	}
+	return f.shortName()
+}
+
+// shortName is the aliased name, without the package.
+func (f Function) shortName() string {
 	if f.Alias != "" {
 		return f.Alias
 	}
@@ -171,6 +185,7 @@ type Argument struct {
 	Charlength     uint
 	TableOf        *Argument // this argument is a table (array) of this type
 	goTypeName     string
+	implicitResult bool // an implicit result set, not a real argument, see setImplicitResults
 	PlsType
 	Flavor    flavor
 	Direction direction
@@ -205,12 +220,15 @@ func NewArgument(name, dataType, plsTypeName, typeName, dirName string, dir dire
 	charset string, precision, scale uint8, charlength uint, typ *PlsType) Argument {
 
 	name = strings.ToLower(name)
+	dataType, plsTypeName = canonicalDatetime(dataType), canonicalDatetime(plsTypeName)
 	if typeName == "..@" {
 		typeName = ""
 	}
 	if typeName != "" && typeName[len(typeName)-1] == '@' {
 		typeName = typeName[:len(typeName)-1]
 	}
+	// an SQL type has no TYPE_SUBNAME
+	typeName = strings.TrimSuffix(typeName, ".")
 
 	if dirName != "" {
 		switch dirName {
@@ -242,19 +260,29 @@ func NewArgument(name, dataType, plsTypeName, typeName, dirName string, dir dire
 		mu:      new(sync.Mutex),
 		AbsType: dataType,
 	}
+	arg.PlsType.Name = canonicalDatetime(arg.PlsType.Name)
 	if arg.PlsType.Name == "" {
 		panic(fmt.Sprintf("empty PLS type of %#v, typ=%#v", arg, typ))
 	}
 	switch arg.Type {
-	case "PL/SQL RECORD":
+	case "PL/SQL RECORD", "OBJECT":
 		arg.Flavor = FLAVOR_RECORD
 		arg.RecordOf = make([]NamedArgument, 0, 1)
-	case "TABLE", "PL/SQL TABLE", "REF CURSOR":
+	case "TABLE", "VARRAY", "PL/SQL TABLE", "REF CURSOR":
 		arg.Flavor = FLAVOR_TABLE
+		if typ.CollectionOf == nil && arg.Type == "REF CURSOR" {
+			// a weakly typed SYS_REFCURSOR, its columns are given by a cursor-columns annotation
+			break
+		}
 		if typ.CollectionOf == nil {
 			panic(fmt.Sprintf("empty CollectionOf type of %#v, typ=%#v", arg, typ))
 		}
 		arg.TableOf = &Argument{PlsType: *typ.CollectionOf}
+	case "BFILE":
+		// a message of the directory and the file name, see getConvBfile
+		arg.Flavor = FLAVOR_RECORD
+		arg.TypeName = "BFILE"
+		arg.RecordOf = bfileFields(dir)
 	}
 
 	switch arg.Type {
@@ -281,6 +309,60 @@ func NewArgument(name, dataType, plsTypeName, typeName, dirName string, dir dire
 	return arg
 }
 
+// fillFromType fills the fields of the record, or the element of the table
+// from its resolved type - for the arguments read without their deeper levels
+// (ReadSnapshot reads the top level only).
+func (arg *Argument) fillFromType() {
+	switch arg.Flavor {
+	case FLAVOR_RECORD:
+		if len(arg.RecordOf) != 0 {
+			return
+		}
+		for _, t := range arg.PlsType.RecordOf {
+			sub := argumentOfType(t.Attr, t, arg.Direction)
+			arg.RecordOf = append(arg.RecordOf, NamedArgument{Name: sub.Name, Argument: &sub})
+		}
+	case FLAVOR_TABLE:
+		// a REF CURSOR's record is its own type
+		if arg.Type == "REF CURSOR" || arg.TableOf == nil || arg.TableOf.Type != "" || arg.PlsType.CollectionOf == nil {
+			return
+		}
+		elem := argumentOfType("", arg.PlsType.CollectionOf, arg.Direction)
+		arg.TableOf = &elem
+	}
+}
+
+// argumentOfType returns the field (or element) of the resolved type t.
+func argumentOfType(name string, t *PlsType, dir direction) Argument {
+	dataType, typeName := t.Name, ""
+	switch t.TypeCode {
+	case "OBJECT", "PL/SQL RECORD", "TABLE":
+		dataType = t.TypeCode
+	case "VARYING ARRAY":
+		dataType = "VARRAY"
+	case "PL/SQL TABLE", "PL/SQL INDEX TABLE":
+		dataType = "PL/SQL TABLE"
+	}
+	if dataType != t.Name {
+		typeName = t.TypeName.qualified()
+	}
+	var precision, scale uint8
+	var charlength uint
+	if t.Prec.Valid {
+		precision = uint8(t.Prec.Int64)
+	}
+	if t.Scale.Valid {
+		scale = uint8(t.Scale.Int64)
+	}
+	if t.Length.Valid {
+		charlength = uint(t.Length.Int64)
+	}
+	arg := NewArgument(name, dataType, "", typeName, "", dir,
+		t.Charset, precision, scale, charlength, t)
+	arg.fillFromType()
+	return arg
+}
+
 func UnoCap(text string) string {
 	i := strings.Index(text, "_")
 	if i == 0 {
@@ -0,0 +1,106 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"fmt"
+	"strings"
+)
+
+// With TaggedCursors, the REF CURSOR outputs of a function are not sent in lockstep
+// (each output having the next batch of every cursor), but one cursor after the other:
+//
+// Each output has a batch of just one cursor, in the cursor_rows oneof, named after the cursor.
+// The batch is a message of the rows, and whether it is the last batch of that cursor
+// (so each cursor has at least one batch, even if it has no rows).
+// The first output has the other output fields, too.
+//
+// The implicit result sets are tagged the same way, as separate cursors.
+
+// TaggedCursors makes the generated functions send their REF CURSOR outputs one after the other,
+// each batch tagged with its cursor.
+var TaggedCursors bool
+
+// cursorOneof is the name of the oneof of the tagged cursor batches in the output message.
+const cursorOneof = "cursor_rows"
+
+// tagsCursors reports whether the REF CURSOR outputs of the function are tagged.
+func (f Function) tagsCursors() bool {
+	return TaggedCursors && !SQLOnly && f.HasCursorOut()
+}
+
+// isTaggedCursor reports whether the output argument of the function is a tagged REF CURSOR.
+func (f Function) isTaggedCursor(arg Argument) bool {
+	return arg.Type == "REF CURSOR" && arg.IsOutput() && f.tagsCursors()
+}
+
+// cursorBatchName returns the name of the message of the tagged cursor's batches.
+func (f Function) cursorBatchName(arg Argument) string {
+	return CamelCase(dot2D.Replace(strings.ToLower(f.AliasedName())) + "__" + strings.ToLower(replHidden(arg.Name)) + "_rows")
+}
+
+// cursorTag is what the iterator of a tagged cursor needs: the type of its batches,
+// and the type of the field of the oneof of the output.
+type cursorTag struct {
+	Batch, Field string
+}
+
+// cursorTag returns the types of the batches of the REF CURSOR output,
+// or the zero cursorTag if it is not tagged.
+func (f Function) cursorTag(arg Argument) cursorTag {
+	if !f.isTaggedCursor(arg) {
+		return cursorTag{}
+	}
+	return cursorTag{
+		Batch: withPb(f.cursorBatchName(arg)),
+		Field: withPb(CamelCase(f.getStructName(true, false)) + "_" + CamelCase(arg.Name)),
+	}
+}
+
+// batchVar returns the declaration of the variable of the batches of the cursor named name,
+// with the rows of GoT, and the iterator's Tag function, putting the batch into the output.
+func (ct cursorTag) batchVar(name, GoT string, size int) (vn, decl, tag string) {
+	vn = "batch" + name
+	decl = fmt.Sprintf("%s := &%s{Rows: make([]%s, 0, %d)} // tagged", vn, ct.Batch, GoT, size)
+	tag = fmt.Sprintf(`Tag: func(last bool) {
+		%s.Last = last
+		output.%s = &%s{%s: %s}
+	},`, vn, CamelCase(cursorOneof), ct.Field, name, vn)
+	return vn, decl, tag
+}
+
+// taggedCursorsLoop is the loop of the generated function sending the outputs,
+// draining the iterators of the tagged cursors one after the other.
+const taggedCursorsLoop = `
+		if len(iterators) == 0 {
+			err = stream.Send(output)
+			return
+		}
+		for _, it := range iterators {
+			// the other fields are in the first output only, and the batch is empty yet
+			it.Tag(true)
+			batchFixed := genocall.TaggedFixedSize(output, batchBytes)
+			for {
+				if err = ctx.Err(); err != nil { return }
+				batchRoom, batchEmpty = batchBytes-batchFixed, true
+				iterErr := it.Iterate()
+				last := iterErr != nil
+				if last && !errors.Is(iterErr, io.EOF) {
+					err = iterErr
+					return
+				}
+				it.Tag(last)
+				if err = stream.Send(output); err != nil {
+					return
+				}
+				// the other fields are in the first output only
+				output = new(%s)
+				if last {
+					break
+				}
+			}
+		}
+		return
+`
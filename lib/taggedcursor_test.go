@@ -0,0 +1,123 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTaggedCursors(t *testing.T) {
+	defer func(old bool) { TaggedCursors = old }(TaggedCursors)
+	TaggedCursors = true
+	functions := append(weakCursorFunctions(t), implicitFunctions(t)...)
+
+	var buf strings.Builder
+	if err := SaveProtobufService(&buf, functions[:1], "snap", "TstWeak", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"rpc List (List_Input) returns (stream List_Output) {}",
+		"\toneof cursor_rows {\n\t\tList_PCurRows p_cur = 1;\n\t\tList_RetRows ret = 2;\n\t}\n",
+		"message List_PCurRows {\n\trepeated ListPCurRow_TstWeak rows = 1;",
+		"\tbool last = 2;\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%q is not in the proto:\n%s", want, buf.String())
+		}
+	}
+	fd, err := ProtoDescriptorService("tst_weak.proto", functions[:1], "snap", "TstWeak", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range fd.MessageType {
+		if m.GetName() != "List_Output" {
+			continue
+		}
+		if len(m.OneofDecl) != 1 || m.OneofDecl[0].GetName() != "cursor_rows" {
+			t.Errorf("got %v, wanted the cursor_rows oneof", m.OneofDecl)
+		}
+		for _, f := range m.Field {
+			if f.OneofIndex == nil || f.GetOneofIndex() != 0 {
+				t.Errorf("%s is not in the oneof", f.GetName())
+			}
+		}
+	}
+
+	for _, fun := range functions {
+		_, callFun := fun.PlsqlBlock("")
+		var want []string
+		switch fun.Name {
+		case "list":
+			want = []string{
+				"batchPCur := &pb.List_PCurRows{Rows: make([]*pb.ListPCurRow_TstWeak, 0, ",
+				"output.CursorRows = &pb.List_Output_PCur{PCur: batchPCur}",
+				"output.CursorRows = &pb.List_Output_Ret{Ret: batchRet}",
+				"it.Tag(last)",
+			}
+		case "list_all":
+			want = []string{
+				"I, err := results.NextOf(1)",
+				"output.CursorRows = &pb.ListAll_Output_DeptRecT{DeptRecT: batchDeptRecT}",
+			}
+		}
+		for _, w := range want {
+			if !strings.Contains(callFun, w) {
+				t.Errorf("%s: %q is not in the call:\n%s", fun.Name, w, callFun)
+			}
+		}
+	}
+
+	buf.Reset()
+	if err := SaveOpenAPI(&buf, functions[:1], "weak"); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if props := doc.Components.Schemas["List_Output"].Properties; props["CursorRows"] == nil {
+		t.Errorf("no CursorRows in the output: %s", props)
+	}
+	if props := doc.Components.Schemas["List_PCurRows"].Properties; props["rows"] == nil || props["last"] == nil {
+		t.Errorf("no rows and last in the batch: %s", props)
+	}
+}
+
+func TestImplicitResultsNextOf(t *testing.T) {
+	ir := NewImplicitResults(&resultSets{set: -1, sets: [][][]driver.Value{
+		{{1, "a"}, {2, "b"}},
+		{{"skipped"}},
+		{},
+		{{"x"}, {"y"}},
+	}})
+	var got []string
+	for _, set := range []int{0, 2, 3, 4} {
+		for {
+			row, err := ir.NextOf(set)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					t.Fatal(err)
+				}
+				break
+			}
+			got = append(got, fmt.Sprintf("%d:%v", set, row))
+		}
+	}
+	if s, want := strings.Join(got, " "), "0:[1 a] 0:[2 b] 3:[x] 3:[y]"; s != want {
+		t.Errorf("got %q, wanted %q", s, want)
+	}
+}
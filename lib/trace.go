@@ -0,0 +1,30 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import "context"
+
+// Tracer traces the calls of the generated functions: it is called with the name of the
+// PL/SQL function ("pkg.fn", in lowercase) before each phase ("prepare" and "exec") of the call,
+// and the returned func is called with the error of the phase at its end.
+//
+// The returned context is used for the phase - such as a godror.ContextWithTraceTag.
+type Tracer func(ctx context.Context, funName, phase string) (context.Context, func(error))
+
+type tracerCtxKey struct{}
+
+// ContextWithTracer returns a context with the Tracer of the generated functions called with it.
+func ContextWithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerCtxKey{}, tracer)
+}
+
+// TraceCall starts the phase of the call of the PL/SQL function by the Tracer of the context,
+// if it has any.
+func TraceCall(ctx context.Context, funName, phase string) (context.Context, func(error)) {
+	if tracer, ok := ctx.Value(tracerCtxKey{}).(Tracer); ok && tracer != nil {
+		return tracer(ctx, funName, phase)
+	}
+	return ctx, func(error) {}
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTraceCall(t *testing.T) {
+	ctx, end := TraceCall(context.Background(), "tst_impl.list_all", "prepare")
+	end(nil) // no Tracer
+	if ctx != context.Background() {
+		t.Errorf("got a new context without a Tracer")
+	}
+
+	var got []string
+	ctx = ContextWithTracer(context.Background(), func(ctx context.Context, funName, phase string) (context.Context, func(error)) {
+		got = append(got, phase+" "+funName)
+		return ctx, func(err error) { got = append(got, "end "+phase+" "+err.Error()) }
+	})
+	_, end = TraceCall(ctx, "tst_impl.list_all", "exec")
+	end(errors.New("ORA-01403"))
+	if s, want := strings.Join(got, ", "), "exec tst_impl.list_all, end exec ORA-01403"; s != want {
+		t.Errorf("got %q, wanted %q", s, want)
+	}
+
+	_, callFun := implicitFunctions(t)[0].PlsqlBlock("")
+	for _, want := range []string{
+		`const traceName = "tst_impl.list_all"`,
+		`genocall.TraceCall(ctx, traceName, "prepare")`,
+		`genocall.TraceCall(ctx, traceName, "exec")`,
+	} {
+		if !strings.Contains(callFun, want) {
+			t.Errorf("%q is not in the call:\n%s", want, callFun)
+		}
+	}
+}
@@ -36,6 +36,18 @@ func (tn TypeName) String() string {
 	return tn.Owner + "." + tn.Package + "." + tn.Name
 }
 
+// qualified returns the name as the database knows it: OWNER.PACKAGE.NAME for a PL/SQL type,
+// OWNER.NAME for an SQL type.
+func (tn TypeName) qualified() string {
+	parts := make([]string, 0, 3)
+	for _, s := range []string{tn.Owner, tn.Package, tn.Name} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
 func (arg PlsType) String() string { return arg.TypeName.String() }
 
 // FromOra retrieves the value of the argument with arg type, from src variable to dst variable.
@@ -43,20 +55,37 @@ func (arg PlsType) FromOra(dst, src, varName string) string {
 	switch arg.Name {
 	case "BLOB":
 		if varName != "" {
-			return fmt.Sprintf("{ if %s.Reader != nil { %s, err = ioutil.ReadAll(%s) }", varName, dst, varName)
+			return fmt.Sprintf("{ if %s.Reader != nil { %s, err = ioutil.ReadAll(%s) } }", varName, dst, varName)
 		}
 		return fmt.Sprintf("%s = godror.Lob{Reader:bytes.NewReader(%s)}", dst, src)
-	case "CLOB":
+	case "CLOB", "NCLOB":
 		if varName != "" {
 			return fmt.Sprintf("{var b []byte; if %s.Reader != nil {b, err = ioutil.ReadAll(%s); %s = string(b)}}", varName, varName, dst)
 		}
 		return fmt.Sprintf("%s = godror.Lob{IsClob:true, Reader:strings.NewReader(%s)}", dst, src)
-	case "DATE", "TIMESTAMP":
+	case "DATE", "TIMESTAMP", typeTimestampTZ, typeTimestampLTZ:
+		if ProtoAPIv2 {
+			if varName != "" {
+				src = varName
+			}
+			return fmt.Sprintf("%s = custom.AsTimestamp(%s)", dst, src)
+		}
 		return fmt.Sprintf("%s = (%s)", dst, src)
+	case typeIntervalDS:
+		if ProtoAPIv2 {
+			if varName != "" {
+				src = varName
+			}
+			return fmt.Sprintf("%s = custom.AsDurationProto(%s)", dst, src)
+		}
 	case "PLS_INTEGER":
 		return fmt.Sprintf("%s = int32(%s)", dst, src)
 	case "NUMBER":
 		return fmt.Sprintf("%s = string(%s)", dst, src)
+	case "BOOLEAN", "PL/SQL BOOLEAN":
+		if !NativeBoolean && varName != "" {
+			return fmt.Sprintf("%s = %s.Int32 == 1", dst, varName)
+		}
 	case "":
 		panic(fmt.Sprintf("empty \"ora\" type: %#v", arg))
 	}
@@ -72,6 +101,19 @@ func (arg PlsType) GetOra(src, varName string) string {
 		}
 		//return fmt.Sprintf("string(%s.(godror.Number))", src)
 		return fmt.Sprintf("custom.AsString(%s)", src)
+	case "DATE", "TIMESTAMP", typeTimestampTZ, typeTimestampLTZ:
+		if ProtoAPIv2 {
+			return fmt.Sprintf("custom.AsTimestamp(%s)", src)
+		}
+		if SQLOnly {
+			return fmt.Sprintf("custom.AsTime(%s)", src)
+		}
+		return fmt.Sprintf("custom.AsDate(%s)", src)
+	case typeIntervalDS:
+		if ProtoAPIv2 {
+			return fmt.Sprintf("custom.AsDurationProto(%s)", src)
+		}
+		return fmt.Sprintf("custom.AsDuration(%s)", src)
 	}
 	return src
 }
@@ -92,7 +134,42 @@ func (arg PlsType) ToOra(dst, src string, dir direction) (expr string, variable
 		if src[0] != '&' {
 			return fmt.Sprintf("%s := godror.Number(%s); %s = %s", dstVar, src, dst, dstVar), dstVar
 		}
-	case "CLOB":
+	case "DATE", "TIMESTAMP", typeTimestampTZ, typeTimestampLTZ:
+		// the *timestamppb.Timestamp cannot be bound, only a time.Time
+		if ProtoAPIv2 {
+			if src[0] != '&' {
+				return fmt.Sprintf("%s := custom.TimeFromTimestamp(%s); %s = %s", dstVar, src, dst, dstVar), dstVar
+			}
+			return fmt.Sprintf("%s := custom.TimeFromTimestamp(%s); %s = sql.Out{Dest:&%s%s} // %s",
+				dstVar, src[1:], dst, dstVar, inTrue, arg.Name), dstVar
+		}
+	case typeIntervalDS:
+		// the *durationpb.Duration cannot be bound, only a time.Duration
+		if ProtoAPIv2 {
+			if src[0] != '&' {
+				return fmt.Sprintf("%s := custom.AsDuration(%s); %s = %s", dstVar, src, dst, dstVar), dstVar
+			}
+			return fmt.Sprintf("%s := custom.AsDuration(%s); %s = sql.Out{Dest:&%s%s} // %s",
+				dstVar, src[1:], dst, dstVar, inTrue, arg.Name), dstVar
+		}
+	case "BOOLEAN", "PL/SQL BOOLEAN":
+		// bound as a number, see NativeBoolean
+		if NativeBoolean {
+			break
+		}
+		if !dir.IsOutput() {
+			return fmt.Sprintf("var %s int32; if %s { %s = 1 }; %s = %s", dstVar, src, dstVar, dst, dstVar), ""
+		}
+		if !dir.IsInput() {
+			return fmt.Sprintf("var %s sql.NullInt32; %s = sql.Out{Dest:&%s}", dstVar, dst, dstVar), dstVar
+		}
+		return fmt.Sprintf("%s := sql.NullInt32{Valid:true}; if %s { %s.Int32 = 1 }; %s = sql.Out{Dest:&%s,In:true}",
+			dstVar, strings.TrimPrefix(src, "&"), dstVar, dst, dstVar), dstVar
+	case "BLOB":
+		if dir.IsOutput() {
+			return fmt.Sprintf("%s := godror.Lob{}; %s = sql.Out{Dest:&%s}", dstVar, dst, dstVar), dstVar
+		}
+	case "CLOB", "NCLOB":
 		if dir.IsOutput() {
 			return fmt.Sprintf("%s := godror.Lob{IsClob:true}; %s = sql.Out{Dest:&%s}", dstVar, dst, dstVar), dstVar
 		}
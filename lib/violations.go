@@ -0,0 +1,37 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package genocall
+
+import "strings"
+
+// FieldViolation is an input field out of its bounds, as found by a generated Check function.
+type FieldViolation struct {
+	// Field is the path of the field in the input message, such as p_rec.items[2].name
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Violations are all the violations of an input, as returned by a generated Check function.
+//
+// It is an ErrInvalidArgument.
+type Violations []FieldViolation
+
+func (vv Violations) Error() string {
+	var buf strings.Builder
+	for i, v := range vv {
+		if i != 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(v.Field)
+		buf.WriteString(": ")
+		buf.WriteString(v.Description)
+	}
+	buf.WriteString(": ")
+	buf.WriteString(ErrInvalidArgument.Error())
+	return buf.String()
+}
+
+// Unwrap returns ErrInvalidArgument.
+func (vv Violations) Unwrap() error { return ErrInvalidArgument }
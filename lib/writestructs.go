@@ -14,6 +14,7 @@ import (
 	"io/ioutil"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -25,27 +26,77 @@ import (
 var ErrMissingTableOf = errors.New("missing TableOf info")
 var ErrInvalidArgument = errors.New("invalid argument")
 
+// SaveFunctions writes the calling machinery of all the functions into one Go file.
+//
+// With SQLOnly, the structs and the Client are written, too.
 func SaveFunctions(dst io.Writer, functions []Function, pkg, pbImport string, saveStructs bool) error {
-	var err error
-	w := errWriter{Writer: dst, err: &err}
-
 	if pkg != "" {
-		if pbImport != "" {
-			pbImport = `pb "` + pbImport + `"`
+		if err := saveFunctionsHeader(dst, functions, pkg, pbImport, "", append(sessionImports(), httpImports()...)...); err != nil {
+			return err
 		}
-		var lastDDL time.Time
-		for _, f := range functions {
-			if f.LastDDL.After(lastDDL) {
-				lastDDL = f.LastDDL
+		if err := saveFunctionsCommon(dst, functions); err != nil {
+			return err
+		}
+		if SQLOnly {
+			if _, err := io.WriteString(dst, clientCommon); err != nil {
+				return err
 			}
 		}
-		if lastDDL.IsZero() {
-			lastDDL = time.Now()
+	}
+	if err := saveFunctions(dst, functions, saveStructs); err != nil {
+		return err
+	}
+	if SQLOnly {
+		return SaveStructsPackage(dst, functions, nil)
+	}
+	return nil
+}
+
+// SaveFunctionsCommon writes the declarations shared by the files written by SaveFunctionsPackage:
+// the server type, its constructor and the logging knobs.
+func SaveFunctionsCommon(dst io.Writer, functions []Function, pkg string) error {
+	if err := saveFunctionsHeader(dst, nil, pkg, "", "", append(sessionImports(), httpImports()...)...); err != nil {
+		return err
+	}
+	return saveFunctionsCommon(dst, functions)
+}
+
+// SaveFunctionsPackage writes the calling machinery of the functions (of one package),
+// without the declarations written by SaveFunctionsCommon.
+//
+// The fingerprint (see Fingerprint) is recorded in the header, next to the DDL times.
+func SaveFunctionsPackage(dst io.Writer, functions []Function, pkg, pbImport, fingerprint string, saveStructs bool) error {
+	if len(functions) == 0 {
+		pbImport = ""
+	}
+	var imports []string
+	if ProtoAPIv2 && !SQLOnly {
+		imports = append(imports, "google.golang.org/protobuf/proto") // proto.Clone of the IN OUT records
+	}
+	if err := saveFunctionsHeader(dst, functions, pkg, pbImport, fingerprint, imports...); err != nil {
+		return err
+	}
+	if len(imports) != 0 {
+		if _, err := io.WriteString(dst, "var _ = proto.Clone\n"); err != nil {
+			return err
 		}
-		io.WriteString(w,
-			// https://github.com/golang/go/issues/13560#issuecomment-288457920
-			`// Code generated by gen-o-call, DO NOT EDIT.
+	}
+	return saveFunctions(dst, functions, saveStructs)
+}
 
+func saveFunctionsHeader(dst io.Writer, functions []Function, pkg, pbImport, fingerprint string, imports ...string) error {
+	var err error
+	w := errWriter{Writer: dst, err: &err}
+	if pbImport != "" {
+		pbImport = `pb "` + pbImport + `"`
+	}
+	for _, imp := range imports {
+		pbImport += "\n\t\"" + imp + "\""
+	}
+	// https://github.com/golang/go/issues/13560#issuecomment-288457920
+	io.WriteString(w, "// Code generated by gen-o-call, DO NOT EDIT.\n\n")
+	writeLastDDLs(w, functions, fingerprint)
+	_, err = io.WriteString(w, `
 package `+pkg+`
 
 import (
@@ -72,10 +123,6 @@ import (
 	`+pbImport+`
 )
 
-var DebugLevel = uint(0)
-
-const LastDDL = "`+lastDDL.Format(time.RFC3339)+`"
-
 // against "unused import" error
 var _ json.Marshaler
 var _ = io.EOF
@@ -85,7 +132,6 @@ var _ strconv.NumError
 var _ time.Time
 var _ strings.Reader
 var _ xml.Name
-var Log = func(keyvals ...interface{}) error { return nil } // logger.Log of github.com/go-kit/kit/log
 var _ = errors.New
 var _ = fmt.Printf
 var _ godror.Lob
@@ -95,23 +141,83 @@ var _ = os.Stdout
 var _ driver.Rows
 var _ = genocall.ErrInvalidArgument
 var _ = ioutil.ReadAll
+var _ sql.Result
+`)
+	return err
+}
+
+func saveFunctionsCommon(dst io.Writer, functions []Function) error {
+	var lastDDL time.Time
+	for _, f := range functions {
+		if f.LastDDL.After(lastDDL) {
+			lastDDL = f.LastDDL
+		}
+	}
+	// an unknown DDL time stays the zero time, so the output is reproducible
+	_, err := io.WriteString(dst, `
+var DebugLevel = uint(0)
+
+const LastDDL = "`+lastDDL.Format(time.RFC3339)+`"
+
+var Log = func(keyvals ...interface{}) error { return nil } // logger.Log of github.com/go-kit/kit/log
 
 type iterator struct {
 	Reset func()
 	Iterate func() error
+	// Tag puts the batch of a tagged cursor into the output
+	Tag func(last bool)
+}
+
+// Preparer is what the functions are called (and DBLog logs) on: a *sql.DB, *sql.Conn or *sql.Tx.
+type Preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
 type genocallServer struct {
 	db *sql.DB
-	DBLog func(context.Context, *sql.DB, string, interface{}) error
+	conn Preparer
+	sessions *sessionPool
+	DBLog func(context.Context, Preparer, string, interface{}) error
+	// SessionKey identifies the client, for pinning it to a session
+	// for the functions of the stateful packages.
+	SessionKey func(context.Context) string
+	// BatchSize returns the maximum number of rows in a batch of the REF CURSOR outputs
+	// of the function (named as "pkg.fn", in lowercase), instead of its default (0 keeps that).
+	BatchSize func(funName string) int
+	// MaxMsgSize is the maximum size of an output message (the MaxSendMsgSize of the gRPC server):
+	// the batches of the REF CURSOR outputs are cut to fit into it.
+	MaxMsgSize int
 }
 
-func NewServer(db *sql.DB, dbLog func(context.Context, *sql.DB, string, interface{}) error) *genocallServer {
-	return &genocallServer{db: db, DBLog: dbLog}
+func NewServer(db *sql.DB, dbLog func(context.Context, Preparer, string, interface{}) error) *genocallServer {
+	return &genocallServer{db: db, conn: db, DBLog: dbLog,
+		sessions: &sessionPool{m: make(map[string]*session)}, SessionKey: sessionKey}
 }
 
+// WithTx returns a server calling the functions in tx,
+// so they commit or roll back together.
+func (s *genocallServer) WithTx(tx *sql.Tx) *genocallServer {
+	s2 := *s
+	s2.conn = tx
+	return &s2
+}
+
+// WithConn returns a server calling the functions on conn,
+// so they share the session (and the package state).
+func (s *genocallServer) WithConn(conn *sql.Conn) *genocallServer {
+	s2 := *s
+	s2.conn = conn
+	return &s2
+}
+`+batchCommon+sessionCommon()+httpCommon()+`
 `)
-	}
+	return err
+}
+
+func saveFunctions(dst io.Writer, functions []Function, saveStructs bool) error {
+	var err error
+	w := errWriter{Writer: dst, err: &err}
 	types := make(map[string]string, 16)
 	inits := make([]string, 0, len(functions))
 	var b []byte
@@ -132,6 +238,9 @@ FunLoop:
 				return err
 			}
 		}
+		if checkName, err = fun.GenChecks(w); err != nil {
+			return err
+		}
 		plsBlock, callFun := fun.PlsqlBlock(checkName)
 		fmt.Fprintf(w, "\nconst %s = `", fun.getPlsqlConstName())
 		io.WriteString(w, plsBlock)
@@ -144,6 +253,7 @@ FunLoop:
 			return fmt.Errorf("error saving function %s: %s", fun.FullName(), err)
 		}
 		w.Write(b)
+		inits = append(inits, fun.httpInit())
 	}
 	for tn, text := range types {
 		if tn[0] == '+' { // REF CURSOR skip
@@ -163,55 +273,65 @@ FunLoop:
 	_, err = io.WriteString(w, "}\n")
 	return err
 }
-func SaveFunctionTests(dst io.Writer, functions []Function, pkg, pbImport string, saveStructs bool) error {
-	var err error
-	w := errWriter{Writer: dst, err: &err}
 
+// SaveFunctionTests writes the tests of all the functions into one Go test file.
+func SaveFunctionTests(dst io.Writer, functions []Function, pkg, pbImport string, saveStructs bool) error {
 	if pkg != "" {
-		if pbImport != "" {
-			pbImport = `pb "` + pbImport + `"`
-		}
-		var lastDDL time.Time
-		for _, f := range functions {
-			if f.LastDDL.After(lastDDL) {
-				lastDDL = f.LastDDL
-			}
-		}
-		if lastDDL.IsZero() {
-			lastDDL = time.Now()
+		if err := saveFunctionTestsCommon(dst, pkg, pbImport); err != nil {
+			return err
 		}
-		io.WriteString(w,
-			// https://github.com/golang/go/issues/13560#issuecomment-288457920
-			`// Code generated by genocall, DO NOT EDIT.
+	}
+	return saveFunctionTests(dst, functions, CamelCase(pkg))
+}
+
+// SaveFunctionTestsCommon writes the test setup shared by the files written by SaveFunctionTestsPackage.
+func SaveFunctionTestsCommon(dst io.Writer, pkg string) error {
+	return saveFunctionTestsCommon(dst, pkg, "")
+}
+
+func saveFunctionTestsCommon(dst io.Writer, pkg, pbImport string) error {
+	if pbImport != "" {
+		pbImport = "\tpb \"" + pbImport + "\"\n"
+	}
+	_, err := io.WriteString(dst,
+		// https://github.com/golang/go/issues/13560#issuecomment-288457920
+		`// Code generated by genocall, DO NOT EDIT.
 
 package `+pkg+`
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
-    "time"
-	"os"
-	"bytes"
 	"flag"
+	"os"
 	"sync"
 	"testing"
+	"time"
 
 	errors "golang.org/x/xerrors"
 
 	"github.com/go-logfmt/logfmt"
 
 	_ "github.com/godror/godror" // Oracle
-	`+pbImport+`
-)
+`+pbImport+`)
+
+// against "unused import" error
+var _ context.Context
+var _ json.Marshaler
+var _ time.Time
 
 var (
 	connectOnce sync.Once
 	flagConnect = flag.String("connect", "", "database to connect to")
-	testDB *sql.DB
-	testServer *genocallServer
+	testDB      *sql.DB
+	testServer  *genocallServer
 )
 
+// TestFunctions are the test functions, by the function's name.
+var TestFunctions = make(map[string]func(t *testing.T, jsonText []byte))
+
 func testSetup(t *testing.T) *genocallServer {
 	connectOnce.Do(func() {
 		flag.Parse()
@@ -258,7 +378,42 @@ func TestCalls(t *testing.T) {
 	}
 }
 `)
+	return err
+}
+
+// SaveFunctionTestsPackage writes the tests of the functions (of one package),
+// registering them in TestFunctions, declared by SaveFunctionTestsCommon.
+func SaveFunctionTestsPackage(dst io.Writer, functions []Function, pkg, pbImport string) error {
+	if _, err := io.WriteString(dst, `// Code generated by genocall, DO NOT EDIT.
+
+package `+pkg+`
+`); err != nil {
+		return err
+	}
+	if len(functions) == 0 {
+		return nil
+	}
+	if pbImport != "" {
+		pbImport = "\n\tpb \"" + pbImport + "\"\n"
+	}
+	if _, err := io.WriteString(dst, `
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+`+pbImport+`)
+`); err != nil {
+		return err
 	}
+	return saveFunctionTests(dst, functions, CamelCase(functions[0].Package))
+}
+
+// saveFunctionTests writes the test functions and registers them in TestFunctions
+// by their name, and by their name prefixed with prefix.
+func saveFunctionTests(dst io.Writer, functions []Function, prefix string) error {
+	var err error
+	w := errWriter{Writer: dst, err: &err}
 	FN := func(f Function) string {
 		fn := f.AliasedName()
 		return CamelCase(strings.Replace(fn, ".", "__", -1))
@@ -269,41 +424,49 @@ func TestCalls(t *testing.T) {
 		structName := CamelCase(f.getStructName(false, false))
 		fn := FN(f)
 
+		call := fmt.Sprintf(`output, err := srv.%s(ctx, &input)
+	t.Log(output)
+	if err != nil {
+		t.Error(err)
+	}`, fn)
+		if SQLOnly && f.HasCursorOut() {
+			call = fmt.Sprintf(`it := newIterator(ctx, func(stream *iteratorStream[%s]) error {
+		return srv.%s(&input, stream)
+	})
+	defer it.Close()
+	for it.Next() {
+		t.Log(it.Output())
+	}
+	if err := it.Err(); err != nil {
+		t.Error(err)
+	}`, CamelCase(f.getStructName(true, false)), fn)
+		}
+
 		fmt.Fprintf(w, `
 func test%s(t *testing.T, jsonText []byte) {
 	srv := testSetup(t)
-	var input pb.%s
+	var input %s
 	if err := json.Unmarshal(jsonText, &input); err != nil {
 		t.Fatal(err)
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
-	output, err := srv.%s(ctx, &input)
-	t.Log(output)
-	if err != nil {
-		t.Error(err)
-	}
+	%s
 }
-
 `,
 			fn,
-			structName,
-			fn,
+			withPb(structName),
+			call,
 		)
 		funNames = append(funNames, fn)
 	}
-	io.WriteString(w, `
-var TestFunctions = map[string]func(t *testing.T, jsonText []byte) {
-	`)
-	Pkg := CamelCase(pkg)
+	io.WriteString(w, "\nfunc init() {\n")
 	for _, fn := range funNames {
-		fmt.Fprintf(w, "\t"+`"%s": test%s,`+"\n", fn, fn)
-		fmt.Fprintf(w, "\t"+`"%s_%s": test%s,`+"\n", Pkg, fn, fn)
+		fmt.Fprintf(w, "\t"+`TestFunctions["%s"] = test%s`+"\n", fn, fn)
+		fmt.Fprintf(w, "\t"+`TestFunctions["%s_%s"] = test%s`+"\n", prefix, fn, fn)
 	}
-	io.WriteString(w, `
-}
-`)
-	return nil
+	io.WriteString(w, "}\n")
+	return err
 }
 
 func (f Function) getPlsqlConstName() string {
@@ -400,6 +563,10 @@ func (f Function) SaveStruct(dst io.Writer, out bool) error {
 	return err
 }
 
+// GenChecks writes the Check function of the function's input, and returns its name
+// (empty if there is nothing to check).
+//
+// The Check function returns all the violations at once, as genocall.Violations.
 func (f Function) GenChecks(w io.Writer) (string, error) {
 	args := make([]Argument, 0, len(f.Args))
 	for _, arg := range f.Args {
@@ -409,26 +576,27 @@ func (f Function) GenChecks(w io.Writer) (string, error) {
 	}
 	checks := make([]string, 0, len(args)+1)
 	for _, arg := range args {
-		checks = genChecks(checks, arg, "s", false)
+		checks = genChecks(checks, arg, "s", `""`, false)
 	}
-	if len(checks) == 0 {
+	if !hasCheck(checks) {
 		return "", nil
 	}
-	structName := CamelCase(strings.SplitN(f.getStructName(false, true), "__", 2)[1])
+	structName := CamelCase(f.getStructName(false, false))
 	buf := Buffers.Get()
 	defer Buffers.Put(buf)
 	nm := "Check" + structName
 	fmt.Fprintf(buf, `
-// %s checks input bounds for pb.%s
-func %s(s *pb.%s) error {
+// %s checks input bounds for %s
+func %s(s *%s) error {
+	var vv genocall.Violations
 	`,
-		nm, structName,
-		nm, structName,
+		nm, withPb(structName),
+		nm, withPb(structName),
 	)
 	for _, line := range checks {
-		fmt.Fprintf(buf, line+"\n")
+		io.WriteString(buf, line+"\n")
 	}
-	if _, err := io.WriteString(buf, "\n\treturn nil\n}\n"); err != nil {
+	if _, err := io.WriteString(buf, "\n\tif len(vv) != 0 {\n\t\treturn vv\n\t}\n\treturn nil\n}\n"); err != nil {
 		return "", err
 	}
 	b, err := format.Source(buf.Bytes())
@@ -439,7 +607,19 @@ func %s(s *pb.%s) error {
 	return nm, err
 }
 
-func genChecks(checks []string, arg Argument, base string, parentIsTable bool) []string {
+// hasCheck reports whether the lines of genChecks check anything.
+func hasCheck(checks []string) bool {
+	for _, line := range checks {
+		if strings.Contains(line, "genocall.FieldViolation{") {
+			return true
+		}
+	}
+	return false
+}
+
+// genChecks appends the checks of arg (base is its parent) to checks,
+// where path is the Go expression of the field path of its parent.
+func genChecks(checks []string, arg Argument, base, path string, parentIsTable bool) []string {
 	aName := (CamelCase(arg.Name))
 	//aName := capitalize(replHidden(arg.Name))
 	got, err := arg.goType(parentIsTable || arg.Flavor == FLAVOR_TABLE)
@@ -451,45 +631,64 @@ func genChecks(checks []string, arg Argument, base string, parentIsTable bool) [
 		name = base
 	} else {
 		name = base + "." + aName
+		fName := arg.Name
+		if strings.HasSuffix(fName, "#") {
+			fName = replHidden(fName)
+		}
+		if path == `""` {
+			path = strconv.Quote(fName)
+		} else if strings.HasSuffix(path, `"`) {
+			path = path[:len(path)-1] + "." + fName + `"`
+		} else {
+			path += ` + ".` + fName + `"`
+		}
+	}
+	violation := func(format string, args ...interface{}) string {
+		return fmt.Sprintf("vv = append(vv, genocall.FieldViolation{Field: %s, Description: %s})",
+			path, fmt.Sprintf(format, args...))
 	}
 	switch arg.Flavor {
 	case FLAVOR_SIMPLE:
+		if arg.Type == typeIntervalYM {
+			checks = append(checks,
+				fmt.Sprintf(`if err := genocall.ParseIntervalYM(%s); err != nil {
+		%s
+    }`,
+					name, violation("err.Error()")))
+			break
+		}
 		switch got {
 		case "string":
+			if arg.Charlength == 0 { // LOBs, LONGs: no limit
+				break
+			}
 			checks = append(checks,
 				fmt.Sprintf(`if len(%s) > %d {
-		return errors.Errorf("%s is longer than accepted (%d): %%w", genocall.ErrInvalidArgument)
+		%s
     }`,
-					name, arg.Charlength, name, arg.Charlength))
+					name, arg.Charlength, violation(`"longer than accepted (%d)"`, arg.Charlength)))
 		case "*string":
 			checks = append(checks,
 				fmt.Sprintf(`if %s != nil && len(*%s) > %d {
-		return errors.Errorf("%s is longer than accepted (%d): %%w", genocall.ErrInvalidArgument)
+		%s
     }`,
 					name, name, arg.Charlength,
-					name, arg.Charlength))
-		case "sql.NullString":
+					violation(`"longer than accepted (%d)"`, arg.Charlength)))
+		case "sql.NullString", "NullString":
 			checks = append(checks,
 				fmt.Sprintf(`if %s.Valid && len(%s.String) > %d {
-		return errors.Errorf("%s is longer than accepted (%d): %%w", genocall.ErrInvalidArgument)
+		%s
     }`,
 					name, name, arg.Charlength,
-					name, arg.Charlength))
-		case "NullString":
-			checks = append(checks,
-				fmt.Sprintf(`if %s.Valid && len(%s.String) > %d {
-		return errors.Errorf("%s is longer than accepted (%d): %%w", genocall.ErrInvalidArgument)
-    }`,
-					name, name, arg.Charlength,
-					name, arg.Charlength))
+					violation(`"longer than accepted (%d)"`, arg.Charlength)))
 		case "godror.Number":
 			checks = append(checks,
 				fmt.Sprintf(
-					`if err := genocall.ParseDigits(%s, %d, %d); err != nil {
-						return errors.Errorf("%s: %%w", genocall.ErrInvalidArgument)
+					`if err := genocall.ParseDigits(string(%s), %d, %d); err != nil {
+						%s
 					}`,
 					name, arg.Precision, arg.Scale,
-					name))
+					violation("err.Error()")))
 
 		case "int32": // no check is needed
 		case "int64", "float64":
@@ -497,10 +696,10 @@ func genChecks(checks []string, arg Argument, base string, parentIsTable bool) [
 				cons := strings.Repeat("9", int(arg.Precision))
 				checks = append(checks,
 					fmt.Sprintf(`if (%s <= -%s || %s > %s) {
-		return errors.Errorf("%s is out of bounds (-%s..%s): %%w", genocall.ErrInvalidArgument)
+		%s
     }`,
 						name, cons, name, cons,
-						name, cons, cons))
+						violation(`"out of bounds (-%s..%s)"`, cons, cons)))
 			}
 		case "NullInt64", "NullFloat64", "sql.NullInt64", "sql.NullFloat64":
 			if arg.Precision > 0 {
@@ -508,38 +707,50 @@ func genChecks(checks []string, arg Argument, base string, parentIsTable bool) [
 				cons := strings.Repeat("9", int(arg.Precision))
 				checks = append(checks,
 					fmt.Sprintf(`if %s.Valid && (%s.%s <= -%s || %s.%s > %s) {
-		return errors.Errorf("%s is out of bounds (-%s..%s): %%w", genocall.ErrInvalidArgument)
+		%s
     }`,
 						name, name, vn, cons, name, vn, cons,
-						name, cons, cons))
+						violation(`"out of bounds (-%s..%s)"`, cons, cons)))
 			}
 
 		default:
 			checks = append(checks, fmt.Sprintf("// No check for %q (%q)", arg.Name, got))
 		}
 	case FLAVOR_RECORD:
+		var sub []string
+		for _, s := range arg.RecordOf {
+			sub = genChecks(sub, *s.Argument, name, path, arg.Flavor == FLAVOR_TABLE) //parentIsTable || sub.Flavor == FLAVOR_TABLE)
+		}
+		if !hasCheck(sub) {
+			return checks
+		}
 		if parentIsTable || got[0] == '*' {
 			checks = append(checks, "if "+name+" != nil {")
 		}
-		for _, sub := range arg.RecordOf {
-			checks = genChecks(checks, *sub.Argument, name, arg.Flavor == FLAVOR_TABLE) //parentIsTable || sub.Flavor == FLAVOR_TABLE)
-		}
+		checks = append(checks, sub...)
 		if parentIsTable || got[0] == '*' {
 			checks = append(checks, "}")
 		}
 	case FLAVOR_TABLE:
+		elem := *arg.TableOf
+		if elem.Flavor == FLAVOR_TABLE {
+			elem = arg.itemsArg()
+		}
+		sub := genChecks(nil, elem, "v", "path", true)
+		if !hasCheck(sub) {
+			return checks
+		}
 		if got[0] == '*' {
 			checks = append(checks, fmt.Sprintf("if %s != nil {  // genChecks[T] %q", name, got))
 		}
-		plus := strings.Join(
-			genChecks(nil, *arg.TableOf, "v", true),
-			"\n\t")
-		if len(strings.TrimSpace(plus)) > 0 {
-			checks = append(checks,
-				fmt.Sprintf("\tfor _, v := range %s.%s {\n\t%s\n}",
-					base, aName,
-					plus))
+		idx, key := "i", "strconv.Itoa(i)"
+		if arg.isMap() {
+			idx, key = "k", "strconv.Quote(k)"
 		}
+		checks = append(checks,
+			fmt.Sprintf("\tfor %s, v := range %s.%s {\n\tpath := %s + \"[\" + %s + \"]\"\n\t%s\n}",
+				idx, base, aName, path, key,
+				strings.Join(sub, "\n\t")))
 		if got[0] == '*' {
 			checks = append(checks, "}")
 		}
@@ -583,13 +794,13 @@ func (arg *Argument) goType(isTable bool) (typName string, err error) {
 	}()
 	if arg.Flavor == FLAVOR_SIMPLE {
 		switch arg.Type {
-		case "CHAR", "VARCHAR2", "ROWID":
+		case "CHAR", "NCHAR", "VARCHAR2", "NVARCHAR2", "ROWID", "UROWID", "LONG", typeIntervalYM:
 			if !isTable && arg.IsOutput() {
 				//return "*string", nil
 				return "string", nil
 			}
 			return "string", nil // NULL is the same as the empty string for Oracle
-		case "RAW":
+		case "RAW", "LONG RAW":
 			return "[]byte", nil
 		case "NUMBER":
 			return "godror.Number", nil
@@ -605,44 +816,37 @@ func (arg *Argument) goType(isTable bool) (typName string, err error) {
 			}
 			return "int32", nil
 		case "BOOLEAN", "PL/SQL BOOLEAN":
-			if !isTable && arg.IsOutput() {
-				return "*bool", nil
-			}
 			return "bool", nil
-		case "DATE", "DATETIME", "TIME", "TIMESTAMP":
+		case "DATE", "DATETIME", "TIME", "TIMESTAMP", typeTimestampTZ, typeTimestampLTZ:
 			return "time.Time", nil
+		case typeIntervalDS:
+			return "time.Duration", nil
 		case "REF CURSOR":
 			return "*sql.Rows", nil
 		case "BLOB":
 			return "[]byte", nil
-		case "CLOB":
+		case "CLOB", "NCLOB":
 			return "string", nil
-		case "BFILE":
-			return "ora.Bfile", nil
 		default:
 			return "", errors.Errorf("%v: %w", arg, UnknownSimpleType)
 		}
 	}
-	typName = arg.TypeName
-	chunks := strings.Split(typName, ".")
-	switch len(chunks) {
-	case 1:
-	case 2:
-		typName = chunks[1] + "__" + chunks[0]
-	default:
-		typName = strings.Join(chunks[1:], "__") + "__" + chunks[0]
-	}
-	//typName = goName(capitalize(typName))
-	typName = capitalize(typName)
+	typName = goTypeName(arg.TypeName)
 
 	if arg.Flavor == FLAVOR_TABLE {
 		//Log("msg", "TABLE", "arg", arg, "tableOf", arg.TableOf)
 		targ := *arg.TableOf
 		targ.Direction = DIR_IN
-		tn, err := targ.goType(true)
-		if err != nil {
+		var tn string
+		if targ.Flavor == FLAVOR_TABLE {
+			// the message holding the table (see itemsArg)
+			tn = "*" + goTypeName(targ.TypeName)
+		} else if tn, err = targ.goType(true); err != nil {
 			return tn, err
 		}
+		if arg.isMap() {
+			return "map[string]" + tn, nil
+		}
 		tn = "[]" + tn
 		if arg.Type != "REF CURSOR" {
 			if arg.IsOutput() && arg.TableOf.Flavor == FLAVOR_SIMPLE {
@@ -665,6 +869,20 @@ func (arg *Argument) goType(isTable bool) (typName string, err error) {
 	return "*" + typName, nil
 }
 
+// goTypeName returns the name of the struct of the (OWNER.PACKAGE.NAME) type.
+func goTypeName(typName string) string {
+	chunks := strings.Split(typName, ".")
+	switch len(chunks) {
+	case 1:
+	case 2:
+		typName = chunks[1] + "__" + chunks[0]
+	default:
+		typName = strings.Join(chunks[1:], "__") + "__" + chunks[0]
+	}
+	//typName = goName(capitalize(typName))
+	return capitalize(typName)
+}
+
 func replHidden(text string) string {
 	if text == "" {
 		return text
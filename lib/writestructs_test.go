@@ -8,9 +8,20 @@ package genocall
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"errors"
 	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
 	"testing"
+	"time"
 )
 
 var flagKeep = flag.Bool("keep", false, "keep temp files")
@@ -68,3 +79,253 @@ func TestJSONPlsqlBlock(t *testing.T) {
 		t.Log(plsBlock, callFun)
 	}
 }
+
+func TestSaveFunctionsPackage(t *testing.T) {
+	functions := twoPackageFunctions(t)
+	files := make(map[string][]byte)
+	save := func(name string, save func(*bytes.Buffer) error) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := save(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if b, err := format.Source(buf.Bytes()); err != nil {
+			t.Errorf("%s: %+v\n%s", name, err, buf.String())
+		} else if strings.HasSuffix(name, "_test.go") && !bytes.Equal(b, buf.Bytes()) {
+			t.Errorf("%s is not gofmt'd:\n%s", name, buf.String())
+		}
+		files[name] = buf.Bytes()
+		return buf.String()
+	}
+
+	common := save("genocall.go", func(buf *bytes.Buffer) error {
+		return SaveFunctionsCommon(buf, functions, "snap")
+	})
+	if !strings.Contains(common, "type genocallServer struct") {
+		t.Error("genocallServer is not in the common file")
+	}
+	for _, f := range functions {
+		if strings.Contains(common, "func (s *genocallServer) "+CamelCase(f.AliasedName())+"(") {
+			t.Errorf("%s is in the common file", f.Name)
+		}
+	}
+	for _, want := range []string{"func (s *genocallServer) WithTx(tx *sql.Tx) *genocallServer", "func (s *genocallServer) WithConn(conn *sql.Conn) *genocallServer"} {
+		if !strings.Contains(common, want) {
+			t.Errorf("%q is not in the common file", want)
+		}
+	}
+	save("genocall_test.go", func(buf *bytes.Buffer) error {
+		return SaveFunctionTestsCommon(buf, "snap")
+	})
+
+	// The functions' bodies need the real Protocol Buffers messages,
+	// so just their signatures are type checked, with the tests.
+	var pbStub, srvStub strings.Builder
+	pbStub.WriteString("package pb\n")
+	srvStub.WriteString("package snap\nimport (\n\t\"context\"\n\tpb \"pb\"\n)\n")
+	for _, f := range functions {
+		name := strings.ToLower(f.Package) + "_genocall"
+		s := save(name+".go", func(buf *bytes.Buffer) error {
+			return SaveFunctionsPackage(buf, []Function{f}, "snap", "pb", "fp", false)
+		})
+		delete(files, name+".go")
+		if strings.Contains(s, "type genocallServer struct") {
+			t.Errorf("%s: genocallServer is declared", name)
+		}
+		method := CamelCase(f.AliasedName())
+		if !strings.Contains(s, "func (s *genocallServer) "+method+"(") {
+			t.Errorf("%s: %s is missing", name, method)
+		}
+		// logged on the connection (or transaction) the function is called on
+		if !strings.Contains(s, "s.DBLog(ctx, conn, funName, input)") {
+			t.Errorf("%s: DBLog is not called on conn", name)
+		}
+		if want := `httpRoutes["/` + strings.ToLower(f.Package+"/"+f.Name) + `"] = httpUnary((*genocallServer).` + method + ")"; !strings.Contains(s, want) {
+			t.Errorf("%s: %s is not registered for HTTPHandler", name, f.Name)
+		}
+		if ddls, fingerprint, err := ReadLastDDLs(strings.NewReader(s)); err != nil {
+			t.Error(err)
+		} else if len(ddls) != 1 || ddls[f.Package].IsZero() || fingerprint != "fp" {
+			t.Errorf("%s: got %v (%q), wanted the DDL time of %s only (and fp)", name, ddls, fingerprint, f.Package)
+		}
+		s = save(name+"_test.go", func(buf *bytes.Buffer) error {
+			return SaveFunctionTestsPackage(buf, []Function{f}, "snap", "pb")
+		})
+		if !strings.Contains(s, `TestFunctions["`+CamelCase(f.Package)+"_"+CamelCase(f.Name)+`"]`) {
+			t.Errorf("%s: test function is not registered\n%s", name, s)
+		}
+		in, out := CamelCase(f.getStructName(false, false)), CamelCase(f.getStructName(true, false))
+		fmt.Fprintf(&pbStub, "type %s struct{}\ntype %s struct{}\n", in, out)
+		fmt.Fprintf(&srvStub, "func (s *genocallServer) %s(ctx context.Context, input *pb.%s) (*pb.%s, error) { return nil, nil }\n",
+			method, in, out)
+	}
+	files["server_stub.go"] = []byte(srvStub.String())
+	// a package without any function
+	save("empty_genocall.go", func(buf *bytes.Buffer) error {
+		return SaveFunctionsPackage(buf, nil, "snap", "pb", "fp", false)
+	})
+	save("empty_genocall_test.go", func(buf *bytes.Buffer) error {
+		return SaveFunctionTestsPackage(buf, nil, "snap", "pb")
+	})
+
+	imp := &stubImporter{fset: token.NewFileSet(), std: importer.Default(), stubs: map[string]string{
+		"pb":                   pbStub.String(),
+		"golang.org/x/xerrors": "package xerrors\nfunc New(string) error { return nil }\nfunc Errorf(string, ...interface{}) error { return nil }\nfunc Is(error, error) bool { return false }\nfunc As(error, interface{}) bool { return false }",
+		"google.golang.org/grpc/metadata": `package metadata
+import "context"
+type MD map[string][]string
+func (MD) Get(string) []string { return nil }
+func FromIncomingContext(context.Context) (MD, bool) { return nil, false }`,
+		"github.com/davecgh/go-spew/spew":     "package spew\nfunc Sdump(...interface{}) string { return \"\" }",
+		"github.com/godror/godror":            "package godror\ntype Lob struct{}",
+		"github.com/godror/gen-o-call/custom": "package custom\nfunc AsDate(interface{}) interface{} { return nil }",
+		"github.com/godror/gen-o-call/lib":    "package genocall\nvar ErrInvalidArgument error\ntype FieldViolation struct{ Field, Description string }\ntype Violations []FieldViolation\nconst DefaultMaxMsgSize = 4 << 20\nfunc ReleaseSession(interface{}, interface{}) error { return nil }\nfunc HTTPStatus(error) int { return 0 }",
+		"github.com/go-logfmt/logfmt": `package logfmt
+import "io"
+type Decoder struct{}
+func NewDecoder(io.Reader) *Decoder { return nil }
+func (*Decoder) ScanRecord() bool { return false }
+func (*Decoder) ScanKeyval() bool { return false }
+func (*Decoder) Key() []byte { return nil }
+func (*Decoder) Value() []byte { return nil }
+func (*Decoder) Err() error { return nil }`,
+	}}
+	if _, err := imp.check("snap", files); err != nil {
+		t.Error(err)
+	}
+}
+
+// stubImporter imports the standard library, and the stubs given as source.
+type stubImporter struct {
+	fset  *token.FileSet
+	std   types.Importer
+	stubs map[string]string
+	pkgs  map[string]*types.Package
+}
+
+func (imp *stubImporter) Import(path string) (*types.Package, error) {
+	if p := imp.pkgs[path]; p != nil {
+		return p, nil
+	}
+	src, ok := imp.stubs[path]
+	if !ok {
+		return imp.std.Import(path)
+	}
+	p, err := imp.check(path, map[string][]byte{path + ".go": []byte(src)})
+	if err != nil {
+		return nil, err
+	}
+	if imp.pkgs == nil {
+		imp.pkgs = make(map[string]*types.Package)
+	}
+	imp.pkgs[path] = p
+	return p, nil
+}
+
+func (imp *stubImporter) check(path string, files map[string][]byte) (*types.Package, error) {
+	parsed := make([]*ast.File, 0, len(files))
+	for name, src := range files {
+		f, err := parser.ParseFile(imp.fset, name, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, f)
+	}
+	var errs []error
+	conf := types.Config{Importer: imp, Error: func(err error) { errs = append(errs, err) }}
+	p, _ := conf.Check(path, imp.fset, parsed, nil)
+	return p, errors.Join(errs...)
+}
+
+func TestSaveFunctionsPackageAPIv2(t *testing.T) {
+	defer func(old bool) { ProtoAPIv2 = old }(ProtoAPIv2)
+	ProtoAPIv2 = true
+	functions := nestedRecordFunctions(t)
+	var buf bytes.Buffer
+	if err := SaveFunctionsPackage(&buf, functions[:1], "snap", "pb", "", false); err != nil {
+		t.Fatal(err)
+	}
+	// the DATE field of the record is a *timestamppb.Timestamp, which cannot be bound
+	if s := buf.String(); !strings.Contains(s, "custom.TimeFromTimestamp(input.POwn.When)") {
+		t.Errorf("the Timestamp is not converted:\n%s", s)
+	}
+
+	// the IN OUT record is cloned, as the messages must not be copied
+	buf.Reset()
+	if err := SaveFunctionsPackage(&buf, booleanFunctions(t), "snap", "pb", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); !strings.Contains(s, "output.PRec = proto.Clone(input.PRec).(*pb.") || strings.Contains(s, "*output.PRec = *input.PRec") {
+		t.Errorf("the IN OUT record is not cloned:\n%s", s)
+	}
+}
+
+// tableFunctions returns TST_CHK.PUT_ROWS(P_ROWS IN ROWS_T, P_CODE IN VARCHAR2(3)),
+// where ROWS_T is a table of ROW_T (NUM NUMBER(5), TEXT VARCHAR2(10)).
+func tableFunctions(t *testing.T) []Function {
+	numT := &PlsType{TypeName: TypeName{Name: "NUMBER"}, Attr: "NUM", Prec: sql.NullInt64{Int64: 5, Valid: true}}
+	vcT := &PlsType{TypeName: TypeName{Name: "VARCHAR2"}, Attr: "TEXT", Length: sql.NullInt64{Int64: 10, Valid: true}}
+	rowT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_CHK", Name: "ROW_T"}, TypeCode: "PL/SQL RECORD", RecordOf: []*PlsType{numT, vcT}}
+	rowsT := &PlsType{TypeName: TypeName{Owner: "OWNR", Package: "TST_CHK", Name: "ROWS_T"}, TypeCode: "PL/SQL TABLE", IndexBy: "PLS_INTEGER", CollectionOf: rowT}
+
+	var args []UserArgument
+	ua := func(level uint8, name, dataType, typeSubname string) *UserArgument {
+		a := UserArgument{
+			PackageName: "TST_CHK", ObjectName: "PUT_ROWS", LastDDL: time.Date(2023, 8, 17, 10, 11, 12, 0, time.UTC),
+			DataLevel: level, ArgumentName: name, InOut: "IN", DataType: dataType,
+		}
+		if typeSubname != "" {
+			a.TypeOwner, a.TypeName, a.TypeSubname = "OWNR", "TST_CHK", typeSubname
+		}
+		args = append(args, a)
+		return &args[len(args)-1]
+	}
+	ua(0, "P_ROWS", "PL/SQL TABLE", "ROWS_T")
+	ua(1, "", "PL/SQL RECORD", "ROW_T")
+	ua(2, "NUM", "NUMBER", "").DataPrecision = 5
+	ua(2, "TEXT", "VARCHAR2", "").CharLength = 10
+	ua(0, "P_CODE", "VARCHAR2", "").CharLength = 3
+	snap := Snapshot{
+		Arguments: args,
+		Types: flattenTypes(map[TypeName]*PlsType{
+			numT.TypeName: numT, vcT.TypeName: vcT, rowT.TypeName: rowT, rowsT.TypeName: rowsT,
+		}),
+	}
+	functions, _, err := snap.Functions(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return functions
+}
+
+func TestGenChecks(t *testing.T) {
+	functions := tableFunctions(t)
+	if len(functions) != 1 {
+		t.Fatalf("got %d functions, wanted 1", len(functions))
+	}
+	var buf strings.Builder
+	name, err := functions[0].GenChecks(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	t.Log(s)
+	if name != "CheckPutRows_Input" {
+		t.Errorf("got %q, wanted CheckPutRows_Input", name)
+	}
+	for _, want := range []string{
+		`var vv genocall.Violations`,
+		`genocall.FieldViolation{Field: "p_code", Description: "longer than accepted (3)"}`,
+		`path := "p_rows" + "[" + strconv.Itoa(i) + "]"`,
+		`genocall.ParseDigits(string(v.Num), 5, 0)`,
+		`genocall.FieldViolation{Field: path + ".num", Description: err.Error()}`,
+		`genocall.FieldViolation{Field: path + ".text", Description: "longer than accepted (10)"}`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("%q is missing", want)
+		}
+	}
+	if strings.Contains(s, "return errors.") {
+		t.Error("returns at the first violation")
+	}
+}
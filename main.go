@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -40,19 +41,19 @@ import (
 	"github.com/UNO-SOFT/zlog/v2"
 	custom "github.com/godror/gen-o-call/custom"
 	genocall "github.com/godror/gen-o-call/lib"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
 
 	// for Oracle-specific drivers
 	godror "github.com/godror/godror"
 )
 
-//go:generate go generate ./lib
-// Should install protobuf-compiler to use it, like
-// curl -L https://github.com/google/protobuf/releases/download/v3.0.0-beta-2/protoc-3.0.0-beta-2-linux-x86_64.zip -o /tmp/protoc-3.0.0-beta-2-linux-x86_64.zip && unzip -p /tmp/protoc-3.0.0-beta-2-linux-x86_64.zip protoc >$HOME/bin/protoc
+// The .pb.go files are generated in-process; protoc is needed only with -protoc,
+// see lib/download-protoc.sh for installing it with protoc-gen-gofast.
 
 var verbose zlog.VerboseVar
 var logger = zlog.NewLogger(zlog.MaybeConsoleHandler(&verbose, os.Stderr)).SLog()
 
-var flagConnect *string
+var flagConnect, flagProtoc *string
 
 func main() {
 	genocall.SetLogger(logger.WithGroup("genocall"))
@@ -74,17 +75,55 @@ func Main(args []string) error {
 	flagPbOut := fs.String("pb-out", "", "package import path for the Protocol Buffers files, optionally with the package name, like \"my/pb-pkg:main\"")
 	flagDbOut := fs.String("db-out", "-:main", "package name of the generated functions, optionally with the package name, like \"my/db-pkg:main\"")
 	fs.BoolVar(&genocall.NumberAsString, "number-as-string", false, "add ,string to json tags")
+	fs.BoolVar(&genocall.ProtoAPIv2, "proto-api-v2", false, "generate google.golang.org/protobuf (APIv2) messages with timestamppb, instead of gogo/protobuf ones")
+	fs.BoolVar(&genocall.SQLOnly, "sql-only", false, "generate plain Go structs and a Client calling the functions through database/sql only, without Protocol Buffers and gRPC")
 	fs.BoolVar(&custom.ZeroIsAlmostZero, "zero-is-almost-zero", false, "zero should be just almost zero, to distinguish 0 and non-set field")
 	fs.Var(&verbose, "v", "verbose logging")
 	flagExcept := fs.String("except", "", "except these functions")
 	flagReplace := fs.String("replace", "", "funcA=>funcB")
 	flagTestOut := fs.Bool("test-out", false, "output test data")
 	flagJsonIn := fs.String("json", "", "JSON input data")
-	fs.IntVar(&genocall.MaxTableSize, "max-table-size", genocall.MaxTableSize, "maximum table size for PL/SQL associative arrays")
+	flagSnapshotIn := fs.String("snapshot", "", "read the functions from this snapshot file (written by -snapshot-out), instead of the database")
+	flagSnapshotOut := fs.String("snapshot-out", "", "write a snapshot of the read functions into this file")
+	flagAnnotations := fs.String("annotations", "", "read further annotations (with the names qualified by the package) from this file")
+	flagDiscoverCursors := fs.Bool("discover-cursors", false, "discover the columns of the weakly typed REF CURSOR outputs by describing their cursor-query annotation's sample query, and record them as annotations in the -annotations file")
+	flagDiscoverCall := fs.Bool("discover-cursors-call", false, "with -discover-cursors, CALL the functions (with NULL inputs) whose cursors have no cursor-query annotation - this RUNS them, with all their side effects (COMMITs in autonomous transactions, sequences, UTL_HTTP, DBMS_PIPE, AQ), even in the read-only transaction")
+	flagIncremental := fs.Bool("incremental", false, "regenerate only if the packages' DDL time differs from the one recorded in the previous output")
+	flagOpenAPI := fs.String("openapi", "", "write an OpenAPI 3 document of the functions (as called by HTTPHandler) into this file")
+	flagJSONSchema := fs.String("jsonschema-out", "", "write a JSON Schema of each input and output message into this directory")
+	flagProtoc = fs.String("protoc", "", "generate the .pb.go files with this protoc (and protoc-gen-go, gogo.proto in its paths), instead of in-process")
+	flagBoolean := fs.String("boolean", "auto", "bind the BOOLEAN arguments natively (\"native\", since Oracle 23c), as numbers (\"number\"), or by the database version (\"auto\")")
+	fs.BoolVar(&genocall.BfileContent, "bfile-content", false, "read the content of the output BFILEs, too, not just their directory and file name")
+	fs.BoolVar(&genocall.LobStreaming, "lob-stream", false, "stream the CLOB, NCLOB and BLOB arguments in chunks, with client and server streaming RPCs")
+	fs.BoolVar(&genocall.TaggedCursors, "tagged-cursors", false, "send the REF CURSOR outputs one after the other, each batch tagged with its cursor (in a oneof)")
+	fs.IntVar(&genocall.MaxTableSize, "max-table-size", genocall.MaxTableSize, "maximum size of the OUT VARCHAR2-indexed associative arrays (maps) - the other tables are read through cursors, without limit")
+	fs.IntVar(&genocall.BatchSize, "batch-size", genocall.BatchSize, "default maximum number of rows in a batch of the REF CURSOR outputs")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if genocall.SQLOnly && genocall.ProtoAPIv2 {
+		return errors.New("-sql-only and -proto-api-v2 are mutually exclusive")
+	}
+	if genocall.SQLOnly && genocall.LobStreaming {
+		return errors.New("-sql-only and -lob-stream are mutually exclusive")
+	}
+	if genocall.SQLOnly && genocall.TaggedCursors {
+		return errors.New("-sql-only and -tagged-cursors are mutually exclusive")
+	}
+	if *flagDiscoverCursors && (*flagAnnotations == "" || *flagSnapshotIn != "" || *flagJsonIn != "") {
+		return errors.New("-discover-cursors needs an -annotations file, and the database (not a -snapshot)")
+	}
+	if *flagDiscoverCall && !*flagDiscoverCursors {
+		return errors.New("-discover-cursors-call needs -discover-cursors")
+	}
+	switch *flagBoolean {
+	case "auto", "number":
+	case "native":
+		genocall.NativeBoolean = true
+	default:
+		return fmt.Errorf("-boolean=%q: wanted auto, native or number", *flagBoolean)
+	}
 	if *flagPbOut == "" {
 		if *flagDbOut == "" {
 			return errors.New("-pb-out or -db-out is required!")
@@ -142,24 +181,46 @@ func Main(args []string) error {
 			return err
 		}
 	} else {
-		db, err := sql.Open("godror", *flagConnect)
-		if err != nil {
-			return fmt.Errorf("connect to %s: %w", *flagConnect, err)
+		var snap *genocall.Snapshot
+		var tx *sql.Tx
+		if *flagSnapshotIn != "" {
+			if snap, err = genocall.ReadSnapshotFile(*flagSnapshotIn); err != nil {
+				return err
+			}
+			if fs.Arg(0) != "" {
+				snap = snap.Select(pattern)
+			}
+		} else {
+			db, err := sql.Open("godror", *flagConnect)
+			if err != nil {
+				return fmt.Errorf("connect to %s: %w", *flagConnect, err)
+			}
+			defer db.Close()
+			if verbose > 1 {
+				godror.SetLogger(zlog.NewLogger(logger.WithGroup("godror").Handler()).Logr())
+			}
+			if tx, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+				return err
+			}
+			defer tx.Rollback()
+
+			if snap, err = genocall.ReadSnapshot(ctx, tx, pattern); err != nil {
+				return fmt.Errorf("read %s: %w", fs.Arg(0), err)
+			}
 		}
-		defer db.Close()
-		if verbose > 1 {
-			godror.SetLogger(zlog.NewLogger(logger.WithGroup("godror").Handler()).Logr())
+		if *flagBoolean == "auto" {
+			genocall.NativeBoolean = snap.SupportsNativeBoolean()
 		}
-		tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
-		if err != nil {
-			return err
+		if *flagSnapshotOut != "" {
+			logger.Info("Writing snapshot", "file", *flagSnapshotOut)
+			if err = genocall.WriteSnapshotFile(*flagSnapshotOut, snap); err != nil {
+				return fmt.Errorf("write snapshot: %w", err)
+			}
 		}
-		defer tx.Rollback()
 
 		var annotations []genocall.Annotation
-		functions, annotations, err = genocall.ReadDB(ctx, tx, pattern, filter)
-		if err != nil {
-			return fmt.Errorf("read %s: %w", fs.Arg(0), err)
+		if functions, annotations, err = snap.Functions(ctx, filter); err != nil {
+			return fmt.Errorf("parse %s: %w", fs.Arg(0), err)
 		}
 		*flagReplace = strings.TrimSpace(*flagReplace)
 		for _, elt := range strings.FieldsFunc(
@@ -176,8 +237,25 @@ func Main(args []string) error {
 			}
 			annotations = append(annotations, a)
 		}
+		if *flagAnnotations != "" {
+			annots, err := genocall.ReadAnnotationsFile(*flagAnnotations)
+			if err != nil {
+				return err
+			}
+			annotations = append(annotations, annots...)
+		}
 		logger.Info("read", "annotations", annotations)
 		functions = genocall.ApplyAnnotations(functions, annotations)
+		if *flagDiscoverCursors {
+			discovered, err := genocall.DiscoverCursorColumns(ctx, tx, functions, annotations, *flagDiscoverCall)
+			if err != nil {
+				return err
+			}
+			if err = genocall.AppendAnnotationsFile(*flagAnnotations, discovered); err != nil {
+				return err
+			}
+			functions = genocall.ApplyAnnotations(functions, discovered)
+		}
 		sort.Slice(functions, func(i, j int) bool { return functions[i].FullName() < functions[j].FullName() })
 
 		if *flagTestOut {
@@ -188,61 +266,69 @@ func Main(args []string) error {
 	}
 
 	defer os.Stdout.Sync()
-	out := os.Stdout
-	var testOut *os.File
-	if dbPath != "" && dbPath != "-" {
-		fn := "genocall.go"
-		if dbPkg != "main" {
-			fn = dbPkg + ".go"
-		}
-		fn = filepath.Join(*flagBaseDir, dbPath, fn)
-		logger.Info("Writing generated functions", "file", fn)
-		os.MkdirAll(filepath.Dir(fn), 0775)
-		if out, err = os.Create(fn); err != nil {
-			return fmt.Errorf("create %s: %w", fn, err)
-		}
-		testFn := fn[:len(fn)-3] + "_test.go"
-		if testOut, err = os.Create(testFn); err != nil {
-			return fmt.Errorf("create %s: %w", testFn, err)
-		}
-		defer func() {
-			if err := out.Close(); err != nil {
-				logger.Error("close", "file", out.Name(), "error", err)
-			}
-			if err := testOut.Close(); err != nil {
-				logger.Error("close", "file", testOut.Name(), "error", err)
+	if *flagOpenAPI != "" {
+		title := dbPath
+		if title == "" || title == "-" {
+			title = dbPkg
+		}
+		var buf bytes.Buffer
+		if err := genocall.SaveOpenAPI(&buf, functions, title); err != nil {
+			return fmt.Errorf("SaveOpenAPI: %w", err)
+		}
+		if _, err := genocall.WriteFileIfChanged(*flagOpenAPI, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	if *flagJSONSchema != "" {
+		files, err := genocall.JSONSchemas(functions)
+		if err != nil {
+			return fmt.Errorf("JSONSchemas: %w", err)
+		}
+		if err = os.MkdirAll(*flagJSONSchema, 0775); err != nil {
+			return err
+		}
+		for name, b := range files {
+			if _, err := genocall.WriteFileIfChanged(filepath.Join(*flagJSONSchema, name), b); err != nil {
+				return err
 			}
-		}()
+		}
+	}
+	pbImport := pbPath
+	if pbImport == dbPath || genocall.SQLOnly {
+		pbImport = ""
 	}
+	if dbPath == "" || dbPath == "-" {
+		if *flagIncremental {
+			return errors.New("-incremental needs a -db-out directory")
+		}
+		return saveAll(functions, dbPkg, pbImport, *flagBaseDir, pbPath, pbPkg)
+	}
+	// the annotations of each package are in its fingerprint (see genocall.SavePackages)
+	fingerprint := genocall.Fingerprint(
+		"except="+*flagExcept, "replace="+*flagReplace,
+		fmt.Sprintf("zero-is-almost-zero=%t", custom.ZeroIsAlmostZero),
+		fmt.Sprintf("proto-api-v2=%t", genocall.ProtoAPIv2),
+		fmt.Sprintf("sql-only=%t", genocall.SQLOnly),
+		"db-out="+*flagDbOut, "pb-out="+*flagPbOut,
+	)
+	return savePackages(functions, *flagBaseDir, dbPath, dbPkg, pbImport, pbPath, pbPkg, fingerprint, *flagIncremental)
+}
 
+// saveAll writes all the functions to stdout, and all the messages into one proto file
+// (except with -sql-only).
+func saveAll(functions []genocall.Function, dbPkg, pbImport, baseDir, pbPath, pbPkg string) error {
 	var grp errgroup.Group
 	grp.Go(func() error {
-		pbPath := pbPath
-		if pbPath == dbPath {
-			pbPath = ""
-		}
 		if err := genocall.SaveFunctions(
-			out, functions,
-			dbPkg, pbPath, false,
+			os.Stdout, functions,
+			dbPkg, pbImport, false,
 		); err != nil {
 			return fmt.Errorf("save functions: %w", err)
 		}
 		return nil
 	})
-	if testOut != nil {
-		grp.Go(func() error {
-			pbPath := pbPath
-			if pbPath == dbPath {
-				pbPath = ""
-			}
-			if err := genocall.SaveFunctionTests(
-				testOut, functions,
-				dbPkg, pbPath, false,
-			); err != nil {
-				return fmt.Errorf("save function tests: %w", err)
-			}
-			return nil
-		})
+	if genocall.SQLOnly {
+		return grp.Wait()
 	}
 
 	grp.Go(func() error {
@@ -250,38 +336,174 @@ func Main(args []string) error {
 		if pbPkg != "main" {
 			fn = pbPkg + ".proto"
 		}
-		fn = filepath.Join(*flagBaseDir, pbPath, fn)
-		os.MkdirAll(filepath.Dir(fn), 0775)
-		logger.Info("Writing Protocol Buffers", "file", fn)
-		fh, err := os.Create(fn)
+		name := path.Join(filepath.ToSlash(pbPath), fn)
+		fd, err := genocall.ProtoDescriptor(name, functions, pbPkg)
 		if err != nil {
-			return fmt.Errorf("create proto: %w", err)
+			return fmt.Errorf("ProtoDescriptor: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := genocall.WriteProtoFile(&buf, fd); err != nil {
+			return fmt.Errorf("WriteProtoFile: %w", err)
 		}
-		err = genocall.SaveProtobuf(fh, functions, pbPkg)
-		if closeErr := fh.Close(); closeErr != nil && err == nil {
-			err = closeErr
+		fn = filepath.Join(baseDir, filepath.FromSlash(name))
+		logger.Info("Writing Protocol Buffers", "file", fn)
+		if _, err := genocall.WriteFileIfChanged(fn, buf.Bytes()); err != nil {
+			return err
 		}
+		return runProtoc(baseDir, pbPath+";"+pbPkg, []*descriptor.FileDescriptorProto{fd}, name)
+	})
+
+	return grp.Wait()
+}
+
+// savePackages writes each package's functions, tests and messages into separate files,
+// with the shared declarations and record messages in common files.
+// With -sql-only, no proto file is written.
+//
+// With incremental, the files of the packages whose DDL time and fingerprint are the same as
+// the recorded in the previous output are left intact.
+func savePackages(functions []genocall.Function, baseDir, dbPath, dbPkg, pbImport, pbPath, pbPkg, fingerprint string, incremental bool) error {
+	var names []string
+	groups := make(map[string][]genocall.Function)
+	for _, f := range functions {
+		if _, ok := groups[f.Package]; !ok {
+			names = append(names, f.Package)
+		}
+		groups[f.Package] = append(groups[f.Package], f)
+	}
+
+	dbDir, pbDir := filepath.Join(baseDir, dbPath), filepath.Join(baseDir, pbPath)
+	dirs := []string{dbDir, pbDir}
+	if genocall.SQLOnly {
+		dirs = dirs[:1]
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0775); err != nil {
+			return fmt.Errorf("create %q: %w", dir, err)
+		}
+	}
+	commonBase := "genocall"
+	if dbPkg != "main" {
+		commonBase = dbPkg
+	}
+	commonProtoBase := "genocall"
+	if pbPkg != "main" {
+		commonProtoBase = pbPkg
+	}
+	commonProto := commonProtoBase + "_common.proto"
+
+	var grp errgroup.Group
+	grp.Go(func() error {
+		written, orphans, err := genocall.SavePackages(dbDir, functions, dbPkg, pbImport, commonBase, fingerprint, incremental)
+		if len(written) != 0 {
+			logger.Info("Written generated functions", "files", written)
+		} else {
+			logger.Info("Generated functions are up to date")
+		}
+		if len(orphans) != 0 {
+			logger.Warn("These files are not for any of the packages, delete them if they are stale", "files", orphans)
+		}
+		return err
+	})
+	if genocall.SQLOnly {
+		return grp.Wait()
+	}
+
+	grp.Go(func() error {
+		// The proto files are cheap to generate, but the common messages
+		// may change with any package, so these are written only if changed.
+		var changed []string
+		commonName := path.Join(filepath.ToSlash(pbPath), commonProto)
+		fd, common, err := genocall.ProtoDescriptorCommon(commonName, functions, pbPkg)
 		if err != nil {
-			return fmt.Errorf("SaveProtobuf: %w", err)
+			return fmt.Errorf("ProtoDescriptorCommon: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := genocall.WriteProtoFile(&buf, fd); err != nil {
+			return fmt.Errorf("WriteProtoFile %s: %w", commonName, err)
 		}
+		protos := []*descriptor.FileDescriptorProto{fd}
+		if ok, err := genocall.WriteFileIfChanged(filepath.Join(pbDir, commonProto), buf.Bytes()); err != nil {
+			return err
+		} else if ok || !incremental {
+			changed = append(changed, commonName)
+		}
+		for _, name := range names {
+			protoName := path.Join(filepath.ToSlash(pbPath), strings.ToLower(name)+".proto")
+			fd, err := genocall.ProtoDescriptorService(protoName, groups[name], pbPkg,
+				genocall.CamelCase(name), commonName, common,
+			)
+			if err != nil {
+				return fmt.Errorf("ProtoDescriptorService %s: %w", name, err)
+			}
+			buf.Reset()
+			if err := genocall.WriteProtoFile(&buf, fd); err != nil {
+				return fmt.Errorf("WriteProtoFile %s: %w", protoName, err)
+			}
+			protos = append(protos, fd)
+			if ok, err := genocall.WriteFileIfChanged(filepath.Join(pbDir, strings.ToLower(name)+".proto"), buf.Bytes()); err != nil {
+				return err
+			} else if ok || !incremental {
+				changed = append(changed, protoName)
+			}
+		}
+		if len(changed) == 0 {
+			logger.Info("Protocol Buffers are up to date")
+			return nil
+		}
+		logger.Info("Writing Protocol Buffers", "files", changed)
+		return runProtoc(baseDir, pbPath+";"+pbPkg, protos, changed...)
+	})
 
-		goOut := "go_out"
-		cmd := exec.Command(
-			"protoc",
-			"--proto_path="+*flagBaseDir+":.",
-			"--"+goOut+"=Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types,plugins=grpc:"+*flagBaseDir,
-			fn,
-		)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("%q: %w", cmd.Args, err)
+	return grp.Wait()
+}
+
+// runProtoc generates the .pb.go files of the proto files (named relative to baseDir),
+// in-process from their descriptors, or with the -protoc command.
+//
+// goPackage is the Go import path and package name of the files ("path;name"), for APIv2.
+func runProtoc(baseDir, goPackage string, protos []*descriptor.FileDescriptorProto, names ...string) error {
+	if *flagProtoc == "" {
+		var generated map[string][]byte
+		var err error
+		if genocall.ProtoAPIv2 {
+			generated, err = genocall.GenerateProtoGoV2(goPackage, protos, names...)
+		} else {
+			generated, err = genocall.GenerateProtoGo(genocall.ProtoGoParameter, protos, names...)
+		}
+		if err != nil {
+			return err
+		}
+		for nm, b := range generated {
+			if _, err := genocall.WriteFileIfChanged(filepath.Join(baseDir, filepath.FromSlash(nm)), b); err != nil {
+				return err
+			}
 		}
 		return nil
-	})
+	}
 
-	if err := grp.Wait(); err != nil {
-		return err
+	files := make([]string, len(names))
+	for i, nm := range names {
+		files[i] = filepath.Join(baseDir, filepath.FromSlash(nm))
+	}
+	args := []string{
+		"--proto_path=" + baseDir + ":.",
+		"--go_out=" + genocall.ProtoGoParameter + ":" + baseDir,
+	}
+	if genocall.ProtoAPIv2 {
+		var mapping string
+		for _, nm := range names {
+			mapping += ",M" + nm + "=" + goPackage
+		}
+		args = append(args[:1],
+			"--go_out=paths=import"+mapping+":"+baseDir,
+			"--go-grpc_out=paths=import,require_unimplemented_servers=false"+mapping+":"+baseDir)
+	}
+	cmd := exec.Command(*flagProtoc, append(args, files...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%q: %w", cmd.Args, err)
 	}
 	return nil
 }
@@ -0,0 +1,107 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package orsrv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+// OraCodes maps the Oracle error numbers (ORA-nnnnn) to the gRPC codes of StatusError.
+var OraCodes = map[int]codes.Code{
+	1:     codes.AlreadyExists,      // unique constraint violated
+	54:    codes.Unavailable,        // resource busy and acquire with NOWAIT specified or timeout expired
+	60:    codes.Aborted,            // deadlock detected while waiting for resource
+	1013:  codes.Canceled,           // user requested cancel of current operation
+	1400:  codes.InvalidArgument,    // cannot insert NULL
+	1403:  codes.NotFound,           // no data found
+	1722:  codes.InvalidArgument,    // invalid number
+	2291:  codes.FailedPrecondition, // integrity constraint violated - parent key not found
+	2292:  codes.FailedPrecondition, // integrity constraint violated - child record found
+	3113:  codes.Unavailable,        // end-of-file on communication channel
+	3114:  codes.Unavailable,        // not connected to ORACLE
+	3135:  codes.Unavailable,        // connection lost contact
+	8177:  codes.Aborted,            // can't serialize access for this transaction
+	12899: codes.InvalidArgument,    // value too large for column
+	30006: codes.Unavailable,        // resource busy; acquire with WAIT timeout expired
+}
+
+// OraCodeRange is a range of Oracle error numbers, From and To inclusive, with its gRPC code.
+type OraCodeRange struct {
+	From, To int
+	Code     codes.Code
+}
+
+// OraCodeRanges maps the ranges of Oracle error numbers to the gRPC codes of StatusError,
+// for the numbers not in OraCodes. The first matching range is used.
+var OraCodeRanges = []OraCodeRange{
+	{From: 20000, To: 20999, Code: codes.FailedPrecondition}, // RAISE_APPLICATION_ERROR
+}
+
+// OraErrorDomain is the Domain of the google.rpc.ErrorInfo details of the Oracle errors.
+var OraErrorDomain = "oracle.com"
+
+// OraCode returns the gRPC code of the Oracle error number, by OraCodes and OraCodeRanges
+// - codes.Unknown if none of them has it.
+func OraCode(number int) codes.Code {
+	if code, ok := OraCodes[number]; ok {
+		return code
+	}
+	for _, r := range OraCodeRanges {
+		if r.From <= number && number <= r.To {
+			return r.Code
+		}
+	}
+	return codes.Unknown
+}
+
+// oraError is an Oracle error, such as *godror.OraErr.
+type oraError interface {
+	error
+	Code() int
+	Message() string
+}
+
+// asOraError returns the Oracle error in the chain of err, if any.
+func asOraError(err error) (oraError, bool) {
+	var oe oraError
+	if !errors.As(err, &oe) || oe.Code() == 0 {
+		return nil, false
+	}
+	return oe, true
+}
+
+// oraErrorInfo returns the google.rpc.ErrorInfo detail of the Oracle error:
+// ORA_nnnnn as the reason, and the code (ORA-nnnnn), the message and
+// the PL/SQL backtrace (the ORA-06512 lines) in the metadata.
+func oraErrorInfo(oe oraError) *errdetails.ErrorInfo {
+	ora := fmt.Sprintf("ORA-%05d", oe.Code())
+	lines := strings.Split(strings.TrimSpace(oe.Message()), "\n")
+	message := strings.TrimSpace(strings.TrimPrefix(lines[0], ora+":"))
+	var backtrace []string
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "ORA-06512:") {
+			backtrace = append(backtrace, line)
+		}
+	}
+	info := &errdetails.ErrorInfo{
+		Reason: "ORA_" + ora[4:],
+		Domain: OraErrorDomain,
+		Metadata: map[string]string{
+			"code":    ora,
+			"number":  strconv.Itoa(oe.Code()),
+			"message": message,
+		},
+	}
+	if len(backtrace) != 0 {
+		info.Metadata["backtrace"] = strings.Join(backtrace, "\n")
+	}
+	return info
+}
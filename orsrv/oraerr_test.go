@@ -0,0 +1,98 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package orsrv
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	genocall "github.com/godror/gen-o-call/lib"
+	errors "golang.org/x/xerrors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// oraErr is an Oracle error, as *godror.OraErr.
+type oraErr struct {
+	code    int
+	message string
+}
+
+func (oe *oraErr) Error() string   { return fmt.Sprintf("ORA-%05d: %s", oe.code, oe.message) }
+func (oe *oraErr) Code() int       { return oe.code }
+func (oe *oraErr) Message() string { return oe.message }
+
+func TestOraCode(t *testing.T) {
+	for number, want := range map[int]codes.Code{
+		1:     codes.AlreadyExists,
+		1403:  codes.NotFound,
+		54:    codes.Unavailable,
+		1013:  codes.Canceled,
+		20000: codes.FailedPrecondition,
+		20999: codes.FailedPrecondition,
+		21000: codes.Unknown,
+		942:   codes.Unknown,
+	} {
+		if got := OraCode(number); got != want {
+			t.Errorf("ORA-%05d: got %v, wanted %v", number, got, want)
+		}
+	}
+}
+
+func TestStatusErrorOra(t *testing.T) {
+	err := errors.Errorf("call: %w", &oraErr{code: 20001, message: `no such customer: 42
+ORA-06512: at "OWNR.TST_CUST", line 12
+ORA-06512: at line 1`})
+	s := status.Convert(StatusError(err))
+	if s.Code() != codes.FailedPrecondition {
+		t.Errorf("got %v, wanted %v", s.Code(), codes.FailedPrecondition)
+	}
+	var info *errdetails.ErrorInfo
+	for _, d := range s.Details() {
+		if ei, ok := d.(*errdetails.ErrorInfo); ok {
+			info = ei
+		}
+	}
+	if info == nil {
+		t.Fatalf("no ErrorInfo in %v", s.Details())
+	}
+	if info.Reason != "ORA_20001" || info.Domain != OraErrorDomain {
+		t.Errorf("got reason %q, domain %q", info.Reason, info.Domain)
+	}
+	for k, want := range map[string]string{
+		"code":      "ORA-20001",
+		"number":    "20001",
+		"message":   "no such customer: 42",
+		"backtrace": "ORA-06512: at \"OWNR.TST_CUST\", line 12\nORA-06512: at line 1",
+	} {
+		if got := info.Metadata[k]; got != want {
+			t.Errorf("%s: got %q, wanted %q", k, got, want)
+		}
+	}
+
+	if err := StatusError(errors.New("plain")); status.Code(err) != codes.Unknown {
+		t.Errorf("plain error: got %v", status.Code(err))
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for _, tC := range []struct {
+		err  error
+		want int
+	}{
+		{&oraErr{code: 1403, message: "no data found"}, http.StatusNotFound},
+		{&oraErr{code: 1, message: "unique constraint violated"}, http.StatusConflict},
+		{&oraErr{code: 54, message: "resource busy"}, http.StatusServiceUnavailable},
+		{errors.Errorf("call: %w", &oraErr{code: 20001, message: "no such customer"}), http.StatusBadRequest},
+		{genocall.Violations{{Field: "p_a", Description: "too long"}}, http.StatusBadRequest},
+		{errors.New("plain"), http.StatusInternalServerError},
+	} {
+		if got := genocall.HTTPStatus(tC.err); got != tC.want {
+			t.Errorf("%v: got %d, wanted %d", tC.err, got, tC.want)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/go-stack/stack"
 	"github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	_ "google.golang.org/grpc/encoding/gzip"
@@ -176,6 +177,16 @@ func GRPCServer(globalCtx context.Context, logger log.Logger, verbose bool, chec
 	return grpc.NewServer(append(opts, options...)...)
 }
 
+func init() {
+	// the generated HTTPHandler responds with the status of these codes, too
+	genocall.StatusError = StatusError
+}
+
+// StatusError returns err as a gRPC status error - genocall.Violations as InvalidArgument,
+// with a google.rpc.BadRequest detail listing the fields.
+//
+// Oracle errors get their code by OraCode, with a google.rpc.ErrorInfo detail
+// of the ORA- code, the message and the PL/SQL backtrace.
 func StatusError(err error) error {
 	if err == nil {
 		return err
@@ -184,15 +195,33 @@ func StatusError(err error) error {
 	var sc interface {
 		Code() codes.Code
 	}
+	oe, isOra := asOraError(err)
 	if errors.Is(err, genocall.ErrInvalidArgument) {
 		code = codes.InvalidArgument
 	} else if errors.As(err, &sc) {
 		code = sc.Code()
+	} else if isOra {
+		code = OraCode(oe.Code())
 	}
 	if code == 0 {
 		return err
 	}
 	s := status.New(code, err.Error())
+	if isOra {
+		if sd, sErr := s.WithDetails(oraErrorInfo(oe)); sErr == nil {
+			s = sd
+		}
+	}
+	var vv genocall.Violations
+	if errors.As(err, &vv) {
+		br := &errdetails.BadRequest{FieldViolations: make([]*errdetails.BadRequest_FieldViolation, len(vv))}
+		for i, v := range vv {
+			br.FieldViolations[i] = &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Description}
+		}
+		if sd, sErr := s.WithDetails(br); sErr == nil {
+			return sd.Err()
+		}
+	}
 	if sd, sErr := s.WithDetails(&pbMessage{Message: fmt.Sprintf("%+v", err)}); sErr == nil {
 		s = sd
 	}
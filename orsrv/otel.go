@@ -0,0 +1,185 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package orsrv
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	genocall "github.com/godror/gen-o-call/lib"
+	godror "github.com/godror/godror"
+	"github.com/grpc-ecosystem/go-grpc-middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// InstrumentationName is the name of the tracer and the meter of the OpenTelemetry interceptors.
+const InstrumentationName = "github.com/godror/gen-o-call/orsrv"
+
+// The attributes of the spans and the metrics.
+const (
+	attrMethod   = attribute.Key("rpc.method")
+	attrCode     = attribute.Key("rpc.grpc.status_code")
+	attrFunction = attribute.Key("plsql.function")
+	attrPhase    = attribute.Key("plsql.phase")
+	attrOraCode  = attribute.Key("ora.code")
+)
+
+// Telemetry returns the server options (for GRPCServer) of the OpenTelemetry interceptors:
+// each RPC is a span (continuing the trace of the incoming metadata, by the global propagator),
+// tagged with the called PL/SQL function, with a child span for its prepare and exec.
+// DBMS_APPLICATION_INFO of the session gets the function as the module, and the trace ID as the action.
+//
+// The metrics are the latency histograms of the methods (genocall.rpc.duration)
+// and of the phases of the PL/SQL functions (genocall.plsql.duration), and the counter
+// of the ORA- errors of the methods (genocall.ora.errors).
+//
+// The global providers are used for the nil tp and mp.
+func Telemetry(tp trace.TracerProvider, mp metric.MeterProvider) ([]grpc.ServerOption, error) {
+	t, err := newTelemetry(tp, mp)
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(t.unary),
+		grpc.ChainStreamInterceptor(t.stream),
+	}, nil
+}
+
+type telemetry struct {
+	tracer        trace.Tracer
+	rpcDuration   metric.Float64Histogram
+	plsqlDuration metric.Float64Histogram
+	oraErrors     metric.Int64Counter
+}
+
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) (telemetry, error) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	t := telemetry{tracer: tp.Tracer(InstrumentationName)}
+	meter := mp.Meter(InstrumentationName)
+	var err error
+	if t.rpcDuration, err = meter.Float64Histogram("genocall.rpc.duration",
+		metric.WithUnit("s"), metric.WithDescription("The duration of the RPCs, by method."),
+	); err != nil {
+		return t, err
+	}
+	if t.plsqlDuration, err = meter.Float64Histogram("genocall.plsql.duration",
+		metric.WithUnit("s"), metric.WithDescription("The duration of the prepare and exec of the PL/SQL functions."),
+	); err != nil {
+		return t, err
+	}
+	if t.oraErrors, err = meter.Int64Counter("genocall.ora.errors",
+		metric.WithDescription("The number of ORA- errors, by method and code."),
+	); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+func (t telemetry) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, end := t.start(ctx, info.FullMethod)
+	res, err := handler(ctx, req)
+	end(err)
+	return res, err
+}
+
+func (t telemetry) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, end := t.start(ss.Context(), info.FullMethod)
+	wss := grpc_middleware.WrapServerStream(ss)
+	wss.WrappedContext = ctx
+	err := handler(srv, wss)
+	end(err)
+	return err
+}
+
+// start starts the span of the RPC, with the Tracer of the generated functions in the context,
+// and returns the func ending it with the error of the RPC.
+func (t telemetry) start(ctx context.Context, fullMethod string) (context.Context, func(error)) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	}
+	ctx, span := t.tracer.Start(ctx, fullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("rpc.system", "grpc"), attrMethod.String(fullMethod)),
+	)
+	ctx = genocall.ContextWithTracer(ctx, t.traceCall)
+	start := time.Now()
+	return ctx, func(err error) {
+		code := status.Code(StatusError(err))
+		attrs := []attribute.KeyValue{attrMethod.String(fullMethod), attrCode.Int(int(code))}
+		t.rpcDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		if oe, ok := asOraError(err); ok {
+			t.oraErrors.Add(ctx, 1, metric.WithAttributes(attrMethod.String(fullMethod),
+				attrOraCode.String(fmt.Sprintf("ORA-%05d", oe.Code()))))
+		}
+		span.SetAttributes(attrCode.Int(int(code)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// traceCall is the genocall.Tracer of the RPCs: it tags the span of the RPC with the PL/SQL function,
+// and starts the child span of the phase.
+func (t telemetry) traceCall(ctx context.Context, funName, phase string) (context.Context, func(error)) {
+	parent := trace.SpanFromContext(ctx)
+	parent.SetAttributes(attrFunction.String(funName))
+	tt := godror.TraceTag{Module: truncate(funName, 48)}
+	if sc := parent.SpanContext(); sc.HasTraceID() {
+		tt.Action = truncate(sc.TraceID().String(), 32)
+	}
+	ctx = godror.ContextWithTraceTag(ctx, tt)
+	attrs := []attribute.KeyValue{attrFunction.String(funName), attrPhase.String(phase)}
+	ctx, span := t.tracer.Start(ctx, phase+" "+funName, trace.WithAttributes(attrs...))
+	start := time.Now()
+	return ctx, func(err error) {
+		t.plsqlDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// truncate s to at most n bytes - DBMS_APPLICATION_INFO limits the module to 48, the action to 32.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// metadataCarrier is the propagation.TextMapCarrier of the gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if vv := metadata.MD(mc).Get(key); len(vv) != 0 {
+		return vv[0]
+	}
+	return ""
+}
+func (mc metadataCarrier) Set(key, value string) { metadata.MD(mc).Set(key, value) }
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Tamás Gulácsi
+//
+// SPDX-License-Identifier: UPL-1.0 OR Apache-2.0
+
+package orsrv
+
+import (
+	"context"
+	"testing"
+
+	genocall "github.com/godror/gen-o-call/lib"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+)
+
+func TestTelemetry(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	tm, err := newTelemetry(tp, mp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const method = "/tst_cust.TstCust/GetCustomer"
+	_, err = tm.unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, prepared := genocall.TraceCall(ctx, "tst_cust.get_customer", "prepare")
+			prepared(nil)
+			_, executed := genocall.TraceCall(ctx, "tst_cust.get_customer", "exec")
+			err := &oraErr{code: 1403, message: "no data found"}
+			executed(err)
+			return nil, err
+		})
+	if err == nil {
+		t.Fatal("wanted the error of the handler")
+	}
+
+	ended := spans.Ended()
+	var names []string
+	for _, s := range ended {
+		names = append(names, s.Name())
+	}
+	if len(ended) != 3 {
+		t.Fatalf("got spans %q, wanted 3", names)
+	}
+	rpc := ended[2]
+	if rpc.Name() != method {
+		t.Errorf("got span %q, wanted %q", rpc.Name(), method)
+	}
+	if !hasAttr(rpc.Attributes(), attrFunction.String("tst_cust.get_customer")) {
+		t.Errorf("the function is not in the attributes of the RPC span: %v", rpc.Attributes())
+	}
+	for i, want := range []string{"prepare tst_cust.get_customer", "exec tst_cust.get_customer"} {
+		if s := ended[i]; s.Name() != want {
+			t.Errorf("%d. got span %q, wanted %q", i, s.Name(), want)
+		} else if s.Parent().SpanID() != rpc.SpanContext().SpanID() {
+			t.Errorf("%q is not a child of the RPC span", s.Name())
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+	for _, name := range []string{"genocall.rpc.duration", "genocall.plsql.duration"} {
+		if h, ok := got[name].(metricdata.Histogram[float64]); !ok || len(h.DataPoints) == 0 {
+			t.Errorf("%s: got %#v", name, got[name])
+		}
+	}
+	if s, ok := got["genocall.ora.errors"].(metricdata.Sum[int64]); !ok || len(s.DataPoints) != 1 {
+		t.Errorf("genocall.ora.errors: got %#v", got["genocall.ora.errors"])
+	} else if dp := s.DataPoints[0]; dp.Value != 1 || !hasAttr(dp.Attributes.ToSlice(), attrOraCode.String("ORA-01403")) {
+		t.Errorf("genocall.ora.errors: got %d of %v", dp.Value, dp.Attributes.ToSlice())
+	}
+}
+
+func hasAttr(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"log/slog"
 	"os/exec"
 	//"encoding/base64"
 	"encoding/json"
@@ -26,7 +27,6 @@ import (
 	_ "github.com/godror/godror"
 	"github.com/google/renameio/v2"
 	"github.com/kortschak/utter"
-	"golang.org/x/exp/slog"
 	"golang.org/x/tools/txtar"
 )
 
@@ -99,8 +99,7 @@ plugins:
 }
 
 func TestReadDB(t *testing.T) {
-	slog.SetDefault(slog.New(slog.HandlerOptions{Level: slog.LevelDebug}.
-		NewTextHandler(testWriter{t})))
+	slog.SetDefault(slog.New(slog.NewTextHandler(testWriter{t}, &slog.HandlerOptions{Level: slog.LevelDebug})))
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	db, err := sql.Open("godror", *flagConnect)
@@ -191,8 +190,7 @@ END;`,
 }
 
 func TestParseCSV(t *testing.T) {
-	slog.SetDefault(slog.New(slog.HandlerOptions{Level: slog.LevelDebug}.
-		NewTextHandler(testWriter{t})))
+	slog.SetDefault(slog.New(slog.NewTextHandler(testWriter{t}, &slog.HandlerOptions{Level: slog.LevelDebug})))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"go/format"
 	"io"
+	"log/slog"
 	"os"
 	"regexp"
 	"sort"
@@ -16,8 +17,6 @@ import (
 	"text/template"
 
 	"github.com/godror/godror"
-
-	"golang.org/x/exp/slog"
 )
 
 // MaxTableSize is the maximum size of the array elements
@@ -448,7 +447,6 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 				//name := capitalize(replHidden(arg.Name))
 				//convIn, convOut = arg.getConvSimpleTable(convIn, convOut, name, addParam(arg.Name), maxTableSize)
 				panic("getConvSimpleTable is missing")
-				continue
 			}
 			if !arg.Type.IsCollection {
 				name := (CamelCase(arg.Name))
@@ -497,7 +495,6 @@ func (fun Function) prepareCall() (decls, pre []string, call string, post []stri
 			//name := capitalize(replHidden(arg.Name))
 			//convIn, convOut = arg.getConvSimpleTable(convIn, convOut, name, addParam(arg.Name), maxTableSize)
 			panic("getConvSimpleTable is missing2")
-			continue
 		}
 
 		// Object, maybe Collection
@@ -11,11 +11,10 @@ import (
 	"fmt"
 	"io"
 	//"regexp"
+	"log/slog"
 	"strings"
 	"sync"
 	"unicode"
-
-	"golang.org/x/exp/slog"
 )
 
 // go:generate sh ./download-protoc.sh